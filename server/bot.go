@@ -10,7 +10,12 @@ import (
 
 // PostBotDM posts a DM as the cloud bot user.
 func (p *Plugin) PostBotDM(userID string, message string) {
-	p.createBotPostDM(&model.Post{
+	_ = p.postBotDM(userID, message)
+}
+
+// postBotDM posts a DM like PostBotDM, and returns an error if it could not be posted
+func (p *Plugin) postBotDM(userID string, message string) error {
+	return p.createBotPostDM(&model.Post{
 		UserId:  p.BotUserID,
 		Message: message,
 	}, userID)
@@ -18,7 +23,7 @@ func (p *Plugin) PostBotDM(userID string, message string) {
 
 // PostBotCustomDM posts a DM as the cloud bot user using custom post with action buttons.
 func (p *Plugin) PostBotCustomDM(userID, message, todo, postPermalink, issueID string) {
-	p.createBotPostDM(&model.Post{
+	_ = p.createBotPostDM(&model.Post{
 		UserId:  p.BotUserID,
 		Message: message + ": " + todo,
 		Type:    "custom_todo",
@@ -32,16 +37,18 @@ func (p *Plugin) PostBotCustomDM(userID, message, todo, postPermalink, issueID s
 	}, userID)
 }
 
-func (p *Plugin) createBotPostDM(post *model.Post, userID string) {
+// createBotPostDM posts post in the DM of the bot with userID. Failures are logged, and
+// returned for the callers that need to know whether the DM was posted.
+func (p *Plugin) createBotPostDM(post *model.Post, userID string) error {
 	channel, appError := p.API.GetDirectChannel(userID, p.BotUserID)
 
 	if appError != nil {
 		p.API.LogError("Unable to get direct channel for bot err=" + appError.Error())
-		return
+		return appError
 	}
 	if channel == nil {
 		p.API.LogError("Could not get direct channel for bot and user_id=%s", userID)
-		return
+		return errors.New("direct channel not found")
 	}
 
 	post.ChannelId = channel.Id
@@ -49,7 +56,9 @@ func (p *Plugin) createBotPostDM(post *model.Post, userID string) {
 
 	if appError != nil {
 		p.API.LogError("Unable to create bot post DM err=" + appError.Error())
+		return appError
 	}
+	return nil
 }
 
 // ReplyPostBot post a message and a todo in the same thread as the post postID
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// channelListLimit is the maximum number of open todos returned for the list of a channel
+const channelListLimit = 200
+
+// ErrNotChannelMember is returned when a user works on the todos of a channel they are not a
+// member of
+var ErrNotChannelMember = errors.New("not a member of the channel")
+
+// ChannelIssues are the todos of the list of a channel
+type ChannelIssues struct {
+	ChannelID string   `json:"channel_id"`
+	Open      []*Issue `json:"open"`
+	// Done holds the most recently completed todos, up to doneListLimit
+	Done []*Issue `json:"done"`
+}
+
+// isChannelMember returns true if userID is a member of channelID. The todos of a channel
+// list are visible to the members of the channel only.
+func (l *listManager) isChannelMember(channelID, userID string) bool {
+	_, appErr := l.api.GetChannelMember(channelID, userID)
+	return appErr == nil
+}
+
+// getMemberChannelIDs returns the IDs of the channels userID is a member of, in all their teams
+func (l *listManager) getMemberChannelIDs(userID string) ([]string, error) {
+	teams, appErr := l.api.GetTeamsForUser(userID)
+	if appErr != nil {
+		return nil, errors.Wrap(appErr, "failed to get the teams of the user")
+	}
+
+	// Direct and group messages are returned for every team.
+	found := map[string]bool{}
+	var channelIDs []string
+	for _, team := range teams {
+		channels, appErr := l.api.GetChannelsForTeamForUser(team.Id, userID, false)
+		if appErr != nil {
+			return nil, errors.Wrap(appErr, "failed to get the channels of the user")
+		}
+		for _, channel := range channels {
+			if !found[channel.Id] {
+				found[channel.Id] = true
+				channelIDs = append(channelIDs, channel.Id)
+			}
+		}
+	}
+	return channelIDs, nil
+}
+
+// AddChannelIssue adds a todo with the message to the list of channelID
+func (l *listManager) AddChannelIssue(userID, channelID, message, description string) (*Issue, error) {
+	if !l.isChannelMember(channelID, userID) {
+		return nil, ErrNotChannelMember
+	}
+
+	message = SanitizeInput(message)
+	description = SanitizeMultiline(description)
+	issue := newIssue(message, "", description, "", userID, "", StatusOpen, 0, 0)
+	issue.ChannelID = channelID
+
+	err := l.transaction(func(tx *listManager) error {
+		if err := tx.store.SaveIssue(issue); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(issue.ID, userID, "create", channelID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
+
+// GetChannelIssues returns the open todos of the list of channelID, most recently added first,
+// and its most recently completed ones
+func (l *listManager) GetChannelIssues(userID, channelID string) (*ChannelIssues, error) {
+	if !l.isChannelMember(channelID, userID) {
+		return nil, ErrNotChannelMember
+	}
+
+	open, err := l.store.GetChannelIssues(channelID, StatusOpen, channelListLimit)
+	if err != nil {
+		return nil, err
+	}
+	done, err := l.store.GetChannelIssues(channelID, StatusDone, doneListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := &ChannelIssues{ChannelID: channelID, Open: open, Done: done}
+	if issues.Open == nil {
+		issues.Open = []*Issue{}
+	}
+	if issues.Done == nil {
+		issues.Done = []*Issue{}
+	}
+	return issues, nil
+}
+
+// CompleteChannelIssue completes the channel todo issueID on behalf of its channel
+func (l *listManager) CompleteChannelIssue(userID, issueID string) (issue *Issue, err error) {
+	err = l.transaction(func(tx *listManager) error {
+		issue, err = tx.store.GetIssue(issueID)
+		if err != nil {
+			return err
+		}
+		if issue.ChannelID == "" {
+			return fmt.Errorf("todo %s is not a channel todo", issueID)
+		}
+		if !tx.isChannelMember(issue.ChannelID, userID) {
+			return ErrNotChannelMember
+		}
+		if err = checkTransition(issue.Status, StatusDone); err != nil {
+			return err
+		}
+
+		if issue, err = tx.setIssueStatus(issueID, StatusDone); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(issueID, userID, "complete", issue.ChannelID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelIssues(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	const channelID = "testchannelid0000000000000"
+	api := l.api.(*plugintest.API)
+	api.On("GetChannelMember", channelID, testUserID).Return(&model.ChannelMember{}, nil)
+	api.On("GetChannelMember", channelID, testOtherID).Return(&model.ChannelMember{}, nil)
+	api.On("GetChannelMember", channelID, testThirdID).Return(nil, model.NewAppError("GetChannelMember", "not_found", nil, "", http.StatusNotFound))
+
+	notes, err := l.AddChannelIssue(testUserID, channelID, "write the release notes", "")
+	require.NoError(t, err)
+	tag, err := l.AddChannelIssue(testOtherID, channelID, "tag the release", "")
+	require.NoError(t, err)
+	_, err = l.AddChannelIssue(testThirdID, channelID, "ship it", "")
+	assert.ErrorIs(t, err, ErrNotChannelMember)
+
+	my, err := l.GetIssueList(testUserID, MyListKey)
+	require.NoError(t, err)
+	assert.Empty(t, my, "channel todos are not in the lists of their creator")
+
+	issues, err := l.GetChannelIssues(testOtherID, channelID)
+	require.NoError(t, err)
+	require.Len(t, issues.Open, 2)
+	assert.ElementsMatch(t, []string{tag.ID, notes.ID}, []string{issues.Open[0].ID, issues.Open[1].ID})
+	_, err = l.GetChannelIssues(testThirdID, channelID)
+	assert.ErrorIs(t, err, ErrNotChannelMember)
+
+	authorized, err := l.IsAuthorized(notes.ID, testOtherID)
+	require.NoError(t, err)
+	assert.True(t, authorized, "the members of the channel can work on its todos")
+	authorized, err = l.IsAuthorized(notes.ID, testThirdID)
+	require.NoError(t, err)
+	assert.False(t, authorized)
+
+	_, err = l.CompleteChannelIssue(testOtherID, notes.ID)
+	require.NoError(t, err)
+	issues, err = l.GetChannelIssues(testUserID, channelID)
+	require.NoError(t, err)
+	require.Len(t, issues.Open, 1)
+	require.Len(t, issues.Done, 1)
+	assert.Equal(t, notes.ID, issues.Done[0].ID)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+// AddChecklistItem appends an item to the checklist of todoID
+func (l *listManager) AddChecklistItem(userID, todoID, message string) (*ChecklistItem, error) {
+	message = SanitizeInput(message)
+	if message == "" {
+		return nil, errors.New("the checklist item is empty")
+	}
+
+	item := &ChecklistItem{
+		TodoID:  todoID,
+		Message: message,
+	}
+
+	err := l.transaction(func(tx *listManager) error {
+		if _, err := tx.store.GetIssue(todoID); err != nil {
+			return err
+		}
+
+		items, err := tx.store.GetChecklist(todoID)
+		if err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			item.SortOrder = items[len(items)-1].SortOrder + 1
+		}
+
+		if err := tx.store.SaveChecklistItem(item); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(todoID, userID, "add_checklist_item", item.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// GetChecklist returns the checklist items of todoID, in their order
+func (l *listManager) GetChecklist(todoID string) ([]*ChecklistItem, error) {
+	return l.store.GetChecklist(todoID)
+}
+
+// CheckChecklistItem checks or unchecks the checklist item itemID. When checking the last
+// unchecked item of a todo set to auto-complete, the todo is completed for its owner and
+// returned with the foreign ID and list to update, as returned by CompleteIssue.
+func (l *listManager) CheckChecklistItem(userID, itemID string, checked bool) (item *ChecklistItem, completed *Issue, foreignID string, listToUpdate string, err error) {
+	err = l.transaction(func(tx *listManager) error {
+		item, err = tx.store.GetChecklistItem(itemID)
+		if err != nil {
+			return err
+		}
+		if item.Checked == checked {
+			return nil
+		}
+
+		item.Checked = checked
+		item.CheckedAt = 0
+		action := "uncheck_checklist_item"
+		if checked {
+			item.CheckedAt = model.GetMillis()
+			action = "check_checklist_item"
+		}
+		if err = tx.store.SaveChecklistItem(item); err != nil {
+			return err
+		}
+		if err = tx.recordAuditLog(item.TodoID, userID, action, item.ID); err != nil {
+			return err
+		}
+
+		if !checked {
+			return nil
+		}
+		completed, foreignID, listToUpdate, err = tx.autoCompleteIssue(item.TodoID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	return item, completed, foreignID, listToUpdate, nil
+}
+
+// autoCompleteIssue completes todoID for its owner if it is set to auto-complete, is in the
+// owner's My list or one of their custom lists, and all its checklist items are checked. It
+// returns the results of CompleteIssue, or a nil issue if the todo is not completed.
+func (l *listManager) autoCompleteIssue(todoID string) (*Issue, string, string, error) {
+	issue, err := l.store.GetIssue(todoID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if !issue.AutoComplete {
+		return nil, "", "", nil
+	}
+	ownerID := issue.OwnerID()
+	if list, ok := issue.ListFor(ownerID); !ok || !isOwnedList(list) {
+		return nil, "", "", nil
+	}
+
+	progress, err := l.store.GetChecklistProgress([]string{todoID})
+	if err != nil {
+		return nil, "", "", err
+	}
+	if !progress[todoID].IsComplete() {
+		return nil, "", "", nil
+	}
+
+	return l.CompleteIssue(ownerID, todoID)
+}
+
+// DeleteChecklistItem removes the checklist item itemID and returns it
+func (l *listManager) DeleteChecklistItem(userID, itemID string) (*ChecklistItem, error) {
+	var item *ChecklistItem
+	err := l.transaction(func(tx *listManager) error {
+		var err error
+		item, err = tx.store.GetChecklistItem(itemID)
+		if err != nil {
+			return err
+		}
+
+		if err = tx.store.DeleteChecklistItem(itemID); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(item.TodoID, userID, "delete_checklist_item", itemID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// SetChecklistAutoComplete sets whether todoID is completed when all its checklist items are
+// checked
+func (l *listManager) SetChecklistAutoComplete(userID, todoID string, enabled bool) error {
+	return l.transaction(func(tx *listManager) error {
+		issue, err := tx.store.GetIssue(todoID)
+		if err != nil {
+			return err
+		}
+		if issue.AutoComplete == enabled {
+			return nil
+		}
+
+		issue.AutoComplete = enabled
+		if err := tx.store.UpdateIssue(issue); err != nil {
+			return err
+		}
+
+		metadata := "off"
+		if enabled {
+			metadata = "on"
+		}
+		return tx.recordAuditLog(todoID, userID, "set_auto_complete", metadata)
+	})
+}
+
+// addChecklistProgress sets the checklist progress of issues
+func (l *listManager) addChecklistProgress(issues []*ExtendedIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	todoIDs := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		todoIDs = append(todoIDs, issue.ID)
+	}
+
+	progress, err := l.store.GetChecklistProgress(todoIDs)
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		issue.Checklist = progress[issue.ID]
+	}
+	return nil
+}
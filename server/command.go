@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"time"
@@ -18,6 +20,7 @@ const (
 	MyFlag            = "my"
 	InFlag            = "in"
 	OutFlag           = "out"
+	DoneFlag          = "done"
 )
 
 func (p *Plugin) getHelp(userID string) string {
@@ -51,7 +54,7 @@ func getCommand() *model.Command {
 		DisplayName:      "Todo Bot",
 		Description:      "Interact with your Todo list.",
 		AutoComplete:     true,
-		AutoCompleteDesc: "Available commands: add, list, pop, send, help",
+		AutoCompleteDesc: "Available commands: add, list, pop, send, search, restore, undo, check, repeat, tag, untag, lists, move, status, channel, team, watch, unwatch, decline, help",
 		AutoCompleteHint: "[command]",
 		AutocompleteData: getAutocompleteData(),
 	}
@@ -66,6 +69,40 @@ func (p *Plugin) postCommandResponse(args *model.CommandArgs, text string) {
 	_ = p.API.SendEphemeralPost(args.UserId, post)
 }
 
+// undoContextKey is the key of the audit log ID of the action in the context of Undo buttons
+const undoContextKey = "audit_log_id"
+
+// postCommandResponseWithUndo posts text like postCommandResponse, with a button undoing the
+// action it reports. The button is left out if the action cannot be undone.
+func (p *Plugin) postCommandResponseWithUndo(args *model.CommandArgs, text string) {
+	logID, err := p.listManager.GetUndoableAction(args.UserId)
+	if err != nil {
+		p.API.LogError("Unable to get the action to undo", "err", err.Error())
+	}
+	if logID == "" {
+		p.postCommandResponse(args, text)
+		return
+	}
+
+	post := &model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: args.ChannelId,
+		Message:   text,
+	}
+	model.ParseSlackAttachment(post, []*model.SlackAttachment{{
+		Actions: []*model.PostAction{{
+			Id:   "undo",
+			Name: "Undo",
+			Type: model.PostActionTypeButton,
+			Integration: &model.PostActionIntegration{
+				URL:     fmt.Sprintf("/plugins/%s/undo", manifest.Id),
+				Context: map[string]interface{}{undoContextKey: logID},
+			},
+		}},
+	}})
+	_ = p.API.SendEphemeralPost(args.UserId, post)
+}
+
 // ExecuteCommand executes a given command and returns a command response.
 func (p *Plugin) ExecuteCommand(_ *plugin.Context, args *model.CommandArgs) (*model.CommandResponse, *model.AppError) {
 	spaceRegExp := regexp.MustCompile(`\s+`)
@@ -94,6 +131,36 @@ func (p *Plugin) ExecuteCommand(_ *plugin.Context, args *model.CommandArgs) (*mo
 			handler = p.runSendCommand
 		case "settings":
 			handler = p.runSettingsCommand
+		case "search":
+			handler = p.runSearchCommand
+		case "restore":
+			handler = p.runRestoreCommand
+		case "undo":
+			handler = p.runUndoCommand
+		case "check":
+			handler = p.runCheckCommand
+		case "repeat":
+			handler = p.runRepeatCommand
+		case "tag":
+			handler = p.runTagCommand
+		case "lists":
+			handler = p.runListsCommand
+		case "move":
+			handler = p.runMoveCommand
+		case "untag":
+			handler = p.runUntagCommand
+		case "status":
+			handler = p.runStatusCommand
+		case "channel":
+			handler = p.runChannelCommand
+		case "team":
+			handler = p.runTeamCommand
+		case "decline":
+			handler = p.runDeclineCommand
+		case "watch":
+			handler = p.runWatchCommand
+		case "unwatch":
+			handler = p.runUnwatchCommand
 		default:
 			// Check if AI is enabled
 			config := p.getConfiguration()
@@ -146,7 +213,15 @@ func (p *Plugin) ExecuteCommand(_ *plugin.Context, args *model.CommandArgs) (*mo
 					// So runAddCommand hardcodes priority/dueAt to 0. 
 					// We must call listManager.AddIssue directly here.
 
-					_, err := p.listManager.AddIssue(args.UserId, intent.Summary, "", "", "", dueAt, priority)
+					// The tags typed by the user are kept even if the summary drops them.
+					_, tags := parseTags(strings.Join(stringArgs[1:], " "))
+					summary, summaryTags := parseTags(intent.Summary)
+					tags = append(tags, summaryTags...)
+
+					issue, err := p.listManager.AddIssue(args.UserId, summary, "", "", "", dueAt, priority)
+					if err == nil && len(tags) > 0 {
+						_, err = p.listManager.TagIssue(args.UserId, issue.ID, tags)
+					}
 					if err != nil {
 						p.postCommandResponse(args, "Failed to create smart todo: "+err.Error())
 					} else {
@@ -187,6 +262,10 @@ func (p *Plugin) runSendCommand(args []string, extra *model.CommandArgs) (bool,
 		return false, nil
 	}
 
+	if strings.Contains(args[0], ",") {
+		return p.runSendSharedCommand(args, extra)
+	}
+
 	userName := args[0]
 	if args[0][0] == '@' {
 		userName = args[0][1:]
@@ -235,8 +314,63 @@ func (p *Plugin) runSendCommand(args []string, extra *model.CommandArgs) (bool,
 	return false, nil
 }
 
+// runSendSharedCommand sends a Todo to the comma-separated users of the first argument. The
+// Todo is done once all of them completed it, or once any of them did with --any.
+func (p *Plugin) runSendSharedCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	completion := CompletionAll
+	messageArgs := args[1:]
+	if messageArgs[0] == "--any" || messageArgs[0] == "--all" {
+		completion = strings.TrimPrefix(messageArgs[0], "--")
+		messageArgs = messageArgs[1:]
+	}
+	message := strings.Join(messageArgs, " ")
+	if message == "" {
+		p.postCommandResponse(extra, "You must specify the users and a message.\n"+p.getHelp(extra.UserId))
+		return false, nil
+	}
+
+	var receiverIDs, userNames []string
+	for _, userName := range strings.Split(args[0], ",") {
+		userName = strings.TrimPrefix(strings.TrimSpace(userName), "@")
+		if userName == "" {
+			continue
+		}
+		receiver, appErr := p.API.GetUserByUsername(userName)
+		if appErr != nil {
+			p.postCommandResponse(extra, fmt.Sprintf("Please, provide valid users: cannot find @%s.", userName))
+			return false, nil
+		}
+
+		allowed, err := p.getAllowIncomingTaskRequestsPreference(receiver.Id)
+		if err != nil {
+			p.API.LogError("Error when getting allow incoming task request preference, err=", err)
+			allowed = true
+		}
+		if !allowed {
+			p.postCommandResponse(extra, fmt.Sprintf("@%s has blocked Todo requests", userName))
+			return false, nil
+		}
+		receiverIDs = append(receiverIDs, receiver.Id)
+		userNames = append(userNames, userName)
+	}
+
+	receiverIssueIDs, err := p.listManager.SendSharedIssue(extra.UserId, receiverIDs, completion, message, "", "", "", 0, 0)
+	if errors.Is(err, ErrInvalidAssignees) {
+		p.postCommandResponse(extra, fmt.Sprintf("Unable to send the Todo: %s.", err.Error()))
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	p.trackSendIssue(extra.UserId, sourceCommand, false)
+	p.notifySharedIssueSent(extra.UserId, receiverIssueIDs, message, "")
+	p.postCommandResponse(extra, fmt.Sprintf("Todo sent to @%s.", strings.Join(userNames, ", @")))
+	return false, nil
+}
+
 func (p *Plugin) runAddCommand(args []string, extra *model.CommandArgs) (bool, error) {
-	message := strings.Join(args, " ")
+	message, tags := parseTags(strings.Join(args, " "))
 
 	if message == "" {
 		p.postCommandResponse(extra, "Please add a task.")
@@ -247,6 +381,11 @@ func (p *Plugin) runAddCommand(args []string, extra *model.CommandArgs) (bool, e
 	if err != nil {
 		return false, err
 	}
+	if len(tags) > 0 {
+		if _, err = p.listManager.TagIssue(extra.UserId, newIssue.ID, tags); err != nil {
+			return false, err
+		}
+	}
 
 	p.trackAddIssue(extra.UserId, sourceCommand, false)
 
@@ -288,6 +427,13 @@ func (p *Plugin) runListCommand(args []string, extra *model.CommandArgs) (bool,
 	listID := MyListKey
 	responseMessage := "Todo List:\n\n"
 
+	// A trailing #tag filters the list
+	tag := ""
+	if len(args) > 0 && strings.HasPrefix(args[len(args)-1], "#") {
+		tag = normalizeTagName(args[len(args)-1])
+		args = args[:len(args)-1]
+	}
+
 	if len(args) > 0 {
 		switch args[0] {
 		case MyFlag:
@@ -297,9 +443,20 @@ func (p *Plugin) runListCommand(args []string, extra *model.CommandArgs) (bool,
 		case OutFlag:
 			listID = OutListKey
 			responseMessage = "Sent Todo list:\n\n"
+		case DoneFlag:
+			listID = DoneListKey
+			responseMessage = "Done Todo list:\n\n"
 		default:
-			p.postCommandResponse(extra, p.getHelp(extra.UserId))
-			return true, nil
+			list, err := p.findCustomList(extra.UserId, strings.Join(args, " "))
+			if err != nil {
+				return false, err
+			}
+			if list == nil {
+				p.postCommandResponse(extra, p.getHelp(extra.UserId))
+				return true, nil
+			}
+			listID = list.ID
+			responseMessage = list.Name + " list:\n\n"
 		}
 	}
 
@@ -310,6 +467,10 @@ func (p *Plugin) runListCommand(args []string, extra *model.CommandArgs) (bool,
 
 	p.sendRefreshEvent(extra.UserId, []string{MyListKey, OutListKey, InListKey})
 
+	if tag != "" {
+		issues = filterIssuesByTag(issues, tag)
+	}
+
 	responseMessage += issuesListToString(issues)
 	p.postCommandResponse(extra, responseMessage)
 
@@ -323,10 +484,16 @@ func (p *Plugin) runPopCommand(_ []string, extra *model.CommandArgs) (bool, erro
 			p.postCommandResponse(extra, "There are no Todos to pop.")
 			return false, nil
 		}
+		if errors.Is(err, ErrInvalidTransition) {
+			p.postCommandResponse(extra, fmt.Sprintf("Unable to pop the top Todo: %s.", err.Error()))
+			return false, nil
+		}
 		return false, err
 	}
 
 	userName := p.listManager.GetUserName(extra.UserId)
+	p.notifyUnblocked(issue.ID)
+	p.notifyWatchersCompleted(extra.UserId, issue)
 
 	if foreignID != "" {
 		p.sendRefreshEvent(foreignID, []string{OutListKey})
@@ -335,7 +502,7 @@ func (p *Plugin) runPopCommand(_ []string, extra *model.CommandArgs) (bool, erro
 		p.PostBotDM(foreignID, message)
 	}
 
-	p.sendRefreshEvent(extra.UserId, []string{MyListKey})
+	p.sendRefreshEvent(extra.UserId, []string{MyListKey, DoneListKey})
 
 	responseMessage := "Removed top Todo."
 
@@ -345,17 +512,698 @@ func (p *Plugin) runPopCommand(_ []string, extra *model.CommandArgs) (bool, erro
 	issues, err := p.listManager.GetIssueList(extra.UserId, MyListKey)
 	if err != nil {
 		p.API.LogError(err.Error())
-		p.postCommandResponse(extra, responseMessage)
+		p.postCommandResponseWithUndo(extra, responseMessage)
 		return false, nil
 	}
 
 	responseMessage += listHeaderMessage
 	responseMessage += issuesListToString(issues)
+	p.postCommandResponseWithUndo(extra, responseMessage)
+
+	return false, nil
+}
+
+func (p *Plugin) runSearchCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	terms := strings.Join(args, " ")
+	if terms == "" {
+		p.postCommandResponse(extra, "Please provide something to search for.")
+		return false, nil
+	}
+
+	issues, err := p.listManager.SearchIssues(extra.UserId, terms)
+	if err != nil {
+		return false, err
+	}
+
+	if len(issues) == 0 {
+		p.postCommandResponse(extra, fmt.Sprintf("No Todos found for \"%s\".", terms))
+		return false, nil
+	}
+
+	responseMessage := fmt.Sprintf("Todos matching \"%s\":\n\n", terms)
+	for _, issue := range issues {
+		responseMessage += fmt.Sprintf("* %s (%s)\n", issue.Message, issue.Status)
+	}
 	p.postCommandResponse(extra, responseMessage)
 
 	return false, nil
 }
 
+func (p *Plugin) runRestoreCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	issues, err := p.listManager.GetTrash(extra.UserId)
+	if err != nil {
+		return false, err
+	}
+
+	if len(args) == 0 {
+		if len(issues) == 0 {
+			p.postCommandResponse(extra, "Your trash is empty.")
+			return false, nil
+		}
+
+		responseMessage := "Removed Todos, restore one with `/todo restore [number]`:\n\n"
+		for i, issue := range issues {
+			responseMessage += fmt.Sprintf("%d. %s\n", i+1, issue.Message)
+		}
+		p.postCommandResponse(extra, responseMessage)
+		return false, nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(issues) {
+		p.postCommandResponse(extra, fmt.Sprintf("There is no Todo number %s in your trash.", args[0]))
+		return false, nil
+	}
+
+	issue, err := p.restoreIssue(extra.UserId, issues[n-1].ID)
+	if err != nil {
+		return false, err
+	}
+
+	p.postCommandResponse(extra, fmt.Sprintf("Restored Todo: %s", issue.Message))
+	return false, nil
+}
+
+func (p *Plugin) runUndoCommand(_ []string, extra *model.CommandArgs) (bool, error) {
+	message, err := p.undoLastAction(extra.UserId, "")
+	if err != nil {
+		return false, err
+	}
+
+	p.postCommandResponse(extra, message)
+	return false, nil
+}
+
+const checkCommandUsage = "Usage: `/todo check [list, add, done, reopen, remove, auto] [todo number] ...`, " +
+	"where the todo number is the position of the Todo in `/todo list`."
+
+func (p *Plugin) runCheckCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) < 2 {
+		p.postCommandResponse(extra, checkCommandUsage)
+		return true, nil
+	}
+
+	issue, err := p.getMyIssueByNumber(extra.UserId, args[1])
+	if err != nil {
+		return false, err
+	}
+	if issue == nil {
+		p.postCommandResponse(extra, fmt.Sprintf("There is no Todo number %s in your list.", args[1]))
+		return false, nil
+	}
+
+	var item *ChecklistItem
+	if len(args) > 2 {
+		switch args[0] {
+		case "done", "reopen", "remove":
+			items, err := p.listManager.GetChecklist(issue.ID)
+			if err != nil {
+				return false, err
+			}
+			n, err := strconv.Atoi(args[2])
+			if err != nil || n < 1 || n > len(items) {
+				p.postCommandResponse(extra, fmt.Sprintf("There is no item number %s in the checklist.", args[2]))
+				return false, nil
+			}
+			item = items[n-1]
+		}
+	}
+
+	switch {
+	case args[0] == "list" && len(args) == 2:
+	case args[0] == "add" && len(args) > 2:
+		if _, err = p.listManager.AddChecklistItem(extra.UserId, issue.ID, strings.Join(args[2:], " ")); err != nil {
+			return false, err
+		}
+		p.sendTodoRefreshEvents(extra.UserId, issue.ID)
+	case (args[0] == "done" || args[0] == "reopen") && item != nil:
+		if _, err = p.checkChecklistItem(extra.UserId, item.ID, args[0] == "done"); err != nil {
+			return false, err
+		}
+	case args[0] == "remove" && item != nil:
+		if _, err = p.listManager.DeleteChecklistItem(extra.UserId, item.ID); err != nil {
+			return false, err
+		}
+		p.sendTodoRefreshEvents(extra.UserId, issue.ID)
+	case args[0] == "auto" && len(args) == 3 && (args[2] == "on" || args[2] == "off"):
+		if err = p.listManager.SetChecklistAutoComplete(extra.UserId, issue.ID, args[2] == "on"); err != nil {
+			return false, err
+		}
+		p.sendTodoRefreshEvents(extra.UserId, issue.ID)
+	default:
+		p.postCommandResponse(extra, checkCommandUsage)
+		return true, nil
+	}
+
+	items, err := p.listManager.GetChecklist(issue.ID)
+	if err != nil {
+		return false, err
+	}
+	p.postCommandResponse(extra, checklistToString(issue, items))
+	return false, nil
+}
+
+const repeatCommandUsage = "Usage: `/todo repeat [todo number] [rule]`, where the rule is daily, weekdays, " +
+	"weekly, weekly followed by days like `mon,thu`, monthly, an RRULE like `FREQ=MONTHLY;BYMONTHDAY=-1`, or off."
+
+func (p *Plugin) runRepeatCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) < 2 {
+		p.postCommandResponse(extra, repeatCommandUsage)
+		return true, nil
+	}
+
+	issue, err := p.getMyIssueByNumber(extra.UserId, args[0])
+	if err != nil {
+		return false, err
+	}
+	if issue == nil {
+		p.postCommandResponse(extra, fmt.Sprintf("There is no Todo number %s in your list.", args[0]))
+		return false, nil
+	}
+
+	rule := strings.Join(args[1:], " ")
+	if rule == "off" {
+		rule = ""
+	} else if _, err = ParseRecurrence(rule); err != nil {
+		p.postCommandResponse(extra, fmt.Sprintf("Invalid recurrence rule: %s.\n%s", err.Error(), repeatCommandUsage))
+		return false, nil
+	}
+
+	updated, err := p.listManager.SetRecurrence(extra.UserId, issue.ID, rule, 0)
+	if err != nil {
+		return false, err
+	}
+	p.sendTodoRefreshEvents(extra.UserId, issue.ID)
+
+	if updated.Recurrence == "" {
+		p.postCommandResponse(extra, fmt.Sprintf("Todo %s no longer repeats.", issue.Message))
+		return false, nil
+	}
+	p.postCommandResponse(extra, fmt.Sprintf("Todo %s repeats with `%s`.", issue.Message, updated.Recurrence))
+	return false, nil
+}
+
+const statusCommandUsage = "Usage: `/todo status [todo number] [status]`, where the status is open, in_progress, blocked or done " +
+	"and the todo number is the position of the Todo in `/todo list`."
+
+func (p *Plugin) runStatusCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) != 2 {
+		p.postCommandResponse(extra, statusCommandUsage)
+		return true, nil
+	}
+
+	status, ok := parseStatus(strings.ToLower(args[1]))
+	if !ok {
+		p.postCommandResponse(extra, fmt.Sprintf("Unknown status %s.\n%s", args[1], statusCommandUsage))
+		return true, nil
+	}
+
+	issue, err := p.getMyIssueByNumber(extra.UserId, args[0])
+	if err != nil {
+		return false, err
+	}
+	if issue == nil {
+		p.postCommandResponse(extra, fmt.Sprintf("There is no Todo number %s in your list.", args[0]))
+		return false, nil
+	}
+
+	updated, foreignID, listToUpdate, err := p.listManager.SetIssueStatus(extra.UserId, issue.ID, status, 0)
+	if errors.Is(err, ErrInvalidTransition) {
+		p.postCommandResponse(extra, fmt.Sprintf("Unable to change the status: %s.", err.Error()))
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	p.postCommandResponse(extra, fmt.Sprintf("Todo %s is now %s.", issue.Message, statusLabel(status)))
+	p.notifyStatusChanged(extra.UserId, updated, foreignID, listToUpdate)
+	return false, nil
+}
+
+const channelCommandUsage = "Usage: `/todo channel add [message]`, `/todo channel list` or `/todo channel done [todo number]`, " +
+	"where the todo number is the position of the Todo in `/todo channel list`."
+
+// runChannelCommand manages the Todo list of the channel the command is run in
+func (p *Plugin) runChannelCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) == 0 {
+		p.postCommandResponse(extra, channelCommandUsage)
+		return true, nil
+	}
+
+	switch {
+	case args[0] == "add" && len(args) > 1:
+		issue, err := p.listManager.AddChannelIssue(extra.UserId, extra.ChannelId, strings.Join(args[1:], " "), "")
+		if errors.Is(err, ErrNotChannelMember) {
+			p.postCommandResponse(extra, "You must be a member of the channel to add a Todo to its list.")
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		p.sendChannelRefreshEvent(extra.ChannelId)
+		p.postCommandResponse(extra, fmt.Sprintf("Added Todo to the channel list: %s", issue.Message))
+		return false, nil
+	case args[0] == "list" && len(args) == 1:
+		issues, err := p.listManager.GetChannelIssues(extra.UserId, extra.ChannelId)
+		if errors.Is(err, ErrNotChannelMember) {
+			p.postCommandResponse(extra, "You must be a member of the channel to see its Todo list.")
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		p.postCommandResponse(extra, "#### Channel Todos"+channelIssuesToString(issues.Open))
+		return false, nil
+	case args[0] == "done" && len(args) == 2:
+		issues, err := p.listManager.GetChannelIssues(extra.UserId, extra.ChannelId)
+		if errors.Is(err, ErrNotChannelMember) {
+			p.postCommandResponse(extra, "You must be a member of the channel to complete its Todos.")
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		n, convErr := strconv.Atoi(args[1])
+		if convErr != nil || n < 1 || n > len(issues.Open) {
+			p.postCommandResponse(extra, fmt.Sprintf("There is no Todo number %s in the channel list.", args[1]))
+			return false, nil
+		}
+
+		issue, err := p.listManager.CompleteChannelIssue(extra.UserId, issues.Open[n-1].ID)
+		if err != nil {
+			return false, err
+		}
+		p.sendChannelRefreshEvent(extra.ChannelId)
+		p.notifyUnblocked(issue.ID)
+		p.notifyWatchersCompleted(extra.UserId, issue)
+		p.postCommandResponse(extra, fmt.Sprintf("Completed channel Todo: %s", issue.Message))
+		return false, nil
+	}
+
+	p.postCommandResponse(extra, channelCommandUsage)
+	return true, nil
+}
+
+// channelIssuesToString lists the open todos of a channel, numbered for /todo channel done
+func channelIssuesToString(issues []*Issue) string {
+	extended := make([]*ExtendedIssue, 0, len(issues))
+	for _, issue := range issues {
+		extended = append(extended, &ExtendedIssue{Issue: *issue})
+	}
+	return issuesListToString(extended)
+}
+
+// runTeamCommand shows the counts of the outstanding todos of the members of the team the
+// command is run in
+func (p *Plugin) runTeamCommand(_ []string, extra *model.CommandArgs) (bool, error) {
+	board, err := p.listManager.GetTeamBoard(extra.UserId, extra.TeamId)
+	if errors.Is(err, ErrNotTeamAdmin) {
+		p.postCommandResponse(extra, "Only the team admins can see the Todos of the team.")
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	p.postCommandResponse(extra, teamBoardToString(board))
+	return false, nil
+}
+
+// teamBoardToString shows the counts of a team board as a table
+func teamBoardToString(board *TeamBoard) string {
+	if len(board.Assignees) == 0 {
+		return "The members of the team have nothing to do!"
+	}
+
+	str := "#### Team Todos\n\n| Member | Open | Overdue | Pending |\n|:--|--:|--:|--:|\n"
+	for _, c := range board.Assignees {
+		str += fmt.Sprintf("| @%s | %d | %d | %d |\n", c.Username, c.Open, c.Overdue, c.Pending)
+	}
+	str += fmt.Sprintf("| **Total** | **%d** | **%d** | **%d** |\n", board.Total.Open, board.Total.Overdue, board.Total.Pending)
+	return str
+}
+
+const declineCommandUsage = "Usage: `/todo decline [todo number] [reason]`, where the todo number is the position " +
+	"of the Todo in `/todo list in` and the reason is optional."
+
+// runDeclineCommand sends a todo of the In list back to its sender
+func (p *Plugin) runDeclineCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) < 1 {
+		p.postCommandResponse(extra, declineCommandUsage)
+		return true, nil
+	}
+
+	issue, err := p.getIssueByNumber(extra.UserId, InListKey, args[0])
+	if err != nil {
+		return false, err
+	}
+	if issue == nil {
+		p.postCommandResponse(extra, fmt.Sprintf("There is no Todo number %s in your In list.", args[0]))
+		return false, nil
+	}
+
+	reason := strings.Join(args[1:], " ")
+	declined, senderID, err := p.listManager.DeclineIssue(extra.UserId, issue.ID, reason)
+	if err != nil {
+		return false, err
+	}
+
+	p.notifyIssueDeclined(extra.UserId, declined, senderID, reason)
+	p.postCommandResponse(extra, fmt.Sprintf("Declined Todo: %s", declined.Message))
+	return false, nil
+}
+
+const watchCommandUsage = "Usage: `/todo watch [todo] [@user]` or `/todo unwatch [todo] [@user]`, where the todo is " +
+	"the ID of the Todo or its position in `/todo list`, and the user is yourself if omitted."
+
+func (p *Plugin) runWatchCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	return p.changeWatcher(args, extra, true)
+}
+
+func (p *Plugin) runUnwatchCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	return p.changeWatcher(args, extra, false)
+}
+
+// changeWatcher adds or removes the watcher args[1], or the user, of the todo args[0], which
+// is the ID of a todo or the number of a todo of the My list
+func (p *Plugin) changeWatcher(args []string, extra *model.CommandArgs, watch bool) (bool, error) {
+	if len(args) < 1 || len(args) > 2 {
+		p.postCommandResponse(extra, watchCommandUsage)
+		return true, nil
+	}
+
+	issueID := args[0]
+	if !model.IsValidId(issueID) {
+		issue, err := p.getMyIssueByNumber(extra.UserId, args[0])
+		if err != nil {
+			return false, err
+		}
+		if issue == nil {
+			p.postCommandResponse(extra, fmt.Sprintf("There is no Todo number %s in your list.", args[0]))
+			return false, nil
+		}
+		issueID = issue.ID
+	}
+
+	watcherID := extra.UserId
+	if len(args) == 2 {
+		watcher, appErr := p.API.GetUserByUsername(strings.TrimPrefix(args[1], "@"))
+		if appErr != nil {
+			p.postCommandResponse(extra, "Please, provide a valid user.\n"+watchCommandUsage)
+			return false, nil
+		}
+		watcherID = watcher.Id
+	}
+
+	// Watchers can read the todo, so only the users working on it can add them, while
+	// watchers can stop watching it.
+	var authorized bool
+	var err error
+	if !watch && watcherID == extra.UserId {
+		authorized, err = p.listManager.IsAuthorizedToRead(issueID, extra.UserId)
+	} else {
+		authorized, err = p.listManager.IsAuthorized(issueID, extra.UserId)
+	}
+	if err != nil {
+		return false, err
+	}
+	if !authorized {
+		p.postCommandResponse(extra, "You can only change the watchers of the Todos you work on.")
+		return false, nil
+	}
+
+	if !watch {
+		if err = p.listManager.Unwatch(extra.UserId, issueID, watcherID); err != nil {
+			return false, err
+		}
+		if watcherID == extra.UserId {
+			p.postCommandResponse(extra, "You no longer watch the Todo.")
+		} else {
+			p.postCommandResponse(extra, fmt.Sprintf("%s no longer watches the Todo.", args[1]))
+		}
+		return false, nil
+	}
+
+	err = p.listManager.Watch(extra.UserId, issueID, watcherID)
+	if errors.Is(err, ErrInvalidWatcher) {
+		p.postCommandResponse(extra, fmt.Sprintf("Unable to add the watcher: %s.", err.Error()))
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	p.notifyWatcherAdded(extra.UserId, issueID, watcherID)
+	if watcherID == extra.UserId {
+		p.postCommandResponse(extra, "You now watch the Todo, and will be notified about its changes.")
+	} else {
+		p.postCommandResponse(extra, fmt.Sprintf("%s now watches the Todo, and will be notified about its changes.", args[1]))
+	}
+	return false, nil
+}
+
+// tagsAutocompleteURL is the plugin route listing the tags of the user for the slash command
+const tagsAutocompleteURL = "autocomplete/tags"
+
+const tagCommandUsage = "Usage: `/todo tag [todo number] [#tag]...` or `/todo untag [todo number] [#tag]...`, " +
+	"where the todo number is the position of the Todo in `/todo list`."
+
+func (p *Plugin) runTagCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	return p.changeTags(args, extra, true)
+}
+
+func (p *Plugin) runUntagCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	return p.changeTags(args, extra, false)
+}
+
+// changeTags adds or removes the tags args[1:] of the todo number args[0] of the My list
+func (p *Plugin) changeTags(args []string, extra *model.CommandArgs, add bool) (bool, error) {
+	if len(args) < 2 {
+		p.postCommandResponse(extra, tagCommandUsage)
+		return true, nil
+	}
+
+	issue, err := p.getMyIssueByNumber(extra.UserId, args[0])
+	if err != nil {
+		return false, err
+	}
+	if issue == nil {
+		p.postCommandResponse(extra, fmt.Sprintf("There is no Todo number %s in your list.", args[0]))
+		return false, nil
+	}
+
+	for _, tag := range args[1:] {
+		if !isValidTagName(normalizeTagName(tag)) {
+			p.postCommandResponse(extra, fmt.Sprintf("%s is not a valid tag. Tags start with a letter and contain letters, numbers, - and _.", tag))
+			return false, nil
+		}
+	}
+
+	if add {
+		_, err = p.listManager.TagIssue(extra.UserId, issue.ID, args[1:])
+	} else {
+		for _, tag := range args[1:] {
+			if err = p.listManager.UntagIssue(extra.UserId, issue.ID, tag); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	p.sendTodoRefreshEvents(extra.UserId, issue.ID)
+
+	issues, err := p.listManager.GetIssueList(extra.UserId, MyListKey)
+	if err != nil {
+		return false, err
+	}
+	p.postCommandResponse(extra, "Todo List:\n\n"+issuesListToString(issues))
+	return false, nil
+}
+
+// filterIssuesByTag returns the issues having the tag name
+func filterIssuesByTag(issues []*ExtendedIssue, name string) []*ExtendedIssue {
+	filtered := []*ExtendedIssue{}
+	for _, issue := range issues {
+		if slices.Contains(issue.Tags, name) {
+			filtered = append(filtered, issue)
+		}
+	}
+	return filtered
+}
+
+const listsCommandUsage = "Usage: `/todo lists [create, rename, order, delete] ...`, " +
+	"where the list number is the position of the list in `/todo lists`."
+
+func (p *Plugin) runListsCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	lists, err := p.listManager.GetCustomLists(extra.UserId)
+	if err != nil {
+		return false, err
+	}
+
+	if len(args) == 0 {
+		p.postCommandResponse(extra, customListsToString(lists))
+		return false, nil
+	}
+
+	var list *CustomList
+	if args[0] != "create" && len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 || n > len(lists) {
+			p.postCommandResponse(extra, fmt.Sprintf("There is no list number %s.", args[1]))
+			return false, nil
+		}
+		list = lists[n-1]
+	}
+
+	switch {
+	case args[0] == "create" && len(args) > 1:
+		list, err = p.listManager.CreateCustomList(extra.UserId, strings.Join(args[1:], " "))
+	case args[0] == "rename" && len(args) > 2:
+		list, err = p.listManager.RenameCustomList(extra.UserId, list.ID, strings.Join(args[2:], " "))
+	case args[0] == "order" && len(args) == 3:
+		position, convErr := strconv.Atoi(args[2])
+		if convErr != nil || position < 1 || position > len(lists) {
+			p.postCommandResponse(extra, fmt.Sprintf("The position must be between 1 and %d.", len(lists)))
+			return false, nil
+		}
+		listIDs := make([]string, 0, len(lists))
+		for _, l := range lists {
+			if l.ID != list.ID {
+				listIDs = append(listIDs, l.ID)
+			}
+		}
+		listIDs = slices.Insert(listIDs, position-1, list.ID)
+		err = p.listManager.ReorderCustomLists(extra.UserId, listIDs)
+	case args[0] == "delete" && len(args) == 2:
+		err = p.listManager.DeleteCustomList(extra.UserId, list.ID)
+	default:
+		p.postCommandResponse(extra, listsCommandUsage)
+		return true, nil
+	}
+	if errors.Is(err, ErrInvalidCustomList) {
+		p.postCommandResponse(extra, err.Error())
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	p.sendRefreshEvent(extra.UserId, []string{MyListKey, list.ID})
+
+	if lists, err = p.listManager.GetCustomLists(extra.UserId); err != nil {
+		return false, err
+	}
+	p.postCommandResponse(extra, customListsToString(lists))
+	return false, nil
+}
+
+func (p *Plugin) runMoveCommand(args []string, extra *model.CommandArgs) (bool, error) {
+	if len(args) < 2 {
+		p.postCommandResponse(extra, "Usage: `/todo move [todo number] [list name]`, where the list name is my for your list.")
+		return true, nil
+	}
+
+	issue, err := p.getMyIssueByNumber(extra.UserId, args[0])
+	if err != nil {
+		return false, err
+	}
+	if issue == nil {
+		p.postCommandResponse(extra, fmt.Sprintf("There is no Todo number %s in your list.", args[0]))
+		return false, nil
+	}
+
+	name := strings.Join(args[1:], " ")
+	listID := MyListKey
+	if name != MyFlag {
+		list, err := p.findCustomList(extra.UserId, name)
+		if err != nil {
+			return false, err
+		}
+		if list == nil {
+			p.postCommandResponse(extra, fmt.Sprintf("You have no list named %s.", name))
+			return false, nil
+		}
+		listID = list.ID
+	}
+
+	oldList, err := p.listManager.MoveIssue(extra.UserId, issue.ID, listID)
+	if err != nil {
+		return false, err
+	}
+	p.sendRefreshEvent(extra.UserId, []string{oldList, listID})
+
+	p.postCommandResponse(extra, fmt.Sprintf("Moved Todo: %s", issue.Message))
+	return false, nil
+}
+
+// findCustomList returns the custom list of userID named name, ignoring the case, or nil if
+// there is none
+func (p *Plugin) findCustomList(userID, name string) (*CustomList, error) {
+	lists, err := p.listManager.GetCustomLists(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range lists {
+		if strings.EqualFold(list.Name, name) {
+			return list, nil
+		}
+	}
+	return nil, nil
+}
+
+func customListsToString(lists []*CustomList) string {
+	if len(lists) == 0 {
+		return "You have no lists. Create one with `/todo lists create [name]`."
+	}
+
+	str := "Your lists:\n\n"
+	for i, list := range lists {
+		str += fmt.Sprintf("%d. %s\n", i+1, list.Name)
+	}
+	return str
+}
+
+// getMyIssueByNumber returns the todo at the 1-based position number of the My list of
+// userID, or nil if there is none
+func (p *Plugin) getMyIssueByNumber(userID, number string) (*ExtendedIssue, error) {
+	return p.getIssueByNumber(userID, MyListKey, number)
+}
+
+// getIssueByNumber returns the todo at the 1-based position number of the list listID of
+// userID, or nil if there is none
+func (p *Plugin) getIssueByNumber(userID, listID, number string) (*ExtendedIssue, error) {
+	issues, err := p.listManager.GetIssueList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 || n > len(issues) {
+		return nil, nil
+	}
+	return issues[n-1], nil
+}
+
+func checklistToString(issue *ExtendedIssue, items []*ChecklistItem) string {
+	if len(items) == 0 {
+		return fmt.Sprintf("The checklist of \"%s\" is empty.", issue.Message)
+	}
+
+	str := fmt.Sprintf("Checklist of \"%s\":\n\n", issue.Message)
+	for i, item := range items {
+		box := "[ ]"
+		if item.Checked {
+			box = "[x]"
+		}
+		str += fmt.Sprintf("%d. %s %s\n", i+1, box, item.Message)
+	}
+	if issue.AutoComplete {
+		str += "\nThe Todo is completed when all the items are checked."
+	}
+	return str
+}
+
 func (p *Plugin) runSettingsCommand(args []string, extra *model.CommandArgs) (bool, error) {
 	const (
 		on  = "on"
@@ -447,13 +1295,13 @@ func (p *Plugin) runSettingsCommand(args []string, extra *model.CommandArgs) (bo
 }
 
 func getAutocompleteData() *model.AutocompleteData {
-	todo := model.NewAutocompleteData("todo", "[command]", "Available commands: list, add, pop, send, settings, help")
+	todo := model.NewAutocompleteData("todo", "[command]", "Available commands: list, add, pop, send, search, restore, undo, check, repeat, tag, untag, lists, move, status, channel, team, watch, unwatch, decline, settings, help")
 
 	add := model.NewAutocompleteData("add", "[message]", "Adds a Todo")
 	add.AddTextArgument("E.g. be awesome", "[message]", "")
 	todo.AddCommand(add)
 
-	list := model.NewAutocompleteData("list", "[name]", "Lists your Todo issues")
+	list := model.NewAutocompleteData("list", "[name] [#tag]", "Lists your Todo issues")
 	items := []model.AutocompleteListItem{{
 		HelpText: "Received Todos",
 		Hint:     "(optional)",
@@ -462,18 +1310,124 @@ func getAutocompleteData() *model.AutocompleteData {
 		HelpText: "Sent Todos",
 		Hint:     "(optional)",
 		Item:     "out",
+	}, {
+		HelpText: "Completed Todos",
+		Hint:     "(optional)",
+		Item:     "done",
 	}}
 	list.AddStaticListArgument("Lists your Todo issues", false, items)
+	list.AddDynamicListArgument("Filter by one of your tags", tagsAutocompleteURL, false)
 	todo.AddCommand(list)
 
 	pop := model.NewAutocompleteData("pop", "", "Removes the Todo issue at the top of the list")
 	todo.AddCommand(pop)
 
-	send := model.NewAutocompleteData("send", "[user] [todo]", "Sends a Todo to a specified user")
-	send.AddTextArgument("Whom to send", "[@awesomePerson]", "")
+	send := model.NewAutocompleteData("send", "[user] [todo]", "Sends a Todo to a specified user, or to several comma-separated users")
+	send.AddTextArgument("Whom to send", "[@awesomePerson] or [@one,@two] [--any]", "")
 	send.AddTextArgument("Todo message", "[message]", "")
 	todo.AddCommand(send)
 
+	search := model.NewAutocompleteData("search", "[terms]", "Searches your Todos and their comments")
+	search.AddTextArgument("E.g. quarterly report", "[terms]", "")
+	todo.AddCommand(search)
+
+	restore := model.NewAutocompleteData("restore", "[number]", "Lists your removed Todos, or restores one of them")
+	restore.AddTextArgument("Number of the Todo in the trash", "[number]", "")
+	todo.AddCommand(restore)
+
+	undo := model.NewAutocompleteData("undo", "", "Reverts your last complete, accept, remove, pop, bump or move")
+	todo.AddCommand(undo)
+
+	check := model.NewAutocompleteData("check", "[subcommand]", "Manages the checklist of a Todo of your list")
+	checkList := model.NewAutocompleteData("list", "[todo number]", "Shows the checklist of a Todo")
+	checkList.AddTextArgument("Number of the Todo in your list", "[todo number]", "")
+	checkAdd := model.NewAutocompleteData("add", "[todo number] [item]", "Adds an item to the checklist of a Todo")
+	checkAdd.AddTextArgument("Number of the Todo in your list", "[todo number]", "")
+	checkAdd.AddTextArgument("E.g. write the tests", "[item]", "")
+	checkDone := model.NewAutocompleteData("done", "[todo number] [item number]", "Checks an item of the checklist")
+	checkReopen := model.NewAutocompleteData("reopen", "[todo number] [item number]", "Unchecks an item of the checklist")
+	checkRemove := model.NewAutocompleteData("remove", "[todo number] [item number]", "Removes an item from the checklist")
+	checkAuto := model.NewAutocompleteData("auto", "[todo number] [on] [off]", "Completes the Todo when all its items are checked")
+	check.AddCommand(checkList)
+	check.AddCommand(checkAdd)
+	check.AddCommand(checkDone)
+	check.AddCommand(checkReopen)
+	check.AddCommand(checkRemove)
+	check.AddCommand(checkAuto)
+	todo.AddCommand(check)
+
+	repeat := model.NewAutocompleteData("repeat", "[todo number] [rule]", "Makes a Todo of your list recurring, or stops it with off")
+	repeat.AddTextArgument("Number of the Todo in your list", "[todo number]", "")
+	repeat.AddTextArgument("E.g. daily, weekdays, weekly mon,thu, monthly, FREQ=MONTHLY;BYMONTHDAY=-1 or off", "[rule]", "")
+	todo.AddCommand(repeat)
+
+	tag := model.NewAutocompleteData("tag", "[todo number] [#tag]", "Adds tags to a Todo of your list")
+	tag.AddTextArgument("Number of the Todo in your list", "[todo number]", "")
+	tag.AddDynamicListArgument("Your tags, or a new #tag", tagsAutocompleteURL, true)
+	todo.AddCommand(tag)
+
+	untag := model.NewAutocompleteData("untag", "[todo number] [#tag]", "Removes tags from a Todo of your list")
+	untag.AddTextArgument("Number of the Todo in your list", "[todo number]", "")
+	untag.AddDynamicListArgument("Your tags", tagsAutocompleteURL, true)
+	todo.AddCommand(untag)
+
+	lists := model.NewAutocompleteData("lists", "[subcommand]", "Shows or manages your custom lists")
+	listsCreate := model.NewAutocompleteData("create", "[name]", "Creates a list")
+	listsCreate.AddTextArgument("E.g. Someday", "[name]", "")
+	listsRename := model.NewAutocompleteData("rename", "[list number] [name]", "Renames a list")
+	listsRename.AddTextArgument("Number of the list in /todo lists", "[list number]", "")
+	listsRename.AddTextArgument("New name of the list", "[name]", "")
+	listsOrder := model.NewAutocompleteData("order", "[list number] [position]", "Moves a list to another position")
+	listsDelete := model.NewAutocompleteData("delete", "[list number]", "Deletes a list and moves its Todos back to your list")
+	lists.AddCommand(listsCreate)
+	lists.AddCommand(listsRename)
+	lists.AddCommand(listsOrder)
+	lists.AddCommand(listsDelete)
+	todo.AddCommand(lists)
+
+	move := model.NewAutocompleteData("move", "[todo number] [list name]", "Moves a Todo of your list to a custom list, or back with my")
+	move.AddTextArgument("Number of the Todo in your list", "[todo number]", "")
+	move.AddTextArgument("Name of the list", "[list name]", "")
+	todo.AddCommand(move)
+
+	status := model.NewAutocompleteData("status", "[todo number] [status]", "Changes the status of a Todo of your list")
+	status.AddTextArgument("Number of the Todo in your list", "[todo number]", "")
+	status.AddStaticListArgument("New status of the Todo", true, []model.AutocompleteListItem{
+		{Item: StatusOpen, HelpText: "Not started yet"},
+		{Item: StatusInProgress, HelpText: "Being worked on"},
+		{Item: StatusBlocked, HelpText: "Cannot progress for now"},
+		{Item: StatusDone, HelpText: "Completed"},
+	})
+	todo.AddCommand(status)
+
+	channel := model.NewAutocompleteData("channel", "[add, list, done]", "Manages the Todo list of the current channel")
+	channelAdd := model.NewAutocompleteData("add", "[message]", "Adds a Todo to the list of the channel")
+	channelAdd.AddTextArgument("E.g. prepare the release notes", "[message]", "")
+	channel.AddCommand(channelAdd)
+	channel.AddCommand(model.NewAutocompleteData("list", "", "Lists the Todos of the channel"))
+	channelDone := model.NewAutocompleteData("done", "[todo number]", "Completes a Todo of the channel")
+	channelDone.AddTextArgument("Number of the Todo in /todo channel list", "[todo number]", "")
+	channel.AddCommand(channelDone)
+	todo.AddCommand(channel)
+
+	team := model.NewAutocompleteData("team", "", "Shows the outstanding Todos of the members of the team, for team admins")
+	todo.AddCommand(team)
+
+	watch := model.NewAutocompleteData("watch", "[todo] [@user]", "Notifies you, or a user, about the changes of a Todo")
+	watch.AddTextArgument("ID of the Todo, or its number in your list", "[todo]", "")
+	watch.AddTextArgument("User to notify, yourself if omitted", "[@user]", "")
+	todo.AddCommand(watch)
+
+	unwatch := model.NewAutocompleteData("unwatch", "[todo] [@user]", "Stops notifying you, or a user, about the changes of a Todo")
+	unwatch.AddTextArgument("ID of the Todo, or its number in your list", "[todo]", "")
+	unwatch.AddTextArgument("User to stop notifying, yourself if omitted", "[@user]", "")
+	todo.AddCommand(unwatch)
+
+	decline := model.NewAutocompleteData("decline", "[todo number] [reason]", "Sends a Todo you received back to its sender")
+	decline.AddTextArgument("Number of the Todo in /todo list in", "[todo number]", "")
+	decline.AddTextArgument("Why you decline the Todo, optional", "[reason]", "")
+	todo.AddCommand(decline)
+
 	settings := model.NewAutocompleteData("settings", "[setting] [on] [off]", "Sets the user settings")
 	summary := model.NewAutocompleteData("summary", "[on] [off]", "Sets the summary settings")
 	summaryOn := model.NewAutocompleteData("on", "", "sets the daily reminder to enable")
@@ -5,18 +5,29 @@ import (
 
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
 )
 
+// failingPreferencesStore is a store failing to save the user preferences
+type failingPreferencesStore struct {
+	*MemoryStore
+}
+
+func (s *failingPreferencesStore) SetReminderPreference(string, bool) error {
+	return errors.New("failed")
+}
+
+func (s *failingPreferencesStore) SetAllowIncomingTaskPreference(string, bool) error {
+	return errors.New("failed")
+}
+
 func TestSetttingsCommand(t *testing.T) {
 	api := &plugintest.API{}
 	api.On("SendEphemeralPost", mock.AnythingOfType("string"), mock.Anything).Return(nil)
-	api.On("KVSet", mock.AnythingOfType("string"), mock.Anything).Return(nil)
-	api.On("KVGet", mock.AnythingOfType("string"), mock.Anything).Return([]byte("true"), nil)
 
 	apiKVSetFailed := &plugintest.API{}
 	apiKVSetFailed.On("SendEphemeralPost", mock.AnythingOfType("string"), mock.Anything).Return(nil)
-	apiKVSetFailed.On("KVSet", mock.AnythingOfType("string"), mock.Anything).Return(model.NewAppError("failed", "", nil, "", 400))
 	apiKVSetFailed.On("LogDebug", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string"))
 
 	tests := []struct {
@@ -109,6 +120,10 @@ func TestSetttingsCommand(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			plugin := Plugin{}
 			plugin.SetAPI(tt.api)
+			plugin.store = NewMemoryStore()
+			if tt.api == apiKVSetFailed {
+				plugin.store = &failingPreferencesStore{NewMemoryStore()}
+			}
 
 			resp, err := plugin.runSettingsCommand(tt.args, &model.CommandArgs{})
 			if tt.wantErr != (err != nil) {
@@ -24,6 +24,14 @@ type configuration struct {
 	EnableSmartTodo bool   `json:"enable_smart_todo"`
 	LLMApiKey       string `json:"llm_api_key"`
 	LLMModel        string `json:"llm_model"`
+
+	// TrashRetentionDays is how long removed todos stay in the trash. 0 keeps them forever.
+	TrashRetentionDays int `json:"trash_retention_days"`
+	// TrashPurgeAuditLog deletes the audit log of the todos purged from the trash
+	TrashPurgeAuditLog bool `json:"trash_purge_audit_log"`
+	// StatusWorkflow is the JSON object of the statuses each active status can change to.
+	// Empty uses the default workflow.
+	StatusWorkflow string `json:"status_workflow"`
 }
 
 // Clone shallow copies the configuration. Your implementation may require a deep copy if
@@ -34,6 +42,9 @@ func (c *configuration) Clone() *configuration {
 }
 
 func (c *configuration) IsValid() error {
+	if _, err := parseStatusWorkflow(c.StatusWorkflow); err != nil {
+		return errors.Wrap(err, "invalid status workflow")
+	}
 	return nil
 }
 
@@ -92,8 +103,14 @@ func (p *Plugin) OnConfigurationChange() error {
 		return errors.Wrap(err, "failed to load plugin configuration")
 	}
 
+	transitions, err := parseStatusWorkflow(configuration.StatusWorkflow)
+	if err != nil {
+		return errors.Wrap(err, "invalid status workflow")
+	}
+
 	shouldUpdateClient := p.hasClientConfigChanged(p.configuration, configuration)
 	p.setConfiguration(configuration)
+	setStatusTransitions(transitions)
 
 	// Dispatch WebSocket event to send all users updated client configs
 	if shouldUpdateClient {
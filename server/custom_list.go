@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// customListsLimit is the maximum number of custom lists of a user
+	customListsLimit = 50
+	// customListNameMaxLength is the maximum length of the name of a custom list, in characters
+	customListNameMaxLength = 64
+)
+
+var (
+	// ErrNoCustomList is returned when a custom list does not exist or is not owned by the user
+	ErrNoCustomList = errors.New("the list does not exist")
+	// ErrInvalidCustomList is wrapped by the errors of invalid custom list changes, such as
+	// a duplicate name
+	ErrInvalidCustomList = errors.New("invalid list")
+)
+
+// CustomList is a named list created by a user, holding some of the user's open todos
+// instead of the My list
+type CustomList struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	Name      string `json:"name"`
+	SortOrder int    `json:"sort_order"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// CustomListIssues is a custom list with its todos
+type CustomListIssues struct {
+	List   *CustomList      `json:"list"`
+	Issues []*ExtendedIssue `json:"issues"`
+}
+
+func sortCustomLists(lists []*CustomList) {
+	sort.Slice(lists, func(i, j int) bool {
+		if lists[i].SortOrder != lists[j].SortOrder {
+			return lists[i].SortOrder < lists[j].SortOrder
+		}
+		if lists[i].CreatedAt != lists[j].CreatedAt {
+			return lists[i].CreatedAt < lists[j].CreatedAt
+		}
+		return lists[i].ID < lists[j].ID
+	})
+}
+
+// isBuiltInList returns true if listID is the key of the My, In, Out or Done list
+func isBuiltInList(listID string) bool {
+	switch listID {
+	case MyListKey, InListKey, OutListKey, DoneListKey:
+		return true
+	}
+	return false
+}
+
+// isOwnedList returns true if listID holds the open todos owned by the user, that is the My
+// list or a custom list
+func isOwnedList(listID string) bool {
+	return listID == MyListKey || !isBuiltInList(listID)
+}
+
+// GetCustomLists returns the custom lists of userID, in their order
+func (l *listManager) GetCustomLists(userID string) ([]*CustomList, error) {
+	return l.store.GetCustomLists(userID)
+}
+
+// CreateCustomList adds a custom list named name at the end of the lists of userID
+func (l *listManager) CreateCustomList(userID, name string) (*CustomList, error) {
+	var list *CustomList
+	err := l.transaction(func(tx *listManager) error {
+		lists, err := tx.store.GetCustomLists(userID)
+		if err != nil {
+			return err
+		}
+		if len(lists) >= customListsLimit {
+			return fmt.Errorf("%w: you cannot have more than %d lists", ErrInvalidCustomList, customListsLimit)
+		}
+		if name, err = validateCustomListName(name, lists, ""); err != nil {
+			return err
+		}
+
+		now := model.GetMillis()
+		list = &CustomList{
+			UserID:    userID,
+			Name:      name,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if len(lists) > 0 {
+			list.SortOrder = lists[len(lists)-1].SortOrder + 1
+		}
+		return tx.store.SaveCustomList(list)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// RenameCustomList renames the custom list listID of userID
+func (l *listManager) RenameCustomList(userID, listID, name string) (*CustomList, error) {
+	var list *CustomList
+	err := l.transaction(func(tx *listManager) error {
+		var err error
+		if list, err = tx.getCustomList(userID, listID); err != nil {
+			return err
+		}
+
+		lists, err := tx.store.GetCustomLists(userID)
+		if err != nil {
+			return err
+		}
+		if list.Name, err = validateCustomListName(name, lists, listID); err != nil {
+			return err
+		}
+
+		list.UpdatedAt = model.GetMillis()
+		return tx.store.SaveCustomList(list)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// ReorderCustomLists sorts the custom lists of userID in the order of listIDs, which must
+// hold all of them
+func (l *listManager) ReorderCustomLists(userID string, listIDs []string) error {
+	return l.transaction(func(tx *listManager) error {
+		lists, err := tx.store.GetCustomLists(userID)
+		if err != nil {
+			return err
+		}
+		if len(listIDs) != len(lists) {
+			return fmt.Errorf("%w: all the lists must be ordered", ErrInvalidCustomList)
+		}
+
+		order := make(map[string]int, len(listIDs))
+		for i, listID := range listIDs {
+			order[listID] = i
+		}
+		for _, list := range lists {
+			position, ok := order[list.ID]
+			if !ok {
+				return fmt.Errorf("%w: all the lists must be ordered", ErrInvalidCustomList)
+			}
+			if list.SortOrder == position {
+				continue
+			}
+			list.SortOrder = position
+			list.UpdatedAt = model.GetMillis()
+			if err := tx.store.SaveCustomList(list); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteCustomList deletes the custom list listID of userID. Its todos are moved back to the
+// My list.
+func (l *listManager) DeleteCustomList(userID, listID string) error {
+	return l.transaction(func(tx *listManager) error {
+		if _, err := tx.getCustomList(userID, listID); err != nil {
+			return err
+		}
+		return tx.store.DeleteCustomList(listID)
+	})
+}
+
+// MoveIssue moves the open todo issueID of userID to listID, which is MyListKey or a custom
+// list of userID, and returns the list it was in
+func (l *listManager) MoveIssue(userID, issueID, listID string) (oldList string, err error) {
+	err = l.transaction(func(tx *listManager) error {
+		if listID != MyListKey {
+			if _, err := tx.getCustomList(userID, listID); err != nil {
+				return err
+			}
+		}
+
+		issue, err := tx.store.GetIssue(issueID)
+		if err != nil {
+			return err
+		}
+		list, ok := issue.ListFor(userID)
+		if !ok || !isOwnedList(list) {
+			return fmt.Errorf("%w: only the open todos of your lists can be moved", ErrInvalidCustomList)
+		}
+		oldList = list
+		if list == listID {
+			return nil
+		}
+
+		before := tx.snapshotIssues(issueID)
+		issue.ListID = listID
+		issue.UpdateAt = model.GetMillis()
+		if err := tx.store.UpdateIssue(issue); err != nil {
+			return err
+		}
+		if err := tx.placeAtTop(issueID); err != nil {
+			return err
+		}
+		return tx.recordUndoableAuditLog(issueID, userID, "move", listID, before)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return oldList, nil
+}
+
+// getCustomList returns the custom list listID, or ErrNoCustomList if it is not a list of
+// userID
+func (l *listManager) getCustomList(userID, listID string) (*CustomList, error) {
+	list, err := l.store.GetCustomList(listID)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil || list.UserID != userID {
+		return nil, ErrNoCustomList
+	}
+	return list, nil
+}
+
+// getCustomListIssues returns the todos of each custom list of userID
+func (l *listManager) getCustomListIssues(userID string) ([]*CustomListIssues, error) {
+	lists, err := l.store.GetCustomLists(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	listsIssues := make([]*CustomListIssues, 0, len(lists))
+	for _, list := range lists {
+		issues, err := l.GetIssueList(userID, list.ID)
+		if err != nil {
+			return nil, err
+		}
+		listsIssues = append(listsIssues, &CustomListIssues{List: list, Issues: issues})
+	}
+	return listsIssues, nil
+}
+
+// validateCustomListName returns the sanitized name, or an error if it is empty, too long or
+// used by another list than exceptID
+func validateCustomListName(name string, lists []*CustomList, exceptID string) (string, error) {
+	name = SanitizeInput(name)
+	if name == "" {
+		return "", fmt.Errorf("%w: the list name is empty", ErrInvalidCustomList)
+	}
+	if utf8.RuneCountInString(name) > customListNameMaxLength {
+		return "", fmt.Errorf("%w: the list name cannot be longer than %d characters", ErrInvalidCustomList, customListNameMaxLength)
+	}
+	for _, list := range lists {
+		if list.ID != exceptID && strings.EqualFold(list.Name, name) {
+			return "", fmt.Errorf("%w: you already have a list named %s", ErrInvalidCustomList, list.Name)
+		}
+	}
+	return name, nil
+}
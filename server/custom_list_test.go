@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomLists(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	sprint, err := l.CreateCustomList(testUserID, "Sprint")
+	require.NoError(t, err)
+	someday, err := l.CreateCustomList(testUserID, "Someday")
+	require.NoError(t, err)
+	_, err = l.CreateCustomList(testUserID, "sprint")
+	assert.ErrorIs(t, err, ErrInvalidCustomList)
+	_, err = l.RenameCustomList(testOtherID, sprint.ID, "Mine")
+	assert.ErrorIs(t, err, ErrNoCustomList)
+
+	require.NoError(t, l.ReorderCustomLists(testUserID, []string{someday.ID, sprint.ID}))
+	lists, err := l.GetCustomLists(testUserID)
+	require.NoError(t, err)
+	require.Len(t, lists, 2)
+	assert.Equal(t, someday.ID, lists[0].ID)
+
+	issue, err := l.AddIssue(testUserID, "plan the sprint", "", "", "", 0, 0)
+	require.NoError(t, err)
+	oldList, err := l.MoveIssue(testUserID, issue.ID, sprint.ID)
+	require.NoError(t, err)
+	assert.Equal(t, MyListKey, oldList)
+	_, err = l.MoveIssue(testOtherID, issue.ID, MyListKey)
+	assert.ErrorIs(t, err, ErrInvalidCustomList, "only the owner can move a todo")
+
+	all, err := l.GetAllList(testUserID)
+	require.NoError(t, err)
+	assert.Empty(t, all.My)
+	require.Len(t, all.Lists, 2)
+	assert.Empty(t, all.Lists[0].Issues)
+	assert.Equal(t, []string{issue.ID}, issueIDs(all.Lists[1].Issues))
+
+	_, _, _, err = l.CompleteIssue(testUserID, issue.ID)
+	require.NoError(t, err, "todos of custom lists can be completed")
+	_, _, err = l.UndoLastAction(testUserID, "")
+	require.NoError(t, err)
+
+	require.NoError(t, l.DeleteCustomList(testUserID, sprint.ID))
+	all, err = l.GetAllList(testUserID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{issue.ID}, issueIDs(all.My), "the todos of a deleted list go back to the My list")
+	assert.Len(t, all.Lists, 1)
+}
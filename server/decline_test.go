@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeclineIssue(t *testing.T) {
+	l, store := setupTestListManager(t)
+
+	receiverIssueID, err := l.SendIssue(testUserID, testOtherID, "review the PR", "", "", "", 0, 0)
+	require.NoError(t, err)
+
+	_, _, err = l.DeclineIssue(testThirdID, receiverIssueID, "")
+	assert.Error(t, err, "only the receiver can decline the todo")
+
+	issue, senderID, err := l.DeclineIssue(testOtherID, receiverIssueID, "not my area")
+	require.NoError(t, err)
+	assert.Equal(t, testUserID, senderID)
+	assert.Equal(t, StatusDeclined, issue.Status)
+
+	in, err := l.GetIssueList(testOtherID, InListKey)
+	require.NoError(t, err)
+	assert.Empty(t, in)
+
+	out, err := l.GetIssueList(testUserID, OutListKey)
+	require.NoError(t, err)
+	require.Len(t, out, 1, "the declined todo is back in the Out list of its sender")
+	assert.Equal(t, StatusDeclined, out[0].Status)
+
+	logs, err := store.GetAuditLogs(out[0].ID)
+	require.NoError(t, err)
+	reasons := []string{}
+	for _, log := range logs {
+		if log.Action == "decline" {
+			reasons = append(reasons, log.Metadata)
+		}
+	}
+	assert.Equal(t, []string{"not my area"}, reasons, "the sender finds the reason in the history of their copy")
+
+	_, _, err = l.DeclineIssue(testOtherID, receiverIssueID, "")
+	assert.Error(t, err, "a declined todo cannot be declined again")
+
+	_, _, err = l.ChangeAssignment(out[0].ID, testUserID, testThirdID, 0)
+	require.NoError(t, err)
+	in, err = l.GetIssueList(testThirdID, InListKey)
+	require.NoError(t, err)
+	assert.Len(t, in, 1, "the sender can send the declined todo to someone else")
+	out, err = l.GetIssueList(testUserID, OutListKey)
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	assert.Equal(t, StatusPending, out[0].Status)
+}
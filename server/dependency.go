@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// blockersLimit is the maximum number of todos blocking a todo
+const blockersLimit = 20
+
+// ErrInvalidDependency is wrapped by the errors of dependencies that cannot be added, such as
+// circular ones
+var ErrInvalidDependency = errors.New("invalid dependency")
+
+// BlockingIssue is a todo blocking another one, possibly of another user
+type BlockingIssue struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+	// OwnerID is the user whose lists the blocking todo appears in
+	OwnerID string `json:"owner_id"`
+	// Resolved is set when the todo no longer blocks, see isResolvedBlocker
+	Resolved bool `json:"resolved"`
+}
+
+// isResolvedBlocker returns true if issue no longer blocks the todos depending on it, as it
+// is done or removed
+func isResolvedBlocker(issue *Issue) bool {
+	return issue.Status == StatusDone || issue.Status == StatusArchived || issue.DeletedAt != 0
+}
+
+// AddDependency records that todoID cannot start before blockerID is done. Dependencies making
+// todos block each other are rejected.
+func (l *listManager) AddDependency(userID, todoID, blockerID string) error {
+	if todoID == blockerID {
+		return fmt.Errorf("%w: a todo cannot block itself", ErrInvalidDependency)
+	}
+
+	return l.transaction(func(tx *listManager) error {
+		if _, err := tx.store.GetIssue(todoID); err != nil {
+			return err
+		}
+		if _, err := tx.store.GetIssue(blockerID); err != nil {
+			return err
+		}
+
+		blockers, err := tx.store.GetBlockers([]string{todoID})
+		if err != nil {
+			return err
+		}
+		if slices.Contains(blockers[todoID], blockerID) {
+			return nil
+		}
+		if len(blockers[todoID]) >= blockersLimit {
+			return fmt.Errorf("%w: a todo cannot be blocked by more than %d todos", ErrInvalidDependency, blockersLimit)
+		}
+
+		circular, err := tx.isBlockedBy(blockerID, todoID)
+		if err != nil {
+			return err
+		}
+		if circular {
+			return fmt.Errorf("%w: the todos would block each other", ErrInvalidDependency)
+		}
+
+		if err := tx.store.AddDependency(todoID, blockerID); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(todoID, userID, "add_blocker", blockerID)
+	})
+}
+
+// RemoveDependency removes the dependency of todoID on blockerID
+func (l *listManager) RemoveDependency(userID, todoID, blockerID string) error {
+	return l.transaction(func(tx *listManager) error {
+		if err := tx.store.RemoveDependency(todoID, blockerID); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(todoID, userID, "remove_blocker", blockerID)
+	})
+}
+
+// GetUnblockedIssues returns the active todos blocked by the resolved todo blockerID, or by
+// the other party's copy of it, that have no other unresolved blocker
+func (l *listManager) GetUnblockedIssues(blockerID string) ([]*Issue, error) {
+	blocker, err := l.store.GetIssue(blockerID)
+	if err != nil {
+		return nil, err
+	}
+	if !isResolvedBlocker(blocker) {
+		return nil, nil
+	}
+
+	blockerIDs := []string{blockerID}
+	if blocker.ForeignIssueID != "" {
+		blockerIDs = append(blockerIDs, blocker.ForeignIssueID)
+	}
+	blocked, err := l.store.GetBlockedIssues(blockerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var unblocked []*Issue
+	seen := map[string]bool{}
+	for _, id := range blockerIDs {
+		for _, todoID := range blocked[id] {
+			if seen[todoID] {
+				continue
+			}
+			seen[todoID] = true
+
+			issue, err := l.store.GetIssue(todoID)
+			if err != nil || isResolvedBlocker(issue) {
+				continue
+			}
+			blocking, err := l.hasUnresolvedBlocker(todoID)
+			if err != nil {
+				return nil, err
+			}
+			if !blocking {
+				unblocked = append(unblocked, issue)
+			}
+		}
+	}
+	return unblocked, nil
+}
+
+// isBlockedBy returns true if todoID depends on targetID, directly or through other todos
+func (l *listManager) isBlockedBy(todoID, targetID string) (bool, error) {
+	visited := map[string]bool{todoID: true}
+	pending := []string{todoID}
+	for len(pending) > 0 {
+		blockers, err := l.store.GetBlockers(pending)
+		if err != nil {
+			return false, err
+		}
+
+		pending = nil
+		for _, ids := range blockers {
+			for _, id := range ids {
+				if id == targetID {
+					return true, nil
+				}
+				if !visited[id] {
+					visited[id] = true
+					pending = append(pending, id)
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// hasUnresolvedBlocker returns true if a todo blocking todoID is not resolved yet. Blockers
+// that no longer exist are resolved.
+func (l *listManager) hasUnresolvedBlocker(todoID string) (bool, error) {
+	blockers, err := l.store.GetBlockers([]string{todoID})
+	if err != nil {
+		return false, err
+	}
+	for _, blockerID := range blockers[todoID] {
+		if blocker, err := l.store.GetIssue(blockerID); err == nil && !isResolvedBlocker(blocker) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// addDependencies sets the todos blocking issues and blocked by them
+func (l *listManager) addDependencies(issues []*ExtendedIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	todoIDs := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		todoIDs = append(todoIDs, issue.ID)
+	}
+
+	blockers, err := l.store.GetBlockers(todoIDs)
+	if err != nil {
+		return err
+	}
+	blocked, err := l.store.GetBlockedIssues(todoIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		for _, blockerID := range blockers[issue.ID] {
+			blocker, err := l.store.GetIssue(blockerID)
+			if err != nil {
+				continue
+			}
+			issue.BlockedBy = append(issue.BlockedBy, &BlockingIssue{
+				ID:       blocker.ID,
+				Message:  blocker.Message,
+				Status:   blocker.Status,
+				OwnerID:  blocker.OwnerID(),
+				Resolved: isResolvedBlocker(blocker),
+			})
+		}
+		issue.Blocks = blocked[issue.ID]
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencies(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	release, err := l.AddIssue(testUserID, "release", "", "", "", 0, 0)
+	require.NoError(t, err)
+	notes, err := l.AddIssue(testUserID, "write the release notes", "", "", "", 0, 0)
+	require.NoError(t, err)
+	reviewID, err := l.SendIssue(testUserID, testOtherID, "review the PR", "", "", "", 0, 0)
+	require.NoError(t, err)
+	out, err := l.GetIssueList(testUserID, OutListKey)
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+
+	require.NoError(t, l.AddDependency(testUserID, release.ID, notes.ID))
+	require.NoError(t, l.AddDependency(testUserID, release.ID, out[0].ID), "a sent todo can block a todo")
+	assert.ErrorIs(t, l.AddDependency(testUserID, notes.ID, release.ID), ErrInvalidDependency, "todos cannot block each other")
+	assert.ErrorIs(t, l.AddDependency(testUserID, release.ID, release.ID), ErrInvalidDependency)
+
+	my, err := l.GetIssueList(testUserID, MyListKey)
+	require.NoError(t, err)
+	require.Len(t, my, 2)
+	assert.Equal(t, release.ID, my[1].ID)
+	require.Len(t, my[1].BlockedBy, 2)
+	assert.Equal(t, []string{release.ID}, my[0].Blocks)
+
+	_, _, _, err = l.CompleteIssue(testUserID, notes.ID)
+	require.NoError(t, err)
+	unblocked, err := l.GetUnblockedIssues(notes.ID)
+	require.NoError(t, err)
+	assert.Empty(t, unblocked, "the review still blocks the release")
+
+	_, _, _, err = l.CompleteIssue(testOtherID, reviewID)
+	require.NoError(t, err)
+	unblocked, err = l.GetUnblockedIssues(reviewID)
+	require.NoError(t, err)
+	require.Len(t, unblocked, 1, "completing the received copy resolves the sent one")
+	assert.Equal(t, release.ID, unblocked[0].ID)
+
+	require.NoError(t, l.RemoveDependency(testUserID, release.ID, notes.ID))
+	my, err = l.GetIssueList(testUserID, MyListKey)
+	require.NoError(t, err)
+	require.Len(t, my, 1)
+	require.Len(t, my[0].BlockedBy, 1)
+	assert.True(t, my[0].BlockedBy[0].Resolved)
+}
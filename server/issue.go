@@ -32,6 +32,134 @@ type Issue struct {
 	Priority      int    `json:"priority"`
 	DueAt         int64  `json:"due_at"`
 	Status        string `json:"status"`
+	// CompletedAt is the time the todo was done, or 0 if it is not
+	CompletedAt int64 `json:"completed_at"`
+	// DeletedAt is the time the todo was moved to the trash, or 0 if it is not in the trash
+	DeletedAt int64 `json:"deleted_at"`
+	// AutoComplete completes the todo when all its checklist items are checked
+	AutoComplete bool `json:"auto_complete"`
+	// Recurrence is the RRULE of a recurring todo, or empty. Completing the todo adds the
+	// next occurrence of the series.
+	Recurrence string `json:"recurrence,omitempty"`
+	// ListID is the custom list of an open todo of its owner, or MyListKey
+	ListID string `json:"list_id,omitempty"`
+	// Rank sorts the todo in its list, lowest first. Todos without a rank come first, most
+	// recently updated first.
+	Rank string `json:"rank,omitempty"`
+	// Completion is set on the copies of a todo sent to several assignees, see IsShared. It
+	// is CompletionAll or CompletionAny.
+	Completion string `json:"completion,omitempty"`
+	// ChannelID is the channel of a todo of a channel list. Channel todos have no assignee
+	// and are not in the lists of any user.
+	ChannelID string `json:"channel_id,omitempty"`
+	// Version is incremented on every change of the issue. Clients echo it back when
+	// updating the issue, so concurrent changes are detected.
+	Version int64 `json:"version"`
+}
+
+const (
+	// StatusOpen is the status of the todos in the My list
+	StatusOpen = "open"
+	// StatusInProgress is the status of open todos being worked on
+	StatusInProgress = "in_progress"
+	// StatusBlocked is the status of open todos that cannot progress for now
+	StatusBlocked = "blocked"
+	// StatusPending is the status of received todos not accepted yet, and of sent todos
+	StatusPending = "pending"
+	// StatusArchived is the status of todos removed from every list
+	StatusArchived = "archived"
+	// StatusDone is the status of completed todos, which are in the Done list of their owner
+	StatusDone = "done"
+	// StatusDeclined is the status of received todos their assignee declined, and of the
+	// sender's copies of those todos, which stay in the Out list of their sender
+	StatusDeclined = "declined"
+)
+
+const (
+	// CompletionAll completes a shared todo once each of its assignees completed it
+	CompletionAll = "all"
+	// CompletionAny completes a shared todo for everyone once one of its assignees completed it
+	CompletionAny = "any"
+)
+
+// IsShared returns true if the issue is a copy of a todo sent to several assignees. The
+// sender's copy is linked to the copy of its first assignee, and the copy of each assignee is
+// linked to the sender's copy.
+func (i *Issue) IsShared() bool {
+	return i.Completion != ""
+}
+
+// IsSenderCopy returns true if the issue is the copy of a sent todo kept by its sender. When
+// a todo is sent, the sender and the receiver each get a copy linked to the other one
+// through the foreign IDs, and only the sender's copy is assigned to its foreign user.
+func (i *Issue) IsSenderCopy() bool {
+	return i.ForeignUserID != "" && i.ForeignUserID == i.AssigneeID && i.CreatorID != i.AssigneeID
+}
+
+// OwnerID returns the user whose lists the issue appears in
+func (i *Issue) OwnerID() string {
+	if i.IsSenderCopy() {
+		return i.CreatorID
+	}
+	return i.AssigneeID
+}
+
+// ListFor returns the list in which the issue appears for userID, if any. Trashed issues
+// keep their status, so they are back in their list when restored.
+func (i *Issue) ListFor(userID string) (string, bool) {
+	if i.DeletedAt != 0 {
+		return "", false
+	}
+
+	if i.IsSenderCopy() {
+		if i.CreatorID == userID && (i.Status == StatusPending || i.Status == StatusDeclined || isActiveStatus(i.Status)) {
+			return OutListKey, true
+		}
+		return "", false
+	}
+
+	if i.AssigneeID != userID {
+		return "", false
+	}
+
+	switch i.Status {
+	case StatusOpen, StatusInProgress, StatusBlocked:
+		return i.ListID, true
+	case StatusPending:
+		return InListKey, true
+	case StatusDone:
+		return DoneListKey, true
+	}
+	return "", false
+}
+
+// sortsBefore returns true if the issue comes before other in listID. The Done list is sorted
+// by completion time, most recent first, and the other lists by rank. It must match
+// listOrderBy.
+func (i *Issue) sortsBefore(other *Issue, listID string) bool {
+	if listID == DoneListKey {
+		if i.CompletedAt != other.CompletedAt {
+			return i.CompletedAt > other.CompletedAt
+		}
+		return i.ID < other.ID
+	}
+
+	if i.Rank != other.Rank {
+		return i.Rank < other.Rank
+	}
+	if i.UpdateAt != other.UpdateAt {
+		return i.UpdateAt > other.UpdateAt
+	}
+	return i.ID < other.ID
+}
+
+// Reference returns the IssueRef pointing to the issue
+func (i *Issue) Reference() *IssueRef {
+	return &IssueRef{
+		IssueID:        i.ID,
+		ForeignIssueID: i.ForeignIssueID,
+		ForeignUserID:  i.ForeignUserID,
+	}
 }
 
 // ExtendedIssue extends the information on Issue to be used on the front-end
@@ -40,6 +168,16 @@ type ExtendedIssue struct {
 	ForeignUser     string `json:"user"`
 	ForeignList     string `json:"list"`
 	ForeignPosition int    `json:"position"`
+	// Checklist is the progress of the checklist of the todo, if it has one
+	Checklist *ChecklistProgress `json:"checklist,omitempty"`
+	// Tags are the sorted tag names of the todo
+	Tags []string `json:"tags,omitempty"`
+	// BlockedBy are the todos the todo depends on, including the resolved ones
+	BlockedBy []*BlockingIssue `json:"blocked_by,omitempty"`
+	// Blocks are the IDs of the todos depending on the todo
+	Blocks []string `json:"blocks,omitempty"`
+	// Assignees is the progress of each assignee of a shared todo, set on the sender's copy
+	Assignees []*Assignee `json:"assignees,omitempty"`
 }
 
 // ListsIssue for all list issues
@@ -47,6 +185,10 @@ type ListsIssue struct {
 	In  []*ExtendedIssue `json:"in"`
 	My  []*ExtendedIssue `json:"my"`
 	Out []*ExtendedIssue `json:"out"`
+	// Done holds the most recently completed todos, up to doneListLimit
+	Done []*ExtendedIssue `json:"done"`
+	// Lists are the custom lists of the user, in their order
+	Lists []*CustomListIssues `json:"lists"`
 }
 
 // Comment represents a comment on a Todo
@@ -58,6 +200,28 @@ type Comment struct {
 	CreatedAt int64  `json:"created_at"`
 }
 
+// ChecklistItem is an item of the checklist of a Todo
+type ChecklistItem struct {
+	ID        string `json:"id"`
+	TodoID    string `json:"todo_id"`
+	Message   string `json:"message"`
+	Checked   bool   `json:"checked"`
+	SortOrder int    `json:"sort_order"`
+	CreatedAt int64  `json:"created_at"`
+	CheckedAt int64  `json:"checked_at"`
+}
+
+// ChecklistProgress counts the checked items of the checklist of a Todo
+type ChecklistProgress struct {
+	Checked int `json:"checked"`
+	Total   int `json:"total"`
+}
+
+// IsComplete returns true if the checklist has items and all of them are checked
+func (p *ChecklistProgress) IsComplete() bool {
+	return p != nil && p.Total > 0 && p.Checked == p.Total
+}
+
 // ExtendedComment adds user info to Comment
 type ExtendedComment struct {
 	Comment
@@ -72,6 +236,8 @@ type AuditLog struct {
 	Action    string `json:"action"`
 	Metadata  string `json:"metadata"`
 	CreatedAt int64  `json:"created_at"`
+	// Snapshot is the state of the todos before the action, for the actions that can be undone
+	Snapshot string `json:"-"`
 }
 
 func newIssue(message, postPermalink, description, postID, creatorID, assigneeID, status string, dueAt int64, priority int) *Issue {
@@ -99,9 +265,19 @@ func issuesListToString(issues []*ExtendedIssue) string {
 
 	str := "\n\n"
 
-	for _, issue := range issues {
+	for i, issue := range issues {
 		createAt := time.Unix(issue.CreateAt/1000, 0)
-		str += fmt.Sprintf("* %s\n  * (%s)\n", issue.Message, createAt.Format("January 2, 2006 at 15:04"))
+		message := issue.Message
+		if issue.Checklist != nil {
+			message = fmt.Sprintf("%s [%d/%d]", message, issue.Checklist.Checked, issue.Checklist.Total)
+		}
+		for _, tag := range issue.Tags {
+			message += " #" + tag
+		}
+		if issue.Status == StatusInProgress || issue.Status == StatusBlocked {
+			message += fmt.Sprintf(" _(%s)_", statusLabel(issue.Status))
+		}
+		str += fmt.Sprintf("%d. %s\n   * (%s)\n", i+1, message, createAt.Format("January 2, 2006 at 15:04"))
 	}
 
 	return str
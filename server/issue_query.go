@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SortByUpdated sorts the todos by their last update time
+	SortByUpdated = "updated"
+	// SortByCreated sorts the todos by their creation time
+	SortByCreated = "created"
+	// SortByDue sorts the todos by their due date. Todos without one come last.
+	SortByDue = "due"
+	// SortByPriority sorts the todos by their priority
+	SortByPriority = "priority"
+	// SortByCompleted sorts the todos by their completion time
+	SortByCompleted = "completed"
+
+	defaultIssuesPerPage = 50
+	maxIssuesPerPage     = 200
+)
+
+// IssueQuery selects a page of the todos owned by a user. Zero values do not filter.
+type IssueQuery struct {
+	Statuses   []string
+	Priorities []int
+	// DueAfter and DueBefore are inclusive bounds on the due date, in milliseconds
+	DueAfter   int64
+	DueBefore  int64
+	CreatorID  string
+	AssigneeID string
+	// Tag is the name of a tag of the owner that the todos must have
+	Tag string
+
+	SortBy     string
+	Descending bool
+	// After is the position of the last todo of the previous page, if any
+	After   *IssueCursor
+	PerPage int
+}
+
+// IssueCursor is the position of a todo in the sort order of an IssueQuery
+type IssueCursor struct {
+	SortValue int64
+	ID        string
+}
+
+// IssuePage is a page of the todos matching an IssueQuery
+type IssuePage struct {
+	Issues []*Issue `json:"issues"`
+	// NextCursor is passed as the cursor parameter to get the next page. It is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// GetIssueQueryFromURL parses the IssueQuery from the query parameters of a request
+func GetIssueQueryFromURL(values url.Values) (*IssueQuery, error) {
+	query := &IssueQuery{
+		CreatorID:  values.Get("creator_id"),
+		AssigneeID: values.Get("assignee_id"),
+		Tag:        normalizeTagName(values.Get("tag")),
+		SortBy:     values.Get("sort"),
+		PerPage:    defaultIssuesPerPage,
+	}
+
+	if statuses := values.Get("status"); statuses != "" {
+		query.Statuses = strings.Split(statuses, ",")
+	}
+
+	if priorities := values.Get("priority"); priorities != "" {
+		for _, value := range strings.Split(priorities, ",") {
+			priority, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, errors.Errorf("invalid priority %q", value)
+			}
+			query.Priorities = append(query.Priorities, priority)
+		}
+	}
+
+	var err error
+	if query.DueAfter, err = parseInt64Param(values, "due_after"); err != nil {
+		return nil, err
+	}
+	if query.DueBefore, err = parseInt64Param(values, "due_before"); err != nil {
+		return nil, err
+	}
+
+	if query.SortBy == "" {
+		query.SortBy = SortByUpdated
+	}
+	// Recent and important todos come first by default, and the next due ones for due dates.
+	query.Descending = query.SortBy != SortByDue
+	switch values.Get("order") {
+	case "":
+	case "asc":
+		query.Descending = false
+	case "desc":
+		query.Descending = true
+	default:
+		return nil, errors.New("order must be asc or desc")
+	}
+
+	if perPage := values.Get("per_page"); perPage != "" {
+		if query.PerPage, err = strconv.Atoi(perPage); err != nil {
+			return nil, errors.Errorf("invalid per_page %q", perPage)
+		}
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		if query.After, err = decodeIssueCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	return query, nil
+}
+
+func parseInt64Param(values url.Values, name string) (int64, error) {
+	value := values.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid %s %q", name, value)
+	}
+	return n, nil
+}
+
+// IsValid checks the filters and the sort of the query
+func (q *IssueQuery) IsValid() error {
+	for _, status := range q.Statuses {
+		switch status {
+		case StatusOpen, StatusInProgress, StatusBlocked, StatusPending, StatusArchived, StatusDone:
+		default:
+			return errors.Errorf("invalid status %q", status)
+		}
+	}
+
+	switch q.SortBy {
+	case SortByUpdated, SortByCreated, SortByDue, SortByPriority, SortByCompleted:
+	default:
+		return errors.Errorf("invalid sort %q", q.SortBy)
+	}
+
+	if q.PerPage <= 0 || q.PerPage > maxIssuesPerPage {
+		return errors.Errorf("per_page must be between 1 and %d", maxIssuesPerPage)
+	}
+
+	return nil
+}
+
+// statuses returns the statuses to filter on. Only active todos are returned by default.
+func (q *IssueQuery) statuses() []string {
+	if len(q.Statuses) == 0 {
+		return append([]string{StatusPending}, activeStatuses...)
+	}
+	return q.Statuses
+}
+
+// sortValue returns the value of issue the query sorts on. It must match the sort
+// expression of the SQL store.
+func (q *IssueQuery) sortValue(issue *Issue) int64 {
+	switch q.SortBy {
+	case SortByCreated:
+		return issue.CreateAt
+	case SortByDue:
+		if issue.DueAt == 0 {
+			return math.MaxInt64
+		}
+		return issue.DueAt
+	case SortByPriority:
+		return int64(issue.Priority)
+	case SortByCompleted:
+		return issue.CompletedAt
+	default:
+		return issue.UpdateAt
+	}
+}
+
+// matches returns true if issue passes the filters of the query. The owner and the
+// cursor are not checked, and trashed issues never match.
+func (q *IssueQuery) matches(issue *Issue) bool {
+	if issue.DeletedAt != 0 {
+		return false
+	}
+	if !slices.Contains(q.statuses(), issue.Status) {
+		return false
+	}
+	if len(q.Priorities) > 0 && !slices.Contains(q.Priorities, issue.Priority) {
+		return false
+	}
+	if q.DueAfter != 0 && issue.DueAt < q.DueAfter {
+		return false
+	}
+	if q.DueBefore != 0 && (issue.DueAt == 0 || issue.DueAt > q.DueBefore) {
+		return false
+	}
+	if q.CreatorID != "" && issue.CreatorID != q.CreatorID {
+		return false
+	}
+	if q.AssigneeID != "" && issue.AssigneeID != q.AssigneeID {
+		return false
+	}
+	return true
+}
+
+// isAfter returns true if issue comes after the cursor in the sort order of the query
+func (q *IssueQuery) isAfter(issue *Issue, cursor *IssueCursor) bool {
+	value := q.sortValue(issue)
+	if value == cursor.SortValue {
+		if q.Descending {
+			return issue.ID < cursor.ID
+		}
+		return issue.ID > cursor.ID
+	}
+	if q.Descending {
+		return value < cursor.SortValue
+	}
+	return value > cursor.SortValue
+}
+
+// sortIssues sorts issues in the order of the query
+func (q *IssueQuery) sortIssues(issues []*Issue) {
+	sort.Slice(issues, func(i, j int) bool {
+		return q.isAfter(issues[j], q.cursorFor(issues[i]))
+	})
+}
+
+// cursorFor returns the cursor positioned on issue
+func (q *IssueQuery) cursorFor(issue *Issue) *IssueCursor {
+	return &IssueCursor{SortValue: q.sortValue(issue), ID: issue.ID}
+}
+
+// Encode returns the opaque representation of the cursor used by the API
+func (c *IssueCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", c.SortValue, c.ID)))
+}
+
+func decodeIssueCursor(cursor string) (*IssueCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	parts := strings.SplitN(string(data), ":", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("invalid cursor")
+	}
+
+	value, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &IssueCursor{SortValue: value, ID: parts[1]}, nil
+}
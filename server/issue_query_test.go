@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetIssueQueryFromURL(t *testing.T) {
+	cursor := (&IssueCursor{SortValue: 42, ID: "issueid"}).Encode()
+	values, err := url.ParseQuery("status=open,pending&priority=1,2&due_after=10&due_before=20&sort=due&order=desc&per_page=10&cursor=" + cursor)
+	require.NoError(t, err)
+
+	query, err := GetIssueQueryFromURL(values)
+	require.NoError(t, err)
+	require.NoError(t, query.IsValid())
+	assert.Equal(t, &IssueQuery{
+		Statuses:   []string{StatusOpen, StatusPending},
+		Priorities: []int{1, 2},
+		DueAfter:   10,
+		DueBefore:  20,
+		SortBy:     SortByDue,
+		Descending: true,
+		After:      &IssueCursor{SortValue: 42, ID: "issueid"},
+		PerPage:    10,
+	}, query)
+
+	query, err = GetIssueQueryFromURL(url.Values{})
+	require.NoError(t, err)
+	assert.Equal(t, SortByUpdated, query.SortBy)
+	assert.True(t, query.Descending)
+	assert.Equal(t, defaultIssuesPerPage, query.PerPage)
+
+	for _, invalid := range []string{"priority=high", "order=up", "cursor=%21", "due_after=soon"} {
+		values, err := url.ParseQuery(invalid)
+		require.NoError(t, err)
+		_, err = GetIssueQueryFromURL(values)
+		assert.Error(t, err, invalid)
+	}
+
+	for _, invalid := range []string{"status=completed", "sort=name", "per_page=1000"} {
+		values, err := url.ParseQuery(invalid)
+		require.NoError(t, err)
+		query, err := GetIssueQueryFromURL(values)
+		require.NoError(t, err)
+		assert.Error(t, query.IsValid(), invalid)
+	}
+}
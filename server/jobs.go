@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	// jobPollInterval is how often every node checks whether a job is due
+	jobPollInterval = time.Minute
+	// jobLockTimeout is how long a node waits for the job mutex before giving up on a run
+	jobLockTimeout = 5 * time.Second
+)
+
+// JobState is the persisted state of a background job, shared by all the nodes of a cluster.
+// A job with LastStartedAt after LastFinishedAt was interrupted or failed, and is run again on
+// the next poll.
+type JobState struct {
+	Name           string `json:"name"`
+	LastStartedAt  int64  `json:"last_started_at"`
+	LastFinishedAt int64  `json:"last_finished_at"`
+	LastError      string `json:"last_error"`
+}
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func() error
+}
+
+// jobRunner runs the plugin background jobs. Every node polls the jobs, but a cluster mutex
+// and the job state persisted in the store make sure each run happens on a single node.
+// Jobs must be idempotent, as an interrupted run is executed again from the start.
+type jobRunner struct {
+	api   plugin.API
+	store ListStore
+	jobs  []*job
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newJobRunner(api plugin.API, store ListStore) *jobRunner {
+	return &jobRunner{
+		api:   api,
+		store: store,
+	}
+}
+
+// Register adds a job to run every interval. Jobs must be registered before calling Start.
+func (r *jobRunner) Register(name string, interval time.Duration, run func() error) {
+	r.jobs = append(r.jobs, &job{
+		name:     name,
+		interval: interval,
+		run:      run,
+	})
+}
+
+// Start starts polling the registered jobs.
+func (r *jobRunner) Start() {
+	r.stop = make(chan struct{})
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go r.poll(j)
+	}
+}
+
+// Stop stops polling the jobs and waits for the running ones to finish.
+func (r *jobRunner) Stop() {
+	if r.stop == nil {
+		return
+	}
+	close(r.stop)
+	r.wg.Wait()
+	r.stop = nil
+}
+
+func (r *jobRunner) poll(j *job) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.runIfDue(j)
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// runIfDue runs the job if no other node is running it and its interval has elapsed
+// since the last run.
+func (r *jobRunner) runIfDue(j *job) {
+	mutex, err := cluster.NewMutex(r.api, "job_"+j.name)
+	if err != nil {
+		r.api.LogError("Unable to create job mutex", "job", j.name, "err", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jobLockTimeout)
+	defer cancel()
+	if err = mutex.LockWithContext(ctx); err != nil {
+		// Another node holds the lock and is running the job.
+		return
+	}
+	defer mutex.Unlock()
+
+	state, err := r.store.GetJobState(j.name)
+	if err != nil {
+		r.api.LogError("Unable to get job state", "job", j.name, "err", err.Error())
+		return
+	}
+	if state == nil {
+		state = &JobState{Name: j.name}
+	}
+
+	now := model.GetMillis()
+	interrupted := state.LastStartedAt > state.LastFinishedAt
+	if !interrupted && now-state.LastStartedAt < j.interval.Milliseconds() {
+		return
+	}
+
+	state.LastStartedAt = now
+	if err = r.store.SaveJobState(state); err != nil {
+		r.api.LogError("Unable to save job state", "job", j.name, "err", err.Error())
+		return
+	}
+
+	// A failed run is not finished, so it is retried on the next poll.
+	state.LastError = ""
+	if err = j.run(); err != nil {
+		r.api.LogError("Job failed", "job", j.name, "err", err.Error())
+		state.LastError = err.Error()
+	} else {
+		state.LastFinishedAt = model.GetMillis()
+	}
+
+	if err = r.store.SaveJobState(state); err != nil {
+		r.api.LogError("Unable to save job state", "job", j.name, "err", err.Error())
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunIfDue(t *testing.T) {
+	const interval = time.Hour
+	now := model.GetMillis()
+	ago := func(d time.Duration) int64 {
+		return now - d.Milliseconds()
+	}
+
+	tests := []struct {
+		name        string
+		state       *JobState
+		runErr      error
+		expectRun   bool
+		expectError string
+		// finished is true if the run is recorded as finished
+		finished bool
+	}{
+		{
+			name:      "never ran",
+			expectRun: true,
+			finished:  true,
+		},
+		{
+			name:  "not due yet",
+			state: &JobState{LastStartedAt: ago(30 * time.Minute), LastFinishedAt: ago(29 * time.Minute)},
+		},
+		{
+			name:      "due",
+			state:     &JobState{LastStartedAt: ago(2 * time.Hour), LastFinishedAt: ago(119 * time.Minute)},
+			expectRun: true,
+			finished:  true,
+		},
+		{
+			name:      "resumes an unfinished run",
+			state:     &JobState{LastStartedAt: ago(10 * time.Minute), LastFinishedAt: ago(2 * time.Hour)},
+			expectRun: true,
+			finished:  true,
+		},
+		{
+			name:        "failed run",
+			state:       &JobState{LastStartedAt: ago(2 * time.Hour), LastFinishedAt: ago(119 * time.Minute)},
+			runErr:      errors.New("database unavailable"),
+			expectRun:   true,
+			expectError: "database unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &plugintest.API{}
+			api.On("KVSetWithOptions", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+			api.On("LogError", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+
+			store := NewMemoryStore()
+			var before JobState
+			if tt.state != nil {
+				tt.state.Name = "test"
+				before = *tt.state
+				require.NoError(t, store.SaveJobState(tt.state))
+			}
+
+			ran := 0
+			r := newJobRunner(api, store)
+			r.Register("test", interval, func() error {
+				ran++
+				return tt.runErr
+			})
+			r.runIfDue(r.jobs[0])
+
+			state, err := store.GetJobState("test")
+			require.NoError(t, err)
+			if !tt.expectRun {
+				assert.Zero(t, ran)
+				assert.Equal(t, &before, state, "the state is unchanged")
+				return
+			}
+
+			assert.Equal(t, 1, ran)
+			require.NotNil(t, state)
+			assert.GreaterOrEqual(t, state.LastStartedAt, now)
+			assert.Equal(t, tt.expectError, state.LastError)
+			if tt.finished {
+				assert.GreaterOrEqual(t, state.LastFinishedAt, state.LastStartedAt)
+			} else {
+				assert.Equal(t, before.LastFinishedAt, state.LastFinishedAt, "a failed run is not finished")
+				assert.Greater(t, state.LastStartedAt, state.LastFinishedAt, "a failed run is retried on the next poll")
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+	"github.com/pkg/errors"
+)
+
+const (
+	// legacyListKeyPrefix prefixes the KV keys of the lists stored by the plugin before 2.0.0.
+	// The key is followed by the user ID and the list ID.
+	legacyListKeyPrefix = "order_"
+	// legacyIssueKeyPrefix prefixes the KV keys of the issues stored by the plugin before 2.0.0
+	legacyIssueKeyPrefix = "item_"
+	// kvMigrationProgressKey is the KV key storing the progress of the legacy data migration
+	kvMigrationProgressKey = "kv_migration_progress"
+
+	kvListPerPage = 1000
+)
+
+// kvMigrationProgress records the last legacy list migrated, so an interrupted migration
+// resumes after it.
+type kvMigrationProgress struct {
+	LastListKey string `json:"last_list_key"`
+	Done        bool   `json:"done"`
+}
+
+// migrateLegacyKVData copies the todos stored in the KV store by the plugin before 2.0.0 into
+// the SQL store. Lists are migrated in key order and the progress is saved after each one.
+// Migrating a list is idempotent, so a list interrupted halfway is simply migrated again.
+func (p *Plugin) migrateLegacyKVData() error {
+	mutex, err := cluster.NewMutex(p.API, "kv_migration")
+	if err != nil {
+		return errors.Wrap(err, "failed to create kv migration mutex")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	progress, err := p.getKVMigrationProgress()
+	if err != nil {
+		return err
+	}
+	if progress.Done {
+		return nil
+	}
+
+	// Collect every key first, as saving the progress adds a key to the KV store.
+	listKeys, err := p.getLegacyListKeys()
+	if err != nil {
+		return err
+	}
+
+	if len(listKeys) > 0 {
+		p.API.LogInfo("Migrating legacy todo lists to the SQL store", "lists", len(listKeys), "resume_after", progress.LastListKey)
+	}
+
+	for _, key := range listKeys {
+		if key <= progress.LastListKey {
+			continue
+		}
+
+		if err := p.migrateLegacyList(key); err != nil {
+			return errors.Wrapf(err, "failed to migrate legacy list %s", key)
+		}
+
+		progress.LastListKey = key
+		if err := p.saveKVMigrationProgress(progress); err != nil {
+			return err
+		}
+	}
+
+	progress.Done = true
+	return p.saveKVMigrationProgress(progress)
+}
+
+func (p *Plugin) getLegacyListKeys() ([]string, error) {
+	var listKeys []string
+	for page := 0; ; page++ {
+		keys, appErr := p.API.KVList(page, kvListPerPage)
+		if appErr != nil {
+			return nil, errors.Wrap(appErr, "failed to list KV keys")
+		}
+
+		for _, key := range keys {
+			if strings.HasPrefix(key, legacyListKeyPrefix) {
+				listKeys = append(listKeys, key)
+			}
+		}
+
+		if len(keys) < kvListPerPage {
+			break
+		}
+	}
+
+	sort.Strings(listKeys)
+	return listKeys, nil
+}
+
+// migrateLegacyList migrates the issues referenced by the legacy list stored at key. The
+// first element of a legacy list is its top, so issues get decreasing update times to keep
+// their order.
+func (p *Plugin) migrateLegacyList(key string) error {
+	userID, listID, ok := parseLegacyListKey(key)
+	if !ok {
+		p.API.LogWarn("Skipping legacy list with an unexpected key", "key", key)
+		return nil
+	}
+
+	data, appErr := p.API.KVGet(key)
+	if appErr != nil {
+		return appErr
+	}
+	if data == nil {
+		return nil
+	}
+
+	var refs []*IssueRef
+	if err := json.Unmarshal(data, &refs); err != nil {
+		p.API.LogWarn("Skipping legacy list that cannot be parsed", "key", key, "err", err.Error())
+		return nil
+	}
+
+	// The list is migrated in a single transaction, so an interrupted migration does not
+	// leave it half migrated.
+	now := model.GetMillis()
+	return p.store.Transaction(func(store ListStore) error {
+		for i, ref := range refs {
+			if ref == nil || ref.IssueID == "" {
+				continue
+			}
+
+			issue, err := p.getLegacyIssue(ref.IssueID)
+			if err != nil {
+				return err
+			}
+			if issue == nil {
+				p.API.LogWarn("Skipping legacy reference to a missing issue", "key", key, "issue_id", ref.IssueID)
+				continue
+			}
+
+			issue.ID = ref.IssueID
+			issue.UpdateAt = now - int64(i)
+			if issue.CreateAt == 0 {
+				issue.CreateAt = issue.UpdateAt
+			}
+			issue.ForeignUserID = ref.ForeignUserID
+			issue.ForeignIssueID = ref.ForeignIssueID
+			issue.ListID = MyListKey
+			issue.Rank = ""
+
+			// The row is written with its final creator, assignee and status, as the list of
+			// a todo is derived from them. A todo of the Out list is the sender's copy of a
+			// sent todo, assigned to its receiver.
+			switch listID {
+			case MyListKey:
+				issue.CreatorID = userID
+				if ref.ForeignUserID != "" {
+					issue.CreatorID = ref.ForeignUserID
+				}
+				issue.AssigneeID = userID
+				issue.Status = StatusOpen
+			case InListKey:
+				issue.CreatorID = ref.ForeignUserID
+				issue.AssigneeID = userID
+				issue.Status = StatusPending
+			case OutListKey:
+				issue.CreatorID = userID
+				issue.AssigneeID = ref.ForeignUserID
+				issue.Status = StatusPending
+			}
+
+			if err := store.SaveIssue(issue); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (p *Plugin) getLegacyIssue(issueID string) (*Issue, error) {
+	data, appErr := p.API.KVGet(legacyIssueKeyPrefix + issueID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	issue := &Issue{}
+	if err := json.Unmarshal(data, issue); err != nil {
+		p.API.LogWarn("Skipping legacy issue that cannot be parsed", "issue_id", issueID, "err", err.Error())
+		return nil, nil
+	}
+	return issue, nil
+}
+
+// parseLegacyListKey splits a legacy list key into the user ID and the list ID.
+func parseLegacyListKey(key string) (userID, listID string, ok bool) {
+	rest := strings.TrimPrefix(key, legacyListKeyPrefix)
+	if len(rest) < 26 {
+		return "", "", false
+	}
+
+	userID, listID = rest[:26], rest[26:]
+	if !model.IsValidId(userID) {
+		return "", "", false
+	}
+
+	switch listID {
+	case MyListKey, InListKey, OutListKey:
+		return userID, listID, true
+	default:
+		return "", "", false
+	}
+}
+
+func (p *Plugin) getKVMigrationProgress() (*kvMigrationProgress, error) {
+	progress := &kvMigrationProgress{}
+
+	data, appErr := p.API.KVGet(kvMigrationProgressKey)
+	if appErr != nil {
+		return nil, errors.Wrap(appErr, "failed to get kv migration progress")
+	}
+	if data == nil {
+		return progress, nil
+	}
+
+	if err := json.Unmarshal(data, progress); err != nil {
+		return nil, errors.Wrap(err, "failed to parse kv migration progress")
+	}
+	return progress, nil
+}
+
+func (p *Plugin) saveKVMigrationProgress(progress *kvMigrationProgress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	if appErr := p.API.KVSet(kvMigrationProgressKey, data); appErr != nil {
+		return errors.Wrap(appErr, "failed to save kv migration progress")
+	}
+	return nil
+}
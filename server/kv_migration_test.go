@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLegacyListKey(t *testing.T) {
+	userID := "abcdefghijklmnopqrstuvwxyz"
+
+	tests := []struct {
+		name       string
+		key        string
+		wantUserID string
+		wantListID string
+		wantOK     bool
+	}{
+		{name: "my list", key: "order_" + userID, wantUserID: userID, wantListID: MyListKey, wantOK: true},
+		{name: "in list", key: "order_" + userID + "_in", wantUserID: userID, wantListID: InListKey, wantOK: true},
+		{name: "out list", key: "order_" + userID + "_out", wantUserID: userID, wantListID: OutListKey, wantOK: true},
+		{name: "unknown list", key: "order_" + userID + "_other", wantOK: false},
+		{name: "short user id", key: "order_abc", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, listID, ok := parseLegacyListKey(tt.key)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantUserID, userID)
+			assert.Equal(t, tt.wantListID, listID)
+		})
+	}
+}
+
+// setupTestKVMigration returns a plugin whose KV store holds the legacy data kv, with an
+// empty memory store to migrate it to
+func setupTestKVMigration(t *testing.T, kv map[string]interface{}) (*Plugin, *MemoryStore, map[string][]byte) {
+	t.Helper()
+
+	data := map[string][]byte{}
+	keys := []string{}
+	for key, value := range kv {
+		b, err := json.Marshal(value)
+		require.NoError(t, err)
+		data[key] = b
+		keys = append(keys, key)
+	}
+
+	api := &plugintest.API{}
+	api.On("KVList", 0, kvListPerPage).Return(keys, nil)
+	api.On("KVGet", mock.AnythingOfType("string")).Return(func(key string) []byte {
+		return data[key]
+	}, nil)
+	api.On("KVSet", kvMigrationProgressKey, mock.Anything).Return(func(key string, value []byte) *model.AppError {
+		data[key] = value
+		return nil
+	})
+	api.On("KVSetWithOptions", mock.Anything, mock.Anything, mock.Anything).Return(true, nil).Maybe()
+	api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+
+	store := NewMemoryStore()
+	p := &Plugin{}
+	p.SetAPI(api)
+	p.store = store
+	return p, store, data
+}
+
+func TestMigrateLegacyKVData(t *testing.T) {
+	const (
+		myTop    = "mytopissue0000000000000000"
+		myBottom = "mybottomissue0000000000000"
+		received = "receivedissue0000000000000"
+		sent     = "sentissue00000000000000000"
+		sentCopy = "sentcopyissue0000000000000"
+	)
+	kv := map[string]interface{}{
+		legacyListKeyPrefix + testUserID: []*IssueRef{{IssueID: myTop}, {IssueID: myBottom}},
+		legacyListKeyPrefix + testUserID + InListKey: []*IssueRef{
+			{IssueID: received, ForeignUserID: testOtherID, ForeignIssueID: "othercopyissue000000000000"},
+		},
+		legacyListKeyPrefix + testUserID + OutListKey: []*IssueRef{
+			{IssueID: sent, ForeignUserID: testOtherID, ForeignIssueID: sentCopy},
+		},
+		legacyListKeyPrefix + testOtherID + InListKey: []*IssueRef{
+			{IssueID: sentCopy, ForeignUserID: testUserID, ForeignIssueID: sent},
+		},
+	}
+	for _, id := range []string{myTop, myBottom, received, sent, sentCopy} {
+		kv[legacyIssueKeyPrefix+id] = &Issue{Message: "todo " + id}
+	}
+
+	p, store, _ := setupTestKVMigration(t, kv)
+	require.NoError(t, p.migrateLegacyKVData())
+
+	my, err := store.GetList(testUserID, MyListKey)
+	require.NoError(t, err)
+	require.Len(t, my, 2)
+	assert.Equal(t, myTop, my[0].IssueID, "the top of a legacy list stays on top")
+	assert.Equal(t, myBottom, my[1].IssueID)
+
+	in, err := store.GetList(testUserID, InListKey)
+	require.NoError(t, err)
+	require.Len(t, in, 1)
+	issue, err := store.GetIssue(received)
+	require.NoError(t, err)
+	assert.Equal(t, testOtherID, issue.CreatorID)
+	assert.Equal(t, testUserID, issue.AssigneeID)
+	assert.Equal(t, StatusPending, issue.Status)
+
+	out, err := store.GetList(testUserID, OutListKey)
+	require.NoError(t, err)
+	require.Len(t, out, 1, "sent todos stay in the Out list of their sender")
+	assert.Equal(t, &IssueRef{IssueID: sent, ForeignUserID: testOtherID, ForeignIssueID: sentCopy}, out[0])
+	issue, err = store.GetIssue(sent)
+	require.NoError(t, err)
+	assert.True(t, issue.IsSenderCopy())
+	assert.Equal(t, testUserID, issue.CreatorID)
+	assert.Equal(t, testOtherID, issue.AssigneeID)
+	assert.Equal(t, StatusPending, issue.Status)
+
+	otherIn, err := store.GetList(testOtherID, InListKey)
+	require.NoError(t, err)
+	assert.Equal(t, []*IssueRef{{IssueID: sentCopy, ForeignUserID: testUserID, ForeignIssueID: sent}}, otherIn)
+}
+
+func TestMigrateLegacyKVDataResumes(t *testing.T) {
+	const (
+		migrated = "migratedissue0000000000000"
+		pending  = "pendingissue00000000000000"
+	)
+	kv := map[string]interface{}{
+		legacyListKeyPrefix + testOtherID: []*IssueRef{{IssueID: pending}},
+		legacyListKeyPrefix + testUserID:  []*IssueRef{{IssueID: migrated}},
+		legacyIssueKeyPrefix + migrated:   &Issue{Message: "migrated"},
+		legacyIssueKeyPrefix + pending:    &Issue{Message: "pending"},
+		kvMigrationProgressKey:            &kvMigrationProgress{LastListKey: legacyListKeyPrefix + testOtherID},
+	}
+
+	p, store, data := setupTestKVMigration(t, kv)
+	require.NoError(t, p.migrateLegacyKVData())
+
+	_, err := store.GetIssue(pending)
+	assert.Error(t, err, "the lists migrated before the interruption are skipped")
+	my, err := store.GetList(testUserID, MyListKey)
+	require.NoError(t, err)
+	assert.Equal(t, []*IssueRef{{IssueID: migrated}}, my)
+
+	progress := &kvMigrationProgress{}
+	require.NoError(t, json.Unmarshal(data[kvMigrationProgressKey], progress))
+	assert.Equal(t, &kvMigrationProgress{LastListKey: legacyListKeyPrefix + testUserID, Done: true}, progress)
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
@@ -15,33 +17,90 @@ const (
 	InListKey = "_in"
 	// OutListKey is the key used to store the list of sent todos
 	OutListKey = "_out"
+	// DoneListKey is the key used to store the list of completed todos
+	DoneListKey = "_done"
 )
 
+// doneListLimit is the maximum number of todos returned in the Done list of GetAllList
+const doneListLimit = 50
+
+// searchResultsLimit is the maximum number of todos returned by a search
+const searchResultsLimit = 50
+
+// trashListLimit is the maximum number of todos returned by GetTrash
+const trashListLimit = 100
+
+// ErrConflict is returned when updating a todo that changed since the version read by the client
+var ErrConflict = errors.New("the todo was modified in the meantime")
+
 // ListStore represents the KVStore operations for lists
 type ListStore interface {
+	// Transaction runs fn with a store whose operations are applied atomically. Nothing is
+	// applied when fn returns an error, which is then returned.
+	Transaction(fn func(store ListStore) error) error
+
 	// Issue related function
 	SaveIssue(issue *Issue) error
 	GetIssue(issueID string) (*Issue, error)
 	RemoveIssue(issueID string) error
 	GetAndRemoveIssue(issueID string) (*Issue, error)
+	// UpdateIssue saves an existing issue if its version is still issue.Version, and
+	// increments the version. It returns ErrConflict if the issue changed in the meantime.
+	UpdateIssue(issue *Issue) error
 
 	// Issue References related functions
 
 	// AddReference creates a new IssueRef with the issueID, foreignUSerID and foreignIssueID, and stores it
-	// on the listID for userID.
+	// on the listID for userID. As the list of a todo is derived from its creator, assignee and status,
+	// this rewrites them: a todo of the In list becomes pending for userID, and a todo of the Out list
+	// becomes the sender's copy, created by userID, assigned to foreignUserID and pending.
 	AddReference(userID, issueID, listID, foreignUserID, foreignIssueID string) error
 	// RemoveReference removes the IssueRef for issueID in listID for userID
 	RemoveReference(userID, issueID, listID string) error
 	// PopReference removes the first IssueRef in listID for userID and returns it
 	PopReference(userID, listID string) (*IssueRef, error)
-	// BumpReference moves the Issue reference for issueID in listID for userID to the beginning of the list
-	BumpReference(userID, issueID, listID string) error
-	// GetIssueReference gets the IssueRef and position of the issue issueID on user userID's list listID
-	GetIssueReference(userID, issueID, listID string) (*IssueRef, int, error)
-	// GetIssueListAndReference gets the issue list, IssueRef and position for user userID
-	GetIssueListAndReference(userID, issueID string) (string, *IssueRef, int)
+	// SetIssueRank sets the rank of issueID in its list, without changing its version
+	SetIssueRank(issueID, rank string) error
+	// GetIssueReference gets the IssueRef of the issue issueID on user userID's list listID
+	GetIssueReference(userID, issueID, listID string) (*IssueRef, error)
+	// GetLinkedIssues returns the issues whose foreign issue is issueID, such as the copies of
+	// the assignees of a shared todo sent as issueID, oldest first
+	GetLinkedIssues(issueID string) ([]*Issue, error)
+	// GetIssueListAndReference gets the issue list and IssueRef for user userID, without
+	// reading the list
+	GetIssueListAndReference(userID, issueID string) (string, *IssueRef)
 	// GetList returns the list of IssueRef in listID for userID
 	GetList(userID, listID string) ([]*IssueRef, error)
+	// GetListHead returns up to limit of the first IssueRefs in listID for userID
+	GetListHead(userID, listID string, limit int) ([]*IssueRef, error)
+	// QueryIssues returns up to limit of the issues owned by userID matching query, in its order
+	QueryIssues(userID string, query *IssueQuery, limit int) ([]*Issue, error)
+	// SearchIssues returns up to limit of the issues owned by userID, or of the channels
+	// channelIDs, whose text or comments contain terms, most recently updated first. The copy
+	// of a sent todo owned by the other party is left out. An empty userID searches every issue.
+	SearchIssues(userID string, channelIDs []string, terms string, limit int) ([]*Issue, error)
+	// GetTrash returns up to limit of the trashed issues owned by userID, most recently
+	// trashed first
+	GetTrash(userID string, limit int) ([]*Issue, error)
+	// PurgeTrash permanently deletes the issues trashed before deletedBefore with their
+	// comments, and with their audit logs if purgeAuditLogs is set. It returns the number
+	// of deleted issues.
+	PurgeTrash(deletedBefore int64, purgeAuditLogs bool) (int, error)
+
+	// Channel todos
+
+	// GetChannelIssues returns up to limit of the todos of channelID with status. Done todos
+	// are sorted most recently completed first, and the other ones most recently created first.
+	GetChannelIssues(channelID, status string, limit int) ([]*Issue, error)
+
+	// Teams
+
+	// GetAssignedIssues returns up to limit of the open and pending todos assigned to one of
+	// userIDs, the ones due first first
+	GetAssignedIssues(userIDs []string, limit int) ([]*Issue, error)
+	// CountAssignedIssues counts the open todos, the open todos overdue at now and the pending
+	// todos of each of userIDs. Users without such todos are not in the returned map.
+	CountAssignedIssues(userIDs []string, now int64) (map[string]*AssigneeCounts, error)
 
 	// Preferences
 	SetReminderPreference(userID string, enabled bool) error
@@ -50,6 +109,8 @@ type ListStore interface {
 	GetLastReminderTime(userID string) (int64, error)
 	SetAllowIncomingTaskPreference(userID string, enabled bool) error
 	GetAllowIncomingTaskPreference(userID string) (bool, error)
+	// GetUsersToRemind returns the users with open todos that have not disabled the daily reminder
+	GetUsersToRemind() ([]string, error)
 
 	// Comments
 	SaveComment(comment *Comment) error
@@ -57,9 +118,71 @@ type ListStore interface {
 	DeleteComment(commentID string) error
 	GetComment(commentID string) (*Comment, error)
 
+	// Checklists
+
+	// SaveChecklistItem creates or updates a checklist item
+	SaveChecklistItem(item *ChecklistItem) error
+	GetChecklistItem(itemID string) (*ChecklistItem, error)
+	// GetChecklist returns the checklist items of todoID, in their sort order
+	GetChecklist(todoID string) ([]*ChecklistItem, error)
+	DeleteChecklistItem(itemID string) error
+	// GetChecklistProgress returns the progress of the checklists of todoIDs. Todos without a
+	// checklist are not in the returned map.
+	GetChecklistProgress(todoIDs []string) (map[string]*ChecklistProgress, error)
+
+	// Tags
+	// GetTag returns the tag of userID named name, or nil if there is none
+	GetTag(userID, name string) (*Tag, error)
+	SaveTag(tag *Tag) error
+	// GetTags returns the tags of userID, sorted by name
+	GetTags(userID string) ([]*Tag, error)
+	AddIssueTag(todoID, tagID string) error
+	RemoveIssueTag(todoID, tagID string) error
+	// GetIssueTags returns the sorted tag names of todoIDs. Todos without tags are not in
+	// the returned map.
+	GetIssueTags(todoIDs []string) (map[string][]string, error)
+
+	// Custom lists
+	SaveCustomList(list *CustomList) error
+	// GetCustomList returns the custom list listID, or nil if there is none
+	GetCustomList(listID string) (*CustomList, error)
+	// GetCustomLists returns the custom lists of userID, in their order
+	GetCustomLists(userID string) ([]*CustomList, error)
+	// DeleteCustomList deletes the custom list listID and moves its todos back to the My list
+	DeleteCustomList(listID string) error
+
+	// Dependencies
+	// AddDependency records that todoID is blocked by blockerID
+	AddDependency(todoID, blockerID string) error
+	RemoveDependency(todoID, blockerID string) error
+	// GetBlockers returns the IDs of the todos blocking each of todoIDs. Todos without
+	// blockers are not in the returned map.
+	GetBlockers(todoIDs []string) (map[string][]string, error)
+	// GetBlockedIssues returns the IDs of the todos blocked by each of blockerIDs. Todos
+	// blocking nothing are not in the returned map.
+	GetBlockedIssues(blockerIDs []string) (map[string][]string, error)
+
+	// Watchers
+	AddWatcher(todoID, userID string) error
+	RemoveWatcher(todoID, userID string) error
+	// GetWatchers returns the sorted IDs of the users watching todoID
+	GetWatchers(todoID string) ([]string, error)
+
 	// Audit Log
 	AddAuditLog(log *AuditLog) error
 	GetAuditLogs(todoID string) ([]*AuditLog, error)
+	// GetLastAuditLog returns the most recent audit log of an action of userID created at or
+	// after since, or nil if there is none. The passiveAuditLogActions recorded for userID
+	// are skipped. Of the logs created at the same time, the ones with a snapshot are the most
+	// recent, as an undoable action is recorded last in its transaction.
+	GetLastAuditLog(userID string, since int64) (*AuditLog, error)
+
+	// Background jobs
+
+	// GetJobState returns the persisted state of the job name, or nil if it never ran
+	GetJobState(name string) (*JobState, error)
+	// SaveJobState creates or updates the persisted state of a job
+	SaveJobState(state *JobState) error
 }
 
 type listManager struct {
@@ -75,22 +198,33 @@ func NewListManager(api plugin.API, store ListStore) ListManager {
 	}
 }
 
+// transaction runs fn with a listManager whose store operations are applied atomically
+func (l *listManager) transaction(fn func(tx *listManager) error) error {
+	return l.store.Transaction(func(store ListStore) error {
+		return fn(&listManager{store: store, api: l.api})
+	})
+}
+
 func (l *listManager) AddIssue(userID, message, postPermalink, description, postID string, dueAt int64, priority int) (*Issue, error) {
 	message = SanitizeInput(message)
 	description = SanitizeMultiline(description)
-	issue := newIssue(message, postPermalink, description, postID, userID, userID, "open", dueAt, priority)
+	issue := newIssue(message, postPermalink, description, postID, userID, userID, StatusOpen, dueAt, priority)
 
-	if err := l.store.SaveIssue(issue); err != nil {
-		return nil, err
-	}
-
-	if err := l.store.AddReference(userID, issue.ID, MyListKey, "", ""); err != nil {
-		if rollbackError := l.store.RemoveIssue(issue.ID); rollbackError != nil {
-			l.api.LogError("cannot rollback issue after add error, Err=", err.Error())
+	err := l.transaction(func(tx *listManager) error {
+		if err := tx.store.SaveIssue(issue); err != nil {
+			return err
+		}
+		if err := tx.store.AddReference(userID, issue.ID, MyListKey, "", ""); err != nil {
+			return err
+		}
+		if err := tx.placeAtTop(issue.ID); err != nil {
+			return err
 		}
+		return tx.recordAuditLog(issue.ID, userID, "create", "")
+	})
+	if err != nil {
 		return nil, err
 	}
-	l.recordAuditLog(issue.ID, userID, "create", "")
 
 	return issue, nil
 }
@@ -98,43 +232,36 @@ func (l *listManager) AddIssue(userID, message, postPermalink, description, post
 func (l *listManager) SendIssue(senderID, receiverID, message, postPermalink, description, postID string, dueAt int64, priority int) (string, error) {
 	message = SanitizeInput(message)
 	description = SanitizeMultiline(description)
-	senderIssue := newIssue(message, postPermalink, description, postID, senderID, receiverID, "pending", dueAt, priority)
-	if err := l.store.SaveIssue(senderIssue); err != nil {
-		return "", err
-	}
+	senderIssue := newIssue(message, postPermalink, description, postID, senderID, receiverID, StatusPending, dueAt, priority)
+	receiverIssue := newIssue(message, postPermalink, description, postID, senderID, receiverID, StatusPending, dueAt, priority)
 
-	receiverIssue := newIssue(message, postPermalink, description, postID, senderID, receiverID, "pending", dueAt, priority)
-	if err := l.store.SaveIssue(receiverIssue); err != nil {
-		if rollbackError := l.store.RemoveIssue(senderIssue.ID); rollbackError != nil {
-			l.api.LogError("cannot rollback sender issue after send error, Err=", err.Error())
+	err := l.transaction(func(tx *listManager) error {
+		if err := tx.store.SaveIssue(senderIssue); err != nil {
+			return err
 		}
-		return "", err
-	}
-
-	if err := l.store.AddReference(senderID, senderIssue.ID, OutListKey, receiverID, receiverIssue.ID); err != nil {
-		if rollbackError := l.store.RemoveIssue(senderIssue.ID); rollbackError != nil {
-			l.api.LogError("cannot rollback sender issue after send error, Err=", err.Error())
+		if err := tx.store.SaveIssue(receiverIssue); err != nil {
+			return err
 		}
-		if rollbackError := l.store.RemoveIssue(receiverIssue.ID); rollbackError != nil {
-			l.api.LogError("cannot rollback receiver issue after send error, Err=", err.Error())
+		if err := tx.store.AddReference(senderID, senderIssue.ID, OutListKey, receiverID, receiverIssue.ID); err != nil {
+			return err
 		}
-		return "", err
-	}
-
-	if err := l.store.AddReference(receiverID, receiverIssue.ID, InListKey, senderID, senderIssue.ID); err != nil {
-		if rollbackError := l.store.RemoveIssue(senderIssue.ID); rollbackError != nil {
-			l.api.LogError("cannot rollback sender issue after send error, Err=", err.Error())
+		if err := tx.store.AddReference(receiverID, receiverIssue.ID, InListKey, senderID, senderIssue.ID); err != nil {
+			return err
 		}
-		if rollbackError := l.store.RemoveIssue(receiverIssue.ID); rollbackError != nil {
-			l.api.LogError("cannot rollback receiver issue after send error ,Err=", err.Error())
+		if err := tx.placeAtTop(senderIssue.ID); err != nil {
+			return err
 		}
-		if rollbackError := l.store.RemoveReference(senderID, senderIssue.ID, OutListKey); rollbackError != nil {
-			l.api.LogError("cannot rollback sender list after send error, Err=", err.Error())
+		if err := tx.placeAtTop(receiverIssue.ID); err != nil {
+			return err
 		}
+		if err := tx.recordAuditLog(senderIssue.ID, senderID, "send", receiverID); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(receiverIssue.ID, receiverID, "receive", senderID)
+	})
+	if err != nil {
 		return "", err
 	}
-	l.recordAuditLog(senderIssue.ID, senderID, "send", receiverID)
-	l.recordAuditLog(receiverIssue.ID, receiverID, "receive", senderID)
 
 	return receiverIssue.ID, nil
 }
@@ -144,18 +271,36 @@ func (l *listManager) GetIssueList(userID, listID string) ([]*ExtendedIssue, err
 	if err != nil {
 		return nil, err
 	}
+	return l.extendIssueList(irs)
+}
 
+// extendIssueList returns the issues of the references irs with their extended info
+func (l *listManager) extendIssueList(irs []*IssueRef) ([]*ExtendedIssue, error) {
 	extendedIssues := []*ExtendedIssue{}
+	positions := listPositions{}
 	for _, ir := range irs {
 		issue, err := l.store.GetIssue(ir.IssueID)
 		if err != nil {
 			continue
 		}
 
-		extendedIssue := l.extendIssueInfo(issue, ir)
+		extendedIssue := l.extendIssueInfo(issue, ir, positions)
 		extendedIssues = append(extendedIssues, extendedIssue)
 	}
 
+	if err := l.addChecklistProgress(extendedIssues); err != nil {
+		return nil, err
+	}
+	if err := l.addTags(extendedIssues); err != nil {
+		return nil, err
+	}
+	if err := l.addDependencies(extendedIssues); err != nil {
+		return nil, err
+	}
+	if err := l.addAssignees(extendedIssues); err != nil {
+		return nil, err
+	}
+
 	return extendedIssues, nil
 }
 
@@ -172,184 +317,322 @@ func (l *listManager) GetAllList(userID string) (listsIssue *ListsIssue, err err
 	if err != nil {
 		return nil, err
 	}
+	doneRefs, err := l.store.GetListHead(userID, DoneListKey, doneListLimit)
+	if err != nil {
+		return nil, err
+	}
+	doneListIssue, err := l.extendIssueList(doneRefs)
+	if err != nil {
+		return nil, err
+	}
+	customListsIssues, err := l.getCustomListIssues(userID)
+	if err != nil {
+		return nil, err
+	}
 	return &ListsIssue{
-		In:  inListIssue,
-		My:  myListIssue,
-		Out: outListIssue,
+		In:    inListIssue,
+		My:    myListIssue,
+		Out:   outListIssue,
+		Done:  doneListIssue,
+		Lists: customListsIssues,
 	}, nil
 }
 
+func (l *listManager) QueryIssues(userID string, query *IssueQuery) (*IssuePage, error) {
+	// Get one more issue than requested, to know if there is a next page.
+	issues, err := l.store.QueryIssues(userID, query, query.PerPage+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &IssuePage{Issues: issues}
+	if len(issues) > query.PerPage {
+		page.Issues = issues[:query.PerPage]
+		page.NextCursor = query.cursorFor(page.Issues[query.PerPage-1]).Encode()
+	}
+	if page.Issues == nil {
+		page.Issues = []*Issue{}
+	}
+
+	return page, nil
+}
+
+func (l *listManager) SearchIssues(userID, terms string) ([]*Issue, error) {
+	terms = strings.TrimSpace(terms)
+	if terms == "" {
+		return nil, errors.New("search terms are required")
+	}
+
+	// The search matches the scope of IsAuthorized: system admins are authorized on every
+	// todo, and the other users on their todos and the todos of their channels.
+	scope := userID
+	var channelIDs []string
+	if l.isSystemAdmin(userID) {
+		scope = ""
+	} else {
+		var err error
+		channelIDs, err = l.getMemberChannelIDs(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	issues, err := l.store.SearchIssues(scope, channelIDs, terms, searchResultsLimit)
+	if err != nil {
+		return nil, err
+	}
+	if issues == nil {
+		issues = []*Issue{}
+	}
+	return issues, nil
+}
+
 func (l *listManager) CompleteIssue(userID, issueID string) (issue *Issue, foreignID string, listToUpdate string, err error) {
-	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
-	if ir == nil {
-		return nil, "", issueList, fmt.Errorf("cannot find element")
+	err = l.transaction(func(tx *listManager) error {
+		var err error
+		issue, foreignID, listToUpdate, err = tx.completeIssue(userID, issueID)
+		return err
+	})
+	if err != nil {
+		return nil, "", listToUpdate, err
 	}
 
-	if err = l.store.RemoveReference(userID, issueID, issueList); err != nil {
-		return nil, "", issueList, err
+	return issue, foreignID, listToUpdate, nil
+}
+
+// completeIssue moves issueID to the Done list of userID, with the copy of the other party of
+// a sent todo, and adds the next occurrence of a recurring todo. The copies of a shared todo
+// are completed as described by completeSharedIssue.
+func (l *listManager) completeIssue(userID, issueID string) (issue *Issue, foreignID string, listToUpdate string, err error) {
+	issueList, ir := l.store.GetIssueListAndReference(userID, issueID)
+	listToUpdate = issueList
+	if ir == nil {
+		return nil, "", listToUpdate, fmt.Errorf("cannot find element")
 	}
 
 	issue, err = l.store.GetIssue(issueID)
 	if err != nil {
-		return nil, "", issueList, err
+		return nil, "", listToUpdate, err
 	}
-	issue.Status = "completed"
-	issue.UpdateAt = model.GetMillis()
-	if err := l.store.SaveIssue(issue); err != nil {
-		l.api.LogError("cannot update issue status, Err=", err.Error())
+	if err = checkTransition(issue.Status, StatusDone); err != nil {
+		return nil, "", listToUpdate, err
 	}
-	l.recordAuditLog(issueID, userID, "complete", "")
 
-	if ir.ForeignUserID == "" {
-		return issue, "", issueList, nil
+	copies, err := l.linkedCopies(issue)
+	if err != nil {
+		return nil, "", listToUpdate, err
 	}
+	before := append(l.snapshotIssues(issueID), copies...)
 
-	err = l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey)
+	issue, err = l.setIssueStatus(issueID, StatusDone)
 	if err != nil {
-		l.api.LogError("cannot clean foreigner list after complete, Err=", err.Error())
+		return nil, "", listToUpdate, err
 	}
 
-	foreignIssue, err := l.store.GetIssue(ir.ForeignIssueID)
-	if err == nil {
-		foreignIssue.Status = "completed"
-		foreignIssue.UpdateAt = model.GetMillis()
-		_ = l.store.SaveIssue(foreignIssue)
+	foreignID = ir.ForeignUserID
+	if err = l.completeCopies(issue, ir, copies); err != nil {
+		return nil, "", listToUpdate, err
 	}
 
-	return issue, ir.ForeignUserID, issueList, nil
-}
-func (l *listManager) EditIssue(userID string, issueID string, newMessage string, newDescription string, newDueAt int64, newPriority int) (string, string, string, error) {
-	issue, err := l.store.GetIssue(issueID)
+	next, err := l.addNextOccurrence(userID, issue)
 	if err != nil {
-		return "", "", "", err
+		return nil, "", listToUpdate, err
+	}
+	if next != nil {
+		err = l.recordUndoableAuditLog(issueID, userID, "complete", "", before, next)
+	} else {
+		err = l.recordUndoableAuditLog(issueID, userID, "complete", "", before)
+	}
+	if err != nil {
+		return nil, "", listToUpdate, err
 	}
 
-	list, _, _ := l.store.GetIssueListAndReference(userID, issueID)
-
-	oldMessage := issue.Message
-	message := SanitizeInput(newMessage)
-	description := SanitizeMultiline(newDescription)
+	return issue, foreignID, listToUpdate, nil
+}
 
-	if issue.ForeignIssueID != "" {
-		foreignIssue, foreignErr := l.store.GetIssue(issue.ForeignIssueID)
-		if foreignErr == nil {
-			foreignIssue.Message = message
-			foreignIssue.Description = description
-			foreignIssue.DueAt = newDueAt
-			foreignIssue.Priority = newPriority
-			foreignIssue.UpdateAt = model.GetMillis()
-			_ = l.store.SaveIssue(foreignIssue)
+func (l *listManager) EditIssue(userID string, issueID string, newMessage string, newDescription string, newDueAt int64, newPriority int, version int64) (foreignUserID string, list string, oldMessage string, err error) {
+	err = l.transaction(func(tx *listManager) error {
+		issue, err := tx.store.GetIssue(issueID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(issue, version); err != nil {
+			return err
 		}
-	}
 
-	issue.Message = message
-	issue.Description = description
-	issue.DueAt = newDueAt
-	issue.Priority = newPriority
-	issue.UpdateAt = model.GetMillis()
+		list, _ = tx.store.GetIssueListAndReference(userID, issueID)
 
-	if err := l.store.SaveIssue(issue); err != nil {
-		return "", "", "", err
-	}
-	l.recordAuditLog(issue.ID, userID, "edit", "")
+		oldMessage = issue.Message
+		message := SanitizeInput(newMessage)
+		description := SanitizeMultiline(newDescription)
 
-	return issue.ForeignUserID, list, oldMessage, nil
-}
+		copies, err := tx.linkedCopies(issue)
+		if err != nil {
+			return err
+		}
+		issues := append([]*Issue{issue}, copies...)
+
+		// All the copies are updated in ID order, so concurrent edits of a sent todo by its
+		// sender and its receivers lock them in the same order.
+		sort.Slice(issues, func(i, j int) bool { return issues[i].ID < issues[j].ID })
+		for _, edited := range issues {
+			edited.Message = message
+			edited.Description = description
+			edited.DueAt = newDueAt
+			edited.Priority = newPriority
+			edited.UpdateAt = model.GetMillis()
+			if err := tx.store.UpdateIssue(edited); err != nil {
+				return err
+			}
+		}
 
-func (l *listManager) ChangeAssignment(issueID string, userID string, sendTo string) (issue *Issue, oldOwner string, err error) {
-	issue, err = l.store.GetIssue(issueID)
+		foreignUserID = issue.ForeignUserID
+		return tx.recordAuditLog(issue.ID, userID, "edit", "")
+	})
 	if err != nil {
-		return nil, "", err
+		return "", "", "", err
 	}
 
-	list, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
-	if ir == nil {
-		return nil, "", errors.New("reference not found")
-	}
+	return foreignUserID, list, oldMessage, nil
+}
 
-	if (list == InListKey) || (ir.ForeignIssueID != "" && list == MyListKey) {
-		return nil, "", errors.New("trying to change the assignment of a todo not owned")
-	}
+func (l *listManager) ChangeAssignment(issueID string, userID string, sendTo string, version int64) (issue *Issue, oldOwner string, err error) {
+	err = l.transaction(func(tx *listManager) error {
+		var err error
+		issue, err = tx.store.GetIssue(issueID)
+		if err != nil {
+			return err
+		}
+		if err = checkVersion(issue, version); err != nil {
+			return err
+		}
+		if issue.IsShared() {
+			return fmt.Errorf("%w: the assignees of a shared todo cannot be changed", ErrInvalidAssignees)
+		}
 
-	if ir.ForeignUserID != "" {
-		// Remove reference from foreign user
-		foreignList, foreignIR, _ := l.store.GetIssueListAndReference(ir.ForeignUserID, ir.ForeignIssueID)
-		if foreignIR == nil {
-			return nil, "", errors.New("reference not found")
+		list, ir := tx.store.GetIssueListAndReference(userID, issueID)
+		if ir == nil {
+			return errors.New("reference not found")
 		}
 
-		if err := l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, foreignList); err != nil {
-			return nil, "", err
+		if (list == InListKey) || (ir.ForeignIssueID != "" && isOwnedList(list)) {
+			return errors.New("trying to change the assignment of a todo not owned")
+		}
+		// The receiver who declined the todo no longer has it.
+		if issue.Status != StatusDeclined {
+			oldOwner = ir.ForeignUserID
 		}
 
-		_, err := l.store.GetAndRemoveIssue(ir.ForeignIssueID)
-		if err != nil {
-			l.api.LogError("cannot remove issue", "err", err.Error())
+		// Claim the version before changing the references, so a concurrent change of the
+		// todo makes one of the two fail.
+		issue.UpdateAt = model.GetMillis()
+		if err = tx.store.UpdateIssue(issue); err != nil {
+			return err
 		}
-	}
 
-	if userID == sendTo && list == OutListKey {
-		if err := l.store.RemoveReference(userID, issueID, OutListKey); err != nil {
-			return nil, "", err
+		if ir.ForeignUserID != "" {
+			// Remove reference from foreign user. The declined copy of the receiver is in none
+			// of their lists, and is only removed.
+			if issue.Status != StatusDeclined {
+				foreignList, foreignIR := tx.store.GetIssueListAndReference(ir.ForeignUserID, ir.ForeignIssueID)
+				if foreignIR == nil {
+					return errors.New("reference not found")
+				}
+
+				if err := tx.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, foreignList); err != nil {
+					return err
+				}
+			}
+
+			if err := tx.store.RemoveIssue(ir.ForeignIssueID); err != nil {
+				return err
+			}
 		}
 
-		if err := l.store.AddReference(userID, issueID, MyListKey, "", ""); err != nil {
-			return nil, "", err
+		if userID == sendTo && list == OutListKey {
+			if err := tx.store.RemoveReference(userID, issueID, OutListKey); err != nil {
+				return err
+			}
+
+			if err := tx.store.AddReference(userID, issueID, MyListKey, "", ""); err != nil {
+				return err
+			}
+			return tx.placeAtTop(issueID)
 		}
 
-		return issue, ir.ForeignUserID, nil
-	}
+		if userID != sendTo {
+			if err := tx.store.RemoveReference(userID, issueID, list); err != nil {
+				return err
+			}
+		}
 
-	if userID != sendTo {
-		if err := l.store.RemoveReference(userID, issueID, list); err != nil {
-			return nil, "", err
+		receiverIssue := newIssue(issue.Message, issue.PostPermalink, issue.Description, issue.PostID, userID, sendTo, StatusPending, issue.DueAt, issue.Priority)
+		if err := tx.store.SaveIssue(receiverIssue); err != nil {
+			return err
 		}
-	}
 
-	receiverIssue := newIssue(issue.Message, issue.PostPermalink, issue.Description, issue.PostID, userID, sendTo, "pending", issue.DueAt, issue.Priority)
-	if err := l.store.SaveIssue(receiverIssue); err != nil {
-		return nil, "", err
-	}
+		if err := tx.store.AddReference(userID, issueID, OutListKey, sendTo, receiverIssue.ID); err != nil {
+			return err
+		}
 
-	if err := l.store.AddReference(userID, issueID, OutListKey, sendTo, receiverIssue.ID); err != nil {
+		if err := tx.store.AddReference(sendTo, receiverIssue.ID, InListKey, userID, issueID); err != nil {
+			return err
+		}
+		if err := tx.placeAtTop(issueID); err != nil {
+			return err
+		}
+		if err := tx.placeAtTop(receiverIssue.ID); err != nil {
+			return err
+		}
+
+		if err := tx.recordAuditLog(receiverIssue.ID, sendTo, "receive", userID); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(issueID, userID, "reassign", sendTo)
+	})
+	if err != nil {
 		return nil, "", err
 	}
 
-	l.recordAuditLog(receiverIssue.ID, sendTo, "receive", userID)
-	l.recordAuditLog(issueID, userID, "reassign", sendTo)
-
-	return issue, ir.ForeignUserID, nil
+	return issue, oldOwner, nil
 }
 
 func (l *listManager) AcceptIssue(userID, issueID string) (todoMessage string, foreignUserID string, outErr error) {
-	issue, err := l.store.GetIssue(issueID)
-	if err != nil {
-		return "", "", err
-	}
+	var issue *Issue
+	var ir *IssueRef
+	err := l.transaction(func(tx *listManager) error {
+		var err error
+		issue, err = tx.store.GetIssue(issueID)
+		if err != nil {
+			return err
+		}
 
-	ir, _, err := l.store.GetIssueReference(userID, issueID, InListKey)
-	if err != nil {
-		return "", "", err
-	}
-	if ir == nil {
-		return "", "", fmt.Errorf("element reference not found")
-	}
+		ir, err = tx.store.GetIssueReference(userID, issueID, InListKey)
+		if err != nil {
+			return err
+		}
+		if ir == nil {
+			return fmt.Errorf("element reference not found")
+		}
 
-	err = l.store.AddReference(userID, issueID, MyListKey, ir.ForeignUserID, ir.ForeignIssueID)
-	if err != nil {
-		return "", "", err
-	}
+		before := tx.snapshotIssues(issueID)
+		if err := tx.store.AddReference(userID, issueID, MyListKey, ir.ForeignUserID, ir.ForeignIssueID); err != nil {
+			return err
+		}
 
-	err = l.store.RemoveReference(userID, issueID, InListKey)
-	if err != nil {
-		if rollbackError := l.store.RemoveReference(userID, issueID, MyListKey); rollbackError != nil {
-			l.api.LogError("cannot rollback accept operation, Err=", rollbackError.Error())
+		if err := tx.store.RemoveReference(userID, issueID, InListKey); err != nil {
+			return err
+		}
+		if err := tx.placeAtTop(issueID); err != nil {
+			return err
 		}
+
+		return tx.recordUndoableAuditLog(issueID, userID, "accept", ir.ForeignUserID, before)
+	})
+	if err != nil {
 		return "", "", err
 	}
 
-	l.recordAuditLog(issueID, userID, "accept", ir.ForeignUserID)
-
 	if issue.PostPermalink != "" {
 		issue.Message = fmt.Sprintf("%s\n[Permalink](%s)", issue.Message, issue.PostPermalink)
 	}
@@ -357,109 +640,363 @@ func (l *listManager) AcceptIssue(userID, issueID string) (todoMessage string, f
 	return issue.Message, ir.ForeignUserID, nil
 }
 
-func (l *listManager) RemoveIssue(userID, issueID string) (outIssue *Issue, foreignID string, isSender bool, listToUpdate string, outErr error) {
-	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
-	if ir == nil {
-		return nil, "", false, issueList, fmt.Errorf("cannot find element")
-	}
+// DeclineIssue declines the todo issueID received by userID, for the optional reason recorded
+// in the audit log. The todo goes back to its sender, whose copy is declined and stays in
+// their Out list until they send it again, take it back or remove it. It returns the declined
+// copy of userID and the sender.
+func (l *listManager) DeclineIssue(userID, issueID, reason string) (issue *Issue, senderID string, err error) {
+	reason = SanitizeInput(reason)
 
-	if err := l.store.RemoveReference(userID, issueID, issueList); err != nil {
-		return nil, "", false, issueList, err
-	}
+	err = l.transaction(func(tx *listManager) error {
+		issue, err = tx.store.GetIssue(issueID)
+		if err != nil {
+			return err
+		}
+		if list, _ := issue.ListFor(userID); list != InListKey {
+			return errors.New("cannot find the todo in the In list")
+		}
+		if err = checkTransition(issue.Status, StatusDeclined); err != nil {
+			return err
+		}
 
-	issue, err := l.store.GetIssue(issueID)
+		copies, err := tx.linkedCopies(issue)
+		if err != nil {
+			return err
+		}
+		if issue, err = tx.setIssueStatus(issueID, StatusDeclined); err != nil {
+			return err
+		}
+		if err = tx.recordAuditLog(issueID, userID, "decline", reason); err != nil {
+			return err
+		}
+		senderID = issue.ForeignUserID
+
+		if issue.IsShared() {
+			return tx.declineSharedIssue(issue, copies, reason)
+		}
+		for _, c := range copies {
+			if _, err = tx.setIssueStatus(c.ID, StatusDeclined); err != nil {
+				return err
+			}
+			// The sender finds the reason in the history of their copy.
+			if err = tx.recordAuditLog(c.ID, userID, "decline", reason); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, "", false, issueList, err
+		return nil, "", err
 	}
-	issue.Status = "removed"
-	issue.UpdateAt = model.GetMillis()
-	if err := l.store.SaveIssue(issue); err != nil {
-		l.api.LogError("cannot update issue status, Err=", err.Error())
+
+	return issue, senderID, nil
+}
+
+// RemoveIssue moves the issue to the trash of userID. The copy of the other party of a
+// sent todo is moved to their trash as well. An assignee removing a shared todo only removes
+// their copy, while its sender removes the copies of all the assignees.
+func (l *listManager) RemoveIssue(userID, issueID string) (outIssue *Issue, foreignID string, isSender bool, listToUpdate string, outErr error) {
+	err := l.transaction(func(tx *listManager) error {
+		issueList, ir := tx.store.GetIssueListAndReference(userID, issueID)
+		listToUpdate = issueList
+		if ir == nil {
+			return fmt.Errorf("cannot find element")
+		}
+
+		issue, err := tx.store.GetIssue(issueID)
+		if err != nil {
+			return err
+		}
+		copies, err := tx.linkedCopies(issue)
+		if err != nil {
+			return err
+		}
+		before := append([]*Issue{issue}, copies...)
+
+		outIssue, err = tx.trashIssue(issueID)
+		if err != nil {
+			return err
+		}
+
+		foreignID = ir.ForeignUserID
+		// The receivers who declined the todo are not notified of its removal.
+		if issue.Status == StatusDeclined {
+			foreignID = ""
+		}
+		if issue.IsShared() {
+			isSender = !issue.IsSenderCopy()
+			if err := tx.removeSharedIssue(issue, copies); err != nil {
+				return err
+			}
+		} else if ir.ForeignUserID != "" {
+			list, _ := tx.store.GetIssueListAndReference(ir.ForeignUserID, ir.ForeignIssueID)
+			isSender = list == OutListKey
+
+			if _, err := tx.store.GetIssue(ir.ForeignIssueID); err == nil {
+				foreignIssue, err := tx.trashIssue(ir.ForeignIssueID)
+				if err != nil {
+					return err
+				}
+				outIssue = foreignIssue
+			}
+		}
+
+		return tx.recordUndoableAuditLog(issueID, userID, "remove", "", before)
+	})
+	if err != nil {
+		return nil, "", false, listToUpdate, err
 	}
 
-	if ir.ForeignUserID == "" {
-		l.recordAuditLog(issueID, userID, "remove", "")
-		return issue, "", false, issueList, nil
+	return outIssue, foreignID, isSender, listToUpdate, nil
+}
+
+// removeSharedIssue removes the copies of the shared todo issue, just removed by one of its
+// parties. The sender removes the todo for every assignee, and the sender's copy of a todo
+// with CompletionAll is done when the assignee removing it was the last one left to complete it.
+func (l *listManager) removeSharedIssue(issue *Issue, copies []*Issue) error {
+	if !issue.IsSenderCopy() {
+		return l.finishSharedIssue(copies)
 	}
-	list, _, _ := l.store.GetIssueListAndReference(ir.ForeignUserID, ir.ForeignIssueID)
 
-	err = l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, list)
-	if err != nil {
-		l.api.LogError("cannot clean foreigner list after remove, Err=", err.Error())
+	for _, c := range copies {
+		if c.DeletedAt != 0 {
+			continue
+		}
+		if _, err := l.trashIssue(c.ID); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// GetTrash returns the trashed todos of userID, most recently trashed first
+func (l *listManager) GetTrash(userID string) ([]*Issue, error) {
+	return l.store.GetTrash(userID, trashListLimit)
+}
 
-	issue, err = l.store.GetAndRemoveIssue(ir.ForeignIssueID)
+// RestoreIssue moves a trashed issue of userID back to its list. The copy of the other party
+// of a sent todo is restored with it if it is still in the trash. When that copy no longer
+// exists, the issue is restored as a todo of userID only. Shared todos are restored as
+// described by restoreSharedIssue.
+func (l *listManager) RestoreIssue(userID, issueID string) (issue *Issue, foreignID string, foreignList string, listToUpdate string, err error) {
+	err = l.transaction(func(tx *listManager) error {
+		issue, err = tx.store.GetIssue(issueID)
+		if err != nil || issue.DeletedAt == 0 || issue.OwnerID() != userID {
+			return errors.New("cannot find the todo in the trash")
+		}
+
+		if issue.IsShared() {
+			if foreignID, foreignList, err = tx.restoreSharedIssue(issue); err != nil {
+				return err
+			}
+		}
+
+		issue.DeletedAt = 0
+		issue.UpdateAt = model.GetMillis()
+
+		if issue.ForeignIssueID != "" && !issue.IsShared() {
+			foreignIssue, err := tx.store.GetIssue(issue.ForeignIssueID)
+			if err != nil || foreignIssue.ForeignIssueID != issue.ID {
+				detachIssue(issue)
+			} else if foreignIssue.DeletedAt != 0 {
+				foreignIssue.DeletedAt = 0
+				foreignIssue.UpdateAt = issue.UpdateAt
+				if err := tx.store.UpdateIssue(foreignIssue); err != nil {
+					return err
+				}
+				foreignID = issue.ForeignUserID
+				foreignList, _ = foreignIssue.ListFor(foreignID)
+			}
+		}
+
+		if err := tx.store.UpdateIssue(issue); err != nil {
+			return err
+		}
+		if err := tx.placeAtTop(issueID); err != nil {
+			return err
+		}
+		listToUpdate, _ = issue.ListFor(userID)
+
+		return tx.recordAuditLog(issueID, userID, "restore", "")
+	})
 	if err != nil {
-		l.api.LogError("cannot clean foreigner issue after remove, Err=", err.Error())
+		return nil, "", "", "", err
 	}
 
-	l.recordAuditLog(issueID, userID, "remove", "")
-
-	return issue, ir.ForeignUserID, list == OutListKey, issueList, nil
+	return issue, foreignID, foreignList, listToUpdate, nil
 }
 
 func (l *listManager) PopIssue(userID string) (issue *Issue, foreignID string, err error) {
-	ir, err := l.store.PopReference(userID, MyListKey)
+	err = l.transaction(func(tx *listManager) error {
+		refs, err := tx.store.GetList(userID, MyListKey)
+		if err != nil {
+			return err
+		}
+		var before []*Issue
+		if len(refs) > 0 {
+			top, err := tx.store.GetIssue(refs[0].IssueID)
+			if err != nil {
+				return err
+			}
+			if err := checkTransition(top.Status, StatusDone); err != nil {
+				return err
+			}
+			before = tx.snapshotIssues(top.ID)
+		}
+
+		ir, err := tx.store.PopReference(userID, MyListKey)
+		if err != nil {
+			return err
+		}
+
+		if ir == nil {
+			return errors.New("unexpected nil for issue reference")
+		}
+
+		issue, err = tx.setIssueStatus(ir.IssueID, StatusDone)
+		if err != nil {
+			return err
+		}
+
+		copies, err := tx.linkedCopies(issue)
+		if err != nil {
+			return err
+		}
+		before = append(before, copies...)
+
+		foreignID = ir.ForeignUserID
+		if err := tx.completeCopies(issue, ir, copies); err != nil {
+			return err
+		}
+
+		return tx.recordUndoableAuditLog(ir.IssueID, userID, "pop", "", before)
+	})
 	if err != nil {
 		return nil, "", err
 	}
 
-	if ir == nil {
-		return nil, "", errors.New("unexpected nil for issue reference")
-	}
+	return issue, foreignID, nil
+}
 
-	issue, err = l.store.GetIssue(ir.IssueID)
+func (l *listManager) BumpIssue(userID, issueID string) (todo *Issue, receiver string, foreignIssueID string, outErr error) {
+	var ir *IssueRef
+	err := l.transaction(func(tx *listManager) error {
+		var err error
+		ir, err = tx.store.GetIssueReference(userID, issueID, OutListKey)
+		if err != nil {
+			return err
+		}
+
+		if ir == nil {
+			return fmt.Errorf("cannot find sender issue")
+		}
+
+		before := tx.snapshotIssues(ir.ForeignIssueID)
+		others, err := tx.otherAssigneeCopies(issueID, ir.ForeignIssueID)
+		if err != nil {
+			return err
+		}
+		before = append(before, others...)
+
+		if err = tx.placeAtTop(ir.ForeignIssueID); err != nil {
+			return err
+		}
+		for _, other := range others {
+			if err = tx.placeAtTop(other.ID); err != nil {
+				return err
+			}
+			if err = tx.recordAuditLog(other.ID, other.AssigneeID, "bumped_by", userID); err != nil {
+				return err
+			}
+		}
+
+		todo, err = tx.store.GetIssue(ir.ForeignIssueID)
+		if err != nil {
+			return errors.Wrap(err, "cannot find foreigner issue after bump")
+		}
+
+		if err = tx.recordAuditLog(ir.ForeignIssueID, ir.ForeignUserID, "bumped_by", userID); err != nil {
+			return err
+		}
+		return tx.recordUndoableAuditLog(issueID, userID, "bump", ir.ForeignUserID, before)
+	})
 	if err != nil {
-		l.api.LogError("cannot find issue after pop, Err=", err.Error())
-		return nil, "", err
+		return nil, "", "", err
 	}
-	issue.Status = "completed"
-	issue.UpdateAt = model.GetMillis()
-	if err := l.store.SaveIssue(issue); err != nil {
-		l.api.LogError("cannot update issue status after pop, Err=", err.Error())
+
+	return todo, ir.ForeignUserID, ir.ForeignIssueID, nil
+}
+
+// completeCopies completes the copies of the other parties of the sent todo issue, just
+// completed through ir. copies are the copies returned by linkedCopies.
+func (l *listManager) completeCopies(issue *Issue, ir *IssueRef, copies []*Issue) error {
+	if issue.IsShared() {
+		return l.completeSharedIssue(issue, copies)
 	}
 	if ir.ForeignUserID == "" {
-		return issue, "", nil
+		return nil
 	}
 
-	err = l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey)
-	if err != nil {
-		l.api.LogError("cannot clean foreigner list after pop, Err=", err.Error())
-	}
-	foreignIssue, err := l.store.GetIssue(ir.ForeignIssueID)
-	if err == nil {
-		foreignIssue.Status = "completed"
-		foreignIssue.UpdateAt = model.GetMillis()
-		_ = l.store.SaveIssue(foreignIssue)
+	if err := l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey); err != nil {
+		return err
 	}
+	return l.setForeignIssueStatus(ir.ForeignIssueID, StatusDone)
+}
 
-	return issue, ir.ForeignUserID, nil
+// checkVersion returns ErrConflict if issue is no longer at the version read by the client.
+// A version of 0 skips the check, for the slash commands, which do not show the version. The
+// API requires the version.
+func checkVersion(issue *Issue, version int64) error {
+	if version != 0 && issue.Version != version {
+		return ErrConflict
+	}
+	return nil
 }
 
-func (l *listManager) BumpIssue(userID, issueID string) (todo *Issue, receiver string, foreignIssueID string, outErr error) {
-	ir, _, err := l.store.GetIssueReference(userID, issueID, OutListKey)
+// setIssueStatus updates the status of issueID and returns the issue. Done issues get
+// their completion time. It returns ErrConflict if the issue changed since it was read.
+func (l *listManager) setIssueStatus(issueID, status string) (*Issue, error) {
+	issue, err := l.store.GetIssue(issueID)
 	if err != nil {
-		return nil, "", "", err
+		return nil, err
 	}
 
-	if ir == nil {
-		return nil, "", "", fmt.Errorf("cannot find sender issue")
+	issue.Status = status
+	issue.UpdateAt = model.GetMillis()
+	if status == StatusDone {
+		issue.CompletedAt = issue.UpdateAt
 	}
+	if err := l.store.UpdateIssue(issue); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
 
-	err = l.store.BumpReference(ir.ForeignUserID, ir.ForeignIssueID, InListKey)
+// trashIssue moves issueID to the trash and returns the issue. It returns ErrConflict if
+// the issue changed since it was read.
+func (l *listManager) trashIssue(issueID string) (*Issue, error) {
+	issue, err := l.store.GetIssue(issueID)
 	if err != nil {
-		return nil, "", "", err
+		return nil, err
 	}
 
-	issue, err := l.store.GetIssue(ir.ForeignIssueID)
-	if err != nil {
-		l.api.LogError("cannot find foreigner issue after bump, Err=", err.Error())
-		return nil, "", "", nil
+	issue.UpdateAt = model.GetMillis()
+	issue.DeletedAt = issue.UpdateAt
+	if err := l.store.UpdateIssue(issue); err != nil {
+		return nil, err
 	}
+	return issue, nil
+}
 
-	l.recordAuditLog(ir.ForeignIssueID, ir.ForeignUserID, "bumped_by", userID)
+// setForeignIssueStatus updates the status of the other party's copy of a todo. The copy
+// may already have been removed, in which case there is nothing to update.
+func (l *listManager) setForeignIssueStatus(foreignIssueID, status string) error {
+	if _, err := l.store.GetIssue(foreignIssueID); err != nil {
+		return nil
+	}
 
-	return issue, ir.ForeignUserID, ir.ForeignIssueID, nil
+	_, err := l.setIssueStatus(foreignIssueID, status)
+	return err
 }
 
 func (l *listManager) GetUserName(userID string) string {
@@ -470,7 +1007,29 @@ func (l *listManager) GetUserName(userID string) string {
 	return user.Username
 }
 
-func (l *listManager) extendIssueInfo(issue *Issue, ir *IssueRef) *ExtendedIssue {
+// listPositions holds the positions of the issues in the lists read by extendIssueInfo,
+// by user and list, so that each list is read once
+type listPositions map[string]map[string]int
+
+// position returns the position of issueID in listID of userID
+func (l *listManager) position(positions listPositions, userID, listID, issueID string) int {
+	key := userID + "/" + listID
+	list, ok := positions[key]
+	if !ok {
+		list = map[string]int{}
+		refs, err := l.store.GetList(userID, listID)
+		if err != nil {
+			l.api.LogError("Unable to get the list", "err", err.Error())
+		}
+		for i, ref := range refs {
+			list[ref.IssueID] = i
+		}
+		positions[key] = list
+	}
+	return list[issueID]
+}
+
+func (l *listManager) extendIssueInfo(issue *Issue, ir *IssueRef, positions listPositions) *ExtendedIssue {
 	if issue == nil || ir == nil {
 		return nil
 	}
@@ -483,7 +1042,11 @@ func (l *listManager) extendIssueInfo(issue *Issue, ir *IssueRef) *ExtendedIssue
 		return feIssue
 	}
 
-	list, _, n := l.store.GetIssueListAndReference(ir.ForeignUserID, ir.ForeignIssueID)
+	list, _ := l.store.GetIssueListAndReference(ir.ForeignUserID, ir.ForeignIssueID)
+	n := 0
+	if list != "" {
+		n = l.position(positions, ir.ForeignUserID, list, ir.ForeignIssueID)
+	}
 
 	var listName string
 	switch list {
@@ -493,6 +1056,8 @@ func (l *listManager) extendIssueInfo(issue *Issue, ir *IssueRef) *ExtendedIssue
 		listName = InFlag
 	case OutListKey:
 		listName = OutFlag
+	case DoneListKey:
+		listName = DoneFlag
 	}
 
 	userName := l.GetUserName(ir.ForeignUserID)
@@ -511,10 +1076,17 @@ func (l *listManager) AddComment(todoID, userID, message string) (*Comment, erro
 		UserID:  userID,
 		Message: message,
 	}
-	if err := l.store.SaveComment(comment); err != nil {
+
+	err := l.transaction(func(tx *listManager) error {
+		if err := tx.store.SaveComment(comment); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(todoID, userID, "add_comment", comment.ID)
+	})
+	if err != nil {
 		return nil, err
 	}
-	l.recordAuditLog(todoID, userID, "add_comment", comment.ID)
+
 	return comment, nil
 }
 
@@ -546,29 +1118,44 @@ func (l *listManager) DeleteComment(commentID, userID string) error {
 		return errors.New("not authorized to delete this comment")
 	}
 
-	if err := l.store.DeleteComment(commentID); err != nil {
-		return err
-	}
-	l.recordAuditLog(comment.TodoID, userID, "delete_comment", commentID)
-	return nil
+	return l.transaction(func(tx *listManager) error {
+		if err := tx.store.DeleteComment(commentID); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(comment.TodoID, userID, "delete_comment", commentID)
+	})
 }
 
-func (l *listManager) recordAuditLog(todoID, userID, action, metadata string) {
-	log := &AuditLog{
+// passiveAuditLogActions are the actions recorded for a user when another user changes their
+// todos, such as sending them a todo. They are not actions of the user.
+var passiveAuditLogActions = []string{"receive", "bumped_by"}
+
+// recordAuditLog adds an entry to the audit log of todoID. It must be called from the
+// transaction of the operation it records.
+func (l *listManager) recordAuditLog(todoID, userID, action, metadata string) error {
+	return l.addAuditLog(&AuditLog{
 		TodoID:   todoID,
 		UserID:   userID,
 		Action:   action,
 		Metadata: metadata,
-	}
+	})
+}
+
+func (l *listManager) addAuditLog(log *AuditLog) error {
 	if err := l.store.AddAuditLog(log); err != nil {
-		l.api.LogError("failed to record audit log", "error", err.Error())
+		return errors.Wrap(err, "failed to record audit log")
 	}
+	return nil
+}
+
+func (l *listManager) isSystemAdmin(userID string) bool {
+	user, err := l.api.GetUser(userID)
+	return err == nil && user.IsSystemAdmin()
 }
 
 func (l *listManager) IsAuthorized(todoID, userID string) (bool, error) {
 	// Root bypass
-	user, err := l.api.GetUser(userID)
-	if err == nil && user.IsSystemAdmin() {
+	if l.isSystemAdmin(userID) {
 		return true, nil
 	}
 
@@ -577,8 +1164,27 @@ func (l *listManager) IsAuthorized(todoID, userID string) (bool, error) {
 		return false, err2
 	}
 
+	if issue.ChannelID != "" {
+		return l.isChannelMember(issue.ChannelID, userID), nil
+	}
+
 	if issue.CreatorID == userID || issue.AssigneeID == userID {
 		return true, nil
 	}
 	return false, nil
 }
+
+// IsAuthorizedToRead checks if the user can see the todo. The watchers of a todo can see it,
+// but not change it.
+func (l *listManager) IsAuthorizedToRead(todoID, userID string) (bool, error) {
+	authorized, err := l.IsAuthorized(todoID, userID)
+	if err != nil || authorized {
+		return authorized, err
+	}
+
+	issue, err := l.store.GetIssue(todoID)
+	if err != nil {
+		return false, err
+	}
+	return l.isWatcher(issue, userID)
+}
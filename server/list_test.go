@@ -0,0 +1,775 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testUserID  = "testuserid0000000000000000"
+	testOtherID = "testotherid000000000000000"
+	testThirdID = "testthirdid000000000000000"
+)
+
+func setupTestListManager(t *testing.T) (*listManager, *MemoryStore) {
+	t.Helper()
+
+	api := &plugintest.API{}
+	api.On("GetUser", mock.AnythingOfType("string")).Return(func(userID string) *model.User {
+		return &model.User{Id: userID, Username: "user_" + userID[:4]}
+	}, nil).Maybe()
+	api.On("LogError", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	t.Cleanup(func() { api.AssertExpectations(t) })
+
+	store := NewMemoryStore()
+	return &listManager{store: store, api: api}, store
+}
+
+func issueIDs(issues []*ExtendedIssue) []string {
+	ids := []string{}
+	for _, issue := range issues {
+		ids = append(ids, issue.ID)
+	}
+	return ids
+}
+
+func TestAddIssue(t *testing.T) {
+	l, store := setupTestListManager(t)
+
+	issue, err := l.AddIssue(testUserID, "<b>be awesome</b>", "", "", "", 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "be awesome", issue.Message)
+
+	myList, err := l.GetIssueList(testUserID, MyListKey)
+	require.NoError(t, err)
+	assert.Equal(t, []string{issue.ID}, issueIDs(myList))
+
+	logs, err := store.GetAuditLogs(issue.ID)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "create", logs[0].Action)
+}
+
+func TestAddReference(t *testing.T) {
+	store := NewMemoryStore()
+	for _, id := range []string{"myissue", "received", "sent"} {
+		require.NoError(t, store.SaveIssue(&Issue{ID: id, CreatorID: testUserID, AssigneeID: testUserID, Status: StatusOpen}))
+	}
+
+	require.NoError(t, store.AddReference(testUserID, "myissue", MyListKey, "", ""))
+	require.NoError(t, store.AddReference(testUserID, "received", InListKey, testOtherID, "othercopy"))
+	require.NoError(t, store.AddReference(testUserID, "sent", OutListKey, testOtherID, "othercopy"))
+
+	for listID, issueID := range map[string]string{MyListKey: "myissue", InListKey: "received", OutListKey: "sent"} {
+		refs, err := store.GetList(testUserID, listID)
+		require.NoError(t, err)
+		require.Len(t, refs, 1, listID)
+		assert.Equal(t, issueID, refs[0].IssueID, listID)
+	}
+
+	sent, err := store.GetIssue("sent")
+	require.NoError(t, err)
+	assert.True(t, sent.IsSenderCopy(), "a todo of the Out list is the sender's copy")
+	assert.Equal(t, testUserID, sent.CreatorID)
+	assert.Equal(t, testOtherID, sent.AssigneeID)
+	assert.Equal(t, StatusPending, sent.Status)
+
+	otherIn, err := store.GetList(testOtherID, InListKey)
+	require.NoError(t, err)
+	assert.Empty(t, otherIn, "the sender's copy is not in the receiver's lists")
+}
+
+func TestSendAcceptAndCompleteIssue(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	receiverIssueID, err := l.SendIssue(testUserID, testOtherID, "review the PR", "", "", "", 0, 0)
+	require.NoError(t, err)
+
+	lists, err := l.GetAllList(testUserID)
+	require.NoError(t, err)
+	assert.Empty(t, lists.My)
+	assert.Empty(t, lists.In)
+	require.Len(t, lists.Out, 1)
+	assert.Equal(t, "user_"+testOtherID[:4], lists.Out[0].ForeignUser)
+	assert.Equal(t, InFlag, lists.Out[0].ForeignList)
+
+	lists, err = l.GetAllList(testOtherID)
+	require.NoError(t, err)
+	assert.Empty(t, lists.My)
+	assert.Empty(t, lists.Out)
+	assert.Equal(t, []string{receiverIssueID}, issueIDs(lists.In))
+
+	message, sender, err := l.AcceptIssue(testOtherID, receiverIssueID)
+	require.NoError(t, err)
+	assert.Equal(t, "review the PR", message)
+	assert.Equal(t, testUserID, sender)
+
+	lists, err = l.GetAllList(testOtherID)
+	require.NoError(t, err)
+	assert.Empty(t, lists.In)
+	assert.Equal(t, []string{receiverIssueID}, issueIDs(lists.My))
+
+	outList, err := l.GetIssueList(testUserID, OutListKey)
+	require.NoError(t, err)
+	require.Len(t, outList, 1)
+	assert.Equal(t, MyListKey, outList[0].ForeignList)
+
+	issue, foreignID, list, err := l.CompleteIssue(testOtherID, receiverIssueID)
+	require.NoError(t, err)
+	assert.Equal(t, receiverIssueID, issue.ID)
+	assert.Equal(t, testUserID, foreignID)
+	assert.Equal(t, MyListKey, list)
+
+	lists, err = l.GetAllList(testOtherID)
+	require.NoError(t, err)
+	assert.Empty(t, lists.My)
+	require.Equal(t, []string{receiverIssueID}, issueIDs(lists.Done))
+	assert.Equal(t, StatusDone, lists.Done[0].Status)
+	assert.NotZero(t, lists.Done[0].CompletedAt)
+
+	lists, err = l.GetAllList(testUserID)
+	require.NoError(t, err)
+	assert.Empty(t, lists.Out)
+	assert.Empty(t, lists.Done)
+}
+
+func TestRemoveReceivedIssue(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	receiverIssueID, err := l.SendIssue(testUserID, testOtherID, "review the PR", "", "", "", 0, 0)
+	require.NoError(t, err)
+
+	_, foreignID, isSender, list, err := l.RemoveIssue(testOtherID, receiverIssueID)
+	require.NoError(t, err)
+	assert.Equal(t, testUserID, foreignID)
+	assert.True(t, isSender, "the foreign user is the sender")
+	assert.Equal(t, InListKey, list)
+
+	inList, err := l.GetIssueList(testOtherID, InListKey)
+	require.NoError(t, err)
+	assert.Empty(t, inList)
+
+	outList, err := l.GetIssueList(testUserID, OutListKey)
+	require.NoError(t, err)
+	assert.Empty(t, outList)
+}
+
+func TestRestoreIssue(t *testing.T) {
+	l, store := setupTestListManager(t)
+
+	receiverIssueID, err := l.SendIssue(testUserID, testOtherID, "review the PR", "", "", "", 0, 0)
+	require.NoError(t, err)
+	_, _, _, _, err = l.RemoveIssue(testOtherID, receiverIssueID)
+	require.NoError(t, err)
+
+	trash, err := l.GetTrash(testOtherID)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, receiverIssueID, trash[0].ID)
+	assert.Len(t, store.issues, 2, "removed todos are kept in the trash")
+
+	_, _, _, _, err = l.RestoreIssue(testThirdID, receiverIssueID)
+	assert.Error(t, err, "only the owner can restore a todo")
+
+	issue, foreignID, foreignList, list, err := l.RestoreIssue(testOtherID, receiverIssueID)
+	require.NoError(t, err)
+	assert.Zero(t, issue.DeletedAt)
+	assert.Equal(t, testUserID, foreignID)
+	assert.Equal(t, OutListKey, foreignList)
+	assert.Equal(t, InListKey, list)
+
+	inList, err := l.GetIssueList(testOtherID, InListKey)
+	require.NoError(t, err)
+	assert.Equal(t, []string{receiverIssueID}, issueIDs(inList))
+
+	outList, err := l.GetIssueList(testUserID, OutListKey)
+	require.NoError(t, err)
+	assert.Len(t, outList, 1, "the sender's copy is restored too")
+
+	trash, err = l.GetTrash(testOtherID)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+func TestRestoreIssueWithoutForeignCopy(t *testing.T) {
+	l, store := setupTestListManager(t)
+
+	receiverIssueID, err := l.SendIssue(testUserID, testOtherID, "review the PR", "", "", "", 0, 0)
+	require.NoError(t, err)
+	outList, err := l.GetIssueList(testUserID, OutListKey)
+	require.NoError(t, err)
+	senderIssueID := outList[0].ID
+
+	_, _, _, _, err = l.RemoveIssue(testUserID, senderIssueID)
+	require.NoError(t, err)
+	require.NoError(t, store.RemoveIssue(receiverIssueID))
+
+	issue, foreignID, _, list, err := l.RestoreIssue(testUserID, senderIssueID)
+	require.NoError(t, err)
+	assert.Empty(t, foreignID)
+	assert.Equal(t, MyListKey, list)
+	assert.Equal(t, StatusOpen, issue.Status)
+	assert.Empty(t, issue.ForeignIssueID)
+}
+
+func TestPurgeTrash(t *testing.T) {
+	l, store := setupTestListManager(t)
+
+	removed, err := l.AddIssue(testUserID, "removed", "", "", "", 0, 0)
+	require.NoError(t, err)
+	kept, err := l.AddIssue(testUserID, "kept", "", "", "", 0, 0)
+	require.NoError(t, err)
+	_, err = l.AddComment(removed.ID, testUserID, "a comment")
+	require.NoError(t, err)
+	_, _, _, _, err = l.RemoveIssue(testUserID, removed.ID)
+	require.NoError(t, err)
+
+	purged, err := store.PurgeTrash(model.GetMillis()-1000, true)
+	require.NoError(t, err)
+	assert.Zero(t, purged, "the retention is not over")
+
+	purged, err = store.PurgeTrash(model.GetMillis()+1, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = store.GetIssue(removed.ID)
+	assert.Error(t, err)
+	_, err = store.GetIssue(kept.ID)
+	assert.NoError(t, err)
+	comments, err := store.GetComments(removed.ID)
+	require.NoError(t, err)
+	assert.Empty(t, comments)
+	logs, err := store.GetAuditLogs(removed.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, logs, "the audit log is kept unless configured otherwise")
+}
+
+func TestUndoLastAction(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	_, _, err := l.UndoLastAction(testOtherID, "")
+	assert.ErrorIs(t, err, ErrNothingToUndo)
+
+	receiverIssueID, err := l.SendIssue(testUserID, testOtherID, "review the PR", "", "", "", 0, 0)
+	require.NoError(t, err)
+	_, _, err = l.AcceptIssue(testOtherID, receiverIssueID)
+	require.NoError(t, err)
+	_, _, _, err = l.CompleteIssue(testOtherID, receiverIssueID)
+	require.NoError(t, err)
+
+	log, issues, err := l.UndoLastAction(testOtherID, "")
+	require.NoError(t, err)
+	assert.Equal(t, "complete", log.Action)
+	assert.Len(t, issues, 2, "both copies are reverted")
+
+	lists, err := l.GetAllList(testOtherID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{receiverIssueID}, issueIDs(lists.My))
+	assert.Empty(t, lists.Done)
+	outList, err := l.GetIssueList(testUserID, OutListKey)
+	require.NoError(t, err)
+	assert.Len(t, outList, 1)
+
+	_, _, err = l.UndoLastAction(testOtherID, "")
+	assert.ErrorIs(t, err, ErrNothingToUndo, "only the last action can be undone")
+}
+
+func TestUndoChangedIssue(t *testing.T) {
+	l, store := setupTestListManager(t)
+
+	issue, err := l.AddIssue(testUserID, "write the docs", "", "", "", 0, 0)
+	require.NoError(t, err)
+	_, _, _, _, err = l.RemoveIssue(testUserID, issue.ID)
+	require.NoError(t, err)
+	removed, err := store.GetIssue(issue.ID)
+	require.NoError(t, err)
+	removed.Message = "write the API docs"
+	require.NoError(t, store.UpdateIssue(removed))
+
+	_, _, err = l.UndoLastAction(testUserID, "")
+	assert.ErrorIs(t, err, ErrConflict, "the removal cannot be undone once the todo changed")
+}
+
+func TestUndoOnlyLastAction(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	first, err := l.AddIssue(testUserID, "write the docs", "", "", "", 0, 0)
+	require.NoError(t, err)
+	second, err := l.AddIssue(testUserID, "write the tests", "", "", "", 0, 0)
+	require.NoError(t, err)
+
+	_, _, _, err = l.CompleteIssue(testUserID, first.ID)
+	require.NoError(t, err)
+	completeLogID, err := l.GetUndoableAction(testUserID)
+	require.NoError(t, err)
+	require.NotEmpty(t, completeLogID)
+
+	_, _, _, _, err = l.RemoveIssue(testUserID, second.ID)
+	require.NoError(t, err)
+	_, _, err = l.UndoLastAction(testUserID, completeLogID)
+	assert.ErrorIs(t, err, ErrNothingToUndo, "the Undo button of an older action does not revert the newest one")
+
+	_, err = l.AddComment(first.ID, testUserID, "done already")
+	require.NoError(t, err)
+	logID, err := l.GetUndoableAction(testUserID)
+	require.NoError(t, err)
+	assert.Empty(t, logID)
+	_, _, err = l.UndoLastAction(testUserID, "")
+	assert.ErrorIs(t, err, ErrNothingToUndo, "the removal is no longer the last action")
+}
+
+func TestUndoAfterReceivingIssue(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	issue, err := l.AddIssue(testUserID, "write the docs", "", "", "", 0, 0)
+	require.NoError(t, err)
+	_, _, _, err = l.CompleteIssue(testUserID, issue.ID)
+	require.NoError(t, err)
+
+	receiverIssueID, err := l.SendIssue(testOtherID, testUserID, "review the PR", "", "", "", 0, 0)
+	require.NoError(t, err)
+	receiverIssue, err := l.store.GetIssue(receiverIssueID)
+	require.NoError(t, err)
+	_, _, _, err = l.BumpIssue(testOtherID, receiverIssue.ForeignIssueID)
+	require.NoError(t, err)
+
+	log, _, err := l.UndoLastAction(testUserID, "")
+	require.NoError(t, err, "the todos received and bumped by others are not actions of the user")
+	assert.Equal(t, "complete", log.Action)
+}
+
+func TestChecklistAutoComplete(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	issue, err := l.AddIssue(testUserID, "release", "", "", "", 0, 0)
+	require.NoError(t, err)
+	first, err := l.AddChecklistItem(testUserID, issue.ID, "tag the release")
+	require.NoError(t, err)
+	second, err := l.AddChecklistItem(testUserID, issue.ID, "publish the notes")
+	require.NoError(t, err)
+	require.NoError(t, l.SetChecklistAutoComplete(testUserID, issue.ID, true))
+
+	_, completed, _, _, err := l.CheckChecklistItem(testUserID, first.ID, true)
+	require.NoError(t, err)
+	assert.Nil(t, completed)
+
+	myList, err := l.GetIssueList(testUserID, MyListKey)
+	require.NoError(t, err)
+	require.Len(t, myList, 1)
+	assert.Equal(t, &ChecklistProgress{Checked: 1, Total: 2}, myList[0].Checklist)
+
+	_, completed, _, _, err = l.CheckChecklistItem(testUserID, second.ID, true)
+	require.NoError(t, err)
+	require.NotNil(t, completed)
+	assert.Equal(t, issue.ID, completed.ID)
+
+	lists, err := l.GetAllList(testUserID)
+	require.NoError(t, err)
+	assert.Empty(t, lists.My)
+	assert.Equal(t, []string{issue.ID}, issueIDs(lists.Done))
+}
+
+func TestCompleteRecurringIssue(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	dueAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	issue, err := l.AddIssue(testUserID, "weekly report", "", "", "", dueAt.UnixMilli(), 0)
+	require.NoError(t, err)
+	_, err = l.SetRecurrence(testUserID, issue.ID, "weekly", 0)
+	require.NoError(t, err)
+	item, err := l.AddChecklistItem(testUserID, issue.ID, "send it")
+	require.NoError(t, err)
+	_, _, _, _, err = l.CheckChecklistItem(testUserID, item.ID, true)
+	require.NoError(t, err)
+
+	_, _, _, err = l.CompleteIssue(testUserID, issue.ID)
+	require.NoError(t, err)
+
+	myList, err := l.GetIssueList(testUserID, MyListKey)
+	require.NoError(t, err)
+	require.Len(t, myList, 1)
+	next := myList[0]
+	assert.NotEqual(t, issue.ID, next.ID)
+	assert.Equal(t, "FREQ=WEEKLY", next.Recurrence)
+	assert.Zero(t, time.UnixMilli(next.DueAt).Sub(dueAt)%(7*24*time.Hour), "the next occurrence is on the same weekday")
+	assert.Greater(t, next.DueAt, model.GetMillis())
+	assert.Equal(t, &ChecklistProgress{Checked: 0, Total: 1}, next.Checklist)
+
+	_, _, err = l.UndoLastAction(testUserID, "")
+	require.NoError(t, err)
+	lists, err := l.GetAllList(testUserID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{issue.ID}, issueIDs(lists.My), "undoing the completion removes the next occurrence")
+}
+
+func TestChangeAssignment(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	issue, err := l.AddIssue(testUserID, "write the docs", "", "", "", 0, 0)
+	require.NoError(t, err)
+
+	_, oldOwner, err := l.ChangeAssignment(issue.ID, testUserID, testOtherID, 0)
+	require.NoError(t, err)
+	assert.Empty(t, oldOwner)
+
+	lists, err := l.GetAllList(testUserID)
+	require.NoError(t, err)
+	assert.Empty(t, lists.My)
+	assert.Equal(t, []string{issue.ID}, issueIDs(lists.Out))
+
+	inList, err := l.GetIssueList(testOtherID, InListKey)
+	require.NoError(t, err)
+	require.Len(t, inList, 1)
+	receiverIssueID := inList[0].ID
+
+	_, oldOwner, err = l.ChangeAssignment(issue.ID, testUserID, testThirdID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, testOtherID, oldOwner)
+
+	inList, err = l.GetIssueList(testOtherID, InListKey)
+	require.NoError(t, err)
+	assert.Empty(t, inList)
+	_, err = l.store.GetIssue(receiverIssueID)
+	assert.Error(t, err)
+
+	inList, err = l.GetIssueList(testThirdID, InListKey)
+	require.NoError(t, err)
+	assert.Len(t, inList, 1)
+
+	_, oldOwner, err = l.ChangeAssignment(issue.ID, testUserID, testUserID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, testThirdID, oldOwner)
+
+	lists, err = l.GetAllList(testUserID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{issue.ID}, issueIDs(lists.My))
+	assert.Empty(t, lists.Out)
+}
+
+func TestEditIssueConflict(t *testing.T) {
+	l, store := setupTestListManager(t)
+
+	receiverIssueID, err := l.SendIssue(testUserID, testOtherID, "review the PR", "", "", "", 0, 0)
+	require.NoError(t, err)
+	receiverIssue, err := store.GetIssue(receiverIssueID)
+	require.NoError(t, err)
+	senderIssue, err := store.GetIssue(receiverIssue.ForeignIssueID)
+	require.NoError(t, err)
+
+	// The sender edits its copy, which also changes the receiver's copy.
+	_, _, _, err = l.EditIssue(testUserID, senderIssue.ID, "review the PR today", "", 0, 0, senderIssue.Version)
+	require.NoError(t, err)
+
+	_, _, _, err = l.EditIssue(testOtherID, receiverIssueID, "review the PR tomorrow", "", 0, 0, receiverIssue.Version)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	_, _, err = l.ChangeAssignment(senderIssue.ID, testUserID, testThirdID, senderIssue.Version)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	current, err := store.GetIssue(receiverIssueID)
+	require.NoError(t, err)
+	assert.Equal(t, "review the PR today", current.Message)
+
+	_, _, _, err = l.EditIssue(testOtherID, receiverIssueID, "review the PR tomorrow", "", 0, 0, current.Version)
+	require.NoError(t, err)
+
+	// Clients not sending a version always overwrite the todo.
+	_, _, _, err = l.EditIssue(testUserID, senderIssue.ID, "review the PR now", "", 0, 0, 0)
+	require.NoError(t, err)
+}
+
+func TestGetAllListLimitsDone(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	for i := 0; i <= doneListLimit; i++ {
+		issue, err := l.AddIssue(testUserID, "ship it", "", "", "", 0, 0)
+		require.NoError(t, err)
+		_, _, _, err = l.CompleteIssue(testUserID, issue.ID)
+		require.NoError(t, err)
+	}
+
+	lists, err := l.GetAllList(testUserID)
+	require.NoError(t, err)
+	assert.Len(t, lists.Done, doneListLimit)
+
+	done, err := l.GetIssueList(testUserID, DoneListKey)
+	require.NoError(t, err)
+	assert.Len(t, done, doneListLimit+1)
+}
+
+func TestQueryIssues(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	var ids []string
+	for i, dueAt := range []int64{300, 0, 100, 200, 100} {
+		issue, err := l.AddIssue(testUserID, "todo", "", "", "", dueAt, i%2)
+		require.NoError(t, err)
+		ids = append(ids, issue.ID)
+	}
+	receiverIssueID, err := l.SendIssue(testOtherID, testUserID, "received", "", "", "", 50, 0)
+	require.NoError(t, err)
+	_, err = l.AddIssue(testOtherID, "not mine", "", "", "", 10, 0)
+	require.NoError(t, err)
+
+	// Todos due at the same time are ordered by ID, and todos without due date come last.
+	first, second := ids[2], ids[4]
+	if second < first {
+		first, second = second, first
+	}
+	expected := []string{receiverIssueID, first, second, ids[3], ids[0], ids[1]}
+
+	query := &IssueQuery{SortBy: SortByDue, PerPage: 4}
+	page, err := l.QueryIssues(testUserID, query)
+	require.NoError(t, err)
+	require.NotEmpty(t, page.NextCursor)
+
+	query.After, err = decodeIssueCursor(page.NextCursor)
+	require.NoError(t, err)
+	nextPage, err := l.QueryIssues(testUserID, query)
+	require.NoError(t, err)
+	assert.Empty(t, nextPage.NextCursor)
+
+	var got []string
+	for _, issue := range append(page.Issues, nextPage.Issues...) {
+		got = append(got, issue.ID)
+	}
+	assert.Equal(t, expected, got)
+
+	page, err = l.QueryIssues(testUserID, &IssueQuery{SortBy: SortByDue, PerPage: 10, Statuses: []string{StatusOpen}, Priorities: []int{0}, DueAfter: 100, DueBefore: 300})
+	require.NoError(t, err)
+	got = nil
+	for _, issue := range page.Issues {
+		got = append(got, issue.ID)
+	}
+	assert.Equal(t, []string{first, second, ids[0]}, got)
+}
+
+func TestSearchIssues(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	const (
+		channelID      = "testchannelid0000000000000"
+		otherChannelID = "otherchannelid000000000000"
+	)
+	api := l.api.(*plugintest.API)
+	api.On("GetTeamsForUser", testUserID).Return([]*model.Team{{Id: "teamone"}, {Id: "teamtwo"}}, nil)
+	api.On("GetChannelsForTeamForUser", mock.AnythingOfType("string"), testUserID, false).Return([]*model.Channel{{Id: channelID}}, nil)
+	api.On("GetChannelMember", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&model.ChannelMember{}, nil)
+
+	report, err := l.AddIssue(testUserID, "Write the quarterly REPORT", "", "", "", 0, 0)
+	require.NoError(t, err)
+	described, err := l.AddIssue(testUserID, "Prepare slides", "", "for the quarterly review", "", 0, 0)
+	require.NoError(t, err)
+	commented, err := l.AddIssue(testUserID, "Call the bank", "", "", "", 0, 0)
+	require.NoError(t, err)
+	_, err = l.AddComment(commented.ID, testUserID, "about the quarterly fees")
+	require.NoError(t, err)
+	_, err = l.AddIssue(testOtherID, "Quarterly planning", "", "", "", 0, 0)
+	require.NoError(t, err)
+	_, err = l.SendIssue(testUserID, testOtherID, "Quarterly budget", "", "", "", 0, 0)
+	require.NoError(t, err)
+	sent, err := l.GetIssueList(testUserID, OutListKey)
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	channelIssue, err := l.AddChannelIssue(testOtherID, channelID, "Quarterly retro", "")
+	require.NoError(t, err)
+	_, err = l.AddChannelIssue(testUserID, otherChannelID, "Quarterly offsite", "")
+	require.NoError(t, err)
+
+	issues, err := l.SearchIssues(testUserID, "quarterly")
+	require.NoError(t, err)
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.ID)
+	}
+	assert.ElementsMatch(t, []string{report.ID, described.ID, commented.ID, sent[0].ID, channelIssue.ID}, got,
+		"only the sender's copy of a sent todo is found, and the todos of the channels of the user")
+
+	issues, err = l.SearchIssues(testUserID, "report")
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, report.ID, issues[0].ID)
+
+	_, err = l.SearchIssues(testUserID, " ")
+	assert.Error(t, err)
+}
+
+func TestPopIssue(t *testing.T) {
+	l, store := setupTestListManager(t)
+
+	first, err := l.AddIssue(testUserID, "first", "", "", "", 0, 0)
+	require.NoError(t, err)
+	second, err := l.AddIssue(testUserID, "second", "", "", "", 0, 0)
+	require.NoError(t, err)
+
+	// The most recently updated todo is at the top of the list
+	second.UpdateAt = first.UpdateAt + 1
+	require.NoError(t, store.SaveIssue(second))
+
+	popped, foreignID, err := l.PopIssue(testUserID)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, popped.ID)
+	assert.Empty(t, foreignID)
+
+	myList, err := l.GetIssueList(testUserID, MyListKey)
+	require.NoError(t, err)
+	assert.Equal(t, []string{first.ID}, issueIDs(myList))
+
+	_, _, err = l.PopIssue(testUserID)
+	require.NoError(t, err)
+
+	_, _, err = l.PopIssue(testUserID)
+	assert.EqualError(t, err, "cannot find issue")
+}
+
+func TestBumpIssue(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	receiverIssueID, err := l.SendIssue(testUserID, testOtherID, "review the PR", "", "", "", 0, 0)
+	require.NoError(t, err)
+
+	outList, err := l.GetIssueList(testUserID, OutListKey)
+	require.NoError(t, err)
+	require.Len(t, outList, 1)
+
+	todo, receiver, foreignIssueID, err := l.BumpIssue(testUserID, outList[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, receiverIssueID, todo.ID)
+	assert.Equal(t, testOtherID, receiver)
+	assert.Equal(t, receiverIssueID, foreignIssueID)
+
+	_, _, _, err = l.BumpIssue(testOtherID, receiverIssueID)
+	assert.Error(t, err)
+}
+
+// failingAuditLogStore is a MemoryStore that cannot record audit logs
+type failingAuditLogStore struct {
+	*MemoryStore
+}
+
+func (s failingAuditLogStore) Transaction(fn func(store ListStore) error) error {
+	return s.MemoryStore.Transaction(func(store ListStore) error {
+		return fn(failingAuditLogStore{store.(*MemoryStore)})
+	})
+}
+
+func (failingAuditLogStore) AddAuditLog(*AuditLog) error {
+	return errors.New("audit log unavailable")
+}
+
+func TestOperationsAreAtomic(t *testing.T) {
+	l, store := setupTestListManager(t)
+
+	issue, err := l.AddIssue(testUserID, "be awesome", "", "", "", 0, 0)
+	require.NoError(t, err)
+
+	l.store = failingAuditLogStore{store}
+
+	_, err = l.SendIssue(testUserID, testOtherID, "review the PR", "", "", "", 0, 0)
+	require.Error(t, err)
+	assert.Len(t, store.issues, 1)
+
+	_, _, _, err = l.CompleteIssue(testUserID, issue.ID)
+	require.Error(t, err)
+
+	stored, err := store.GetIssue(issue.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusOpen, stored.Status)
+}
+
+func TestIsAuthorized(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	issue, err := l.AddIssue(testUserID, "secret", "", "", "", 0, 0)
+	require.NoError(t, err)
+
+	authorized, err := l.IsAuthorized(issue.ID, testUserID)
+	require.NoError(t, err)
+	assert.True(t, authorized)
+
+	authorized, err = l.IsAuthorized(issue.ID, testOtherID)
+	require.NoError(t, err)
+	assert.False(t, authorized)
+}
+
+// countingListStore is a MemoryStore counting the lists it reads
+type countingListStore struct {
+	*MemoryStore
+	lists *int
+}
+
+func (s countingListStore) GetList(userID, listID string) ([]*IssueRef, error) {
+	*s.lists++
+	return s.MemoryStore.GetList(userID, listID)
+}
+
+func TestGetIssueListReadsForeignListsOnce(t *testing.T) {
+	l, store := setupTestListManager(t)
+
+	for _, message := range []string{"review the PR", "merge the PR", "deploy the PR"} {
+		_, err := l.SendIssue(testOtherID, testUserID, message, "", "", "", 0, 0)
+		require.NoError(t, err)
+	}
+	outList, err := l.GetIssueList(testOtherID, OutListKey)
+	require.NoError(t, err)
+	require.Len(t, outList, 3)
+
+	lists := 0
+	l.store = countingListStore{store, &lists}
+	inList, err := l.GetIssueList(testUserID, InListKey)
+	require.NoError(t, err)
+	require.Len(t, inList, 3)
+	assert.Equal(t, 2, lists, "the Out list of the sender is read once for all the todos")
+
+	for _, issue := range inList {
+		assert.Equal(t, OutFlag, issue.ForeignList)
+		position := slices.IndexFunc(outList, func(sent *ExtendedIssue) bool { return sent.ID == issue.ForeignIssueID })
+		assert.Equal(t, position, issue.ForeignPosition)
+	}
+}
+
+// racingListStore is a MemoryStore where another writer updates each issue right after it
+// is read
+type racingListStore struct {
+	*MemoryStore
+}
+
+func (s racingListStore) GetIssue(issueID string) (*Issue, error) {
+	issue, err := s.MemoryStore.GetIssue(issueID)
+	if err != nil {
+		return nil, err
+	}
+	concurrent := *issue
+	concurrent.Message = "changed in the meantime"
+	if err := s.MemoryStore.UpdateIssue(&concurrent); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+func TestChangeIssueConflict(t *testing.T) {
+	l, store := setupTestListManager(t)
+
+	issue, err := l.AddIssue(testUserID, "write the tests", "", "", "", 0, 0)
+	require.NoError(t, err)
+
+	racing := &listManager{store: racingListStore{store}, api: l.api}
+	_, err = racing.setIssueStatus(issue.ID, StatusDone)
+	assert.ErrorIs(t, err, ErrConflict)
+	_, err = racing.trashIssue(issue.ID)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	stored, err := store.GetIssue(issue.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusOpen, stored.Status, "the concurrent change is not overwritten")
+	assert.Zero(t, stored.DeletedAt)
+	assert.Equal(t, "changed in the meantime", stored.Message)
+}
@@ -0,0 +1,1103 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+// MemoryStore is a ListStore keeping everything in memory. It follows the semantics of
+// SQLStore and is meant for tests and small installations that do not need persistence.
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	issues       map[string]*Issue
+	comments     map[string]*Comment
+	checklists   map[string]*ChecklistItem
+	tags         map[string]*Tag
+	customLists  map[string]*CustomList
+	issueTags    map[memoryIssueTag]bool
+	dependencies map[memoryDependency]bool
+	watchers     map[memoryWatcher]bool
+	auditLogs    []*AuditLog
+	preferences  map[string]*memoryPreferences
+	jobStates    map[string]*JobState
+}
+
+type memoryIssueTag struct {
+	todoID string
+	tagID  string
+}
+
+type memoryDependency struct {
+	todoID    string
+	blockerID string
+}
+
+type memoryWatcher struct {
+	todoID string
+	userID string
+}
+
+type memoryPreferences struct {
+	reminderEnabled   bool
+	lastReminderAt    int64
+	allowIncomingTask bool
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		issues:       map[string]*Issue{},
+		comments:     map[string]*Comment{},
+		checklists:   map[string]*ChecklistItem{},
+		tags:         map[string]*Tag{},
+		customLists:  map[string]*CustomList{},
+		issueTags:    map[memoryIssueTag]bool{},
+		dependencies: map[memoryDependency]bool{},
+		watchers:     map[memoryWatcher]bool{},
+		preferences:  map[string]*memoryPreferences{},
+		jobStates:    map[string]*JobState{},
+	}
+}
+
+// Transaction runs fn with a copy of the store, and replaces the content of the store with
+// the copy when fn succeeds. Other operations wait for the transaction to finish.
+func (s *MemoryStore) Transaction(fn func(store ListStore) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	txStore := s.clone()
+	if err := fn(txStore); err != nil {
+		return err
+	}
+
+	s.issues = txStore.issues
+	s.comments = txStore.comments
+	s.checklists = txStore.checklists
+	s.tags = txStore.tags
+	s.customLists = txStore.customLists
+	s.issueTags = txStore.issueTags
+	s.dependencies = txStore.dependencies
+	s.watchers = txStore.watchers
+	s.auditLogs = txStore.auditLogs
+	s.preferences = txStore.preferences
+	s.jobStates = txStore.jobStates
+	return nil
+}
+
+// clone returns a deep copy of the store. The caller must hold the lock.
+func (s *MemoryStore) clone() *MemoryStore {
+	c := NewMemoryStore()
+	for id, issue := range s.issues {
+		copied := *issue
+		c.issues[id] = &copied
+	}
+	for id, comment := range s.comments {
+		copied := *comment
+		c.comments[id] = &copied
+	}
+	for id, item := range s.checklists {
+		copied := *item
+		c.checklists[id] = &copied
+	}
+	for id, tag := range s.tags {
+		copied := *tag
+		c.tags[id] = &copied
+	}
+	for issueTag := range s.issueTags {
+		c.issueTags[issueTag] = true
+	}
+	for dependency := range s.dependencies {
+		c.dependencies[dependency] = true
+	}
+	for watcher := range s.watchers {
+		c.watchers[watcher] = true
+	}
+	for id, list := range s.customLists {
+		copied := *list
+		c.customLists[id] = &copied
+	}
+	c.auditLogs = append(c.auditLogs, s.auditLogs...)
+	for userID, prefs := range s.preferences {
+		copied := *prefs
+		c.preferences[userID] = &copied
+	}
+	for name, state := range s.jobStates {
+		copied := *state
+		c.jobStates[name] = &copied
+	}
+	return c
+}
+
+func (s *MemoryStore) SaveIssue(issue *Issue) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.saveIssue(issue)
+	return nil
+}
+
+func (s *MemoryStore) UpdateIssue(issue *Issue) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.issues[issue.ID]
+	if !ok {
+		return errors.New("cannot find issue")
+	}
+	if existing.Version != issue.Version {
+		return ErrConflict
+	}
+
+	s.saveIssue(issue)
+	return nil
+}
+
+// saveIssue stores a copy of issue and increments its version. The caller must hold the lock.
+func (s *MemoryStore) saveIssue(issue *Issue) {
+	issue.Version++
+	saved := *issue
+	if existing, ok := s.issues[issue.ID]; ok {
+		// Like the SQL upsert, the creation fields of an existing issue are never updated.
+		saved.CreateAt = existing.CreateAt
+		saved.PostID = existing.PostID
+		saved.CreatorID = existing.CreatorID
+	}
+	s.issues[issue.ID] = &saved
+}
+
+func (s *MemoryStore) GetIssue(issueID string) (*Issue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	issue, ok := s.issues[issueID]
+	if !ok {
+		return nil, errors.New("cannot find issue")
+	}
+	found := *issue
+	return &found, nil
+}
+
+func (s *MemoryStore) RemoveIssue(issueID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.issues, issueID)
+	return nil
+}
+
+func (s *MemoryStore) GetAndRemoveIssue(issueID string) (*Issue, error) {
+	issue, err := s.GetIssue(issueID)
+	if err != nil {
+		return nil, err
+	}
+	return issue, s.RemoveIssue(issueID)
+}
+
+func (s *MemoryStore) AddReference(userID, issueID, listID, foreignUserID, foreignIssueID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	issue, ok := s.issues[issueID]
+	if !ok {
+		return nil
+	}
+
+	switch listID {
+	case InListKey:
+		issue.AssigneeID = userID
+		issue.Status = StatusPending
+	case OutListKey:
+		issue.CreatorID = userID
+		issue.AssigneeID = foreignUserID
+		issue.Status = StatusPending
+	default:
+		issue.AssigneeID = userID
+		issue.Status = StatusOpen
+	}
+	issue.ListID = MyListKey
+	issue.ForeignUserID = foreignUserID
+	issue.ForeignIssueID = foreignIssueID
+	issue.Version++
+	return nil
+}
+
+func (s *MemoryStore) RemoveReference(userID, issueID, listID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	issue, ok := s.issues[issueID]
+	if !ok {
+		return nil
+	}
+	if list, inList := issue.ListFor(userID); inList && list == listID {
+		issue.Status = StatusArchived
+		issue.UpdateAt = model.GetMillis()
+		issue.Version++
+	}
+	return nil
+}
+
+func (s *MemoryStore) PopReference(userID, listID string) (*IssueRef, error) {
+	refs, err := s.GetList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, errors.New("cannot find issue")
+	}
+
+	if err := s.RemoveReference(userID, refs[0].IssueID, listID); err != nil {
+		return nil, err
+	}
+	return refs[0], nil
+}
+
+func (s *MemoryStore) SetIssueRank(issueID, rank string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	issue, ok := s.issues[issueID]
+	if !ok {
+		return errors.New("cannot find issue")
+	}
+	issue.Rank = rank
+	return nil
+}
+
+func (s *MemoryStore) GetIssueReference(userID, issueID, listID string) (*IssueRef, error) {
+	list, ir := s.GetIssueListAndReference(userID, issueID)
+	if ir == nil || list != listID {
+		return nil, errors.New("cannot find issue reference")
+	}
+	return ir, nil
+}
+
+func (s *MemoryStore) GetLinkedIssues(issueID string) ([]*Issue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var issues []*Issue
+	for _, issue := range s.issues {
+		if issue.ForeignIssueID == issueID {
+			found := *issue
+			issues = append(issues, &found)
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].CreateAt != issues[j].CreateAt {
+			return issues[i].CreateAt < issues[j].CreateAt
+		}
+		return issues[i].ID < issues[j].ID
+	})
+	return issues, nil
+}
+
+func (s *MemoryStore) GetIssueListAndReference(userID, issueID string) (string, *IssueRef) {
+	issue, err := s.GetIssue(issueID)
+	if err != nil {
+		return "", nil
+	}
+
+	list, ok := issue.ListFor(userID)
+	if !ok {
+		return "", nil
+	}
+	return list, issue.Reference()
+}
+
+func (s *MemoryStore) GetList(userID, listID string) ([]*IssueRef, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var issues []*Issue
+	for _, issue := range s.issues {
+		if list, ok := issue.ListFor(userID); ok && list == listID {
+			issues = append(issues, issue)
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].sortsBefore(issues[j], listID)
+	})
+
+	refs := make([]*IssueRef, 0, len(issues))
+	for _, issue := range issues {
+		refs = append(refs, issue.Reference())
+	}
+	return refs, nil
+}
+
+func (s *MemoryStore) GetListHead(userID, listID string, limit int) ([]*IssueRef, error) {
+	refs, err := s.GetList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) > limit {
+		refs = refs[:limit]
+	}
+	return refs, nil
+}
+
+func (s *MemoryStore) QueryIssues(userID string, query *IssueQuery, limit int) ([]*Issue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var issues []*Issue
+	for _, issue := range s.issues {
+		if issue.OwnerID() != userID || !query.matches(issue) {
+			continue
+		}
+		if query.Tag != "" && !s.hasTag(issue.ID, userID, query.Tag) {
+			continue
+		}
+		if query.After != nil && !query.isAfter(issue, query.After) {
+			continue
+		}
+		found := *issue
+		issues = append(issues, &found)
+	}
+
+	query.sortIssues(issues)
+	if len(issues) > limit {
+		issues = issues[:limit]
+	}
+	return issues, nil
+}
+
+func (s *MemoryStore) SearchIssues(userID string, channelIDs []string, terms string, limit int) ([]*Issue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	terms = strings.ToLower(terms)
+	matches := func(text string) bool {
+		return strings.Contains(strings.ToLower(text), terms)
+	}
+
+	commented := map[string]bool{}
+	for _, comment := range s.comments {
+		if matches(comment.Message) {
+			commented[comment.TodoID] = true
+		}
+	}
+
+	var issues []*Issue
+	for _, issue := range s.issues {
+		if issue.DeletedAt != 0 {
+			continue
+		}
+		if userID != "" {
+			inScope := issue.OwnerID() == userID
+			if issue.ChannelID != "" {
+				inScope = slices.Contains(channelIDs, issue.ChannelID)
+			}
+			if !inScope {
+				continue
+			}
+		}
+		if !matches(issue.Message) && !matches(issue.Description) && !commented[issue.ID] {
+			continue
+		}
+		found := *issue
+		issues = append(issues, &found)
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].UpdateAt != issues[j].UpdateAt {
+			return issues[i].UpdateAt > issues[j].UpdateAt
+		}
+		return issues[i].ID < issues[j].ID
+	})
+	if len(issues) > limit {
+		issues = issues[:limit]
+	}
+	return issues, nil
+}
+
+func (s *MemoryStore) GetTrash(userID string, limit int) ([]*Issue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var issues []*Issue
+	for _, issue := range s.issues {
+		if issue.DeletedAt == 0 || issue.OwnerID() != userID {
+			continue
+		}
+		found := *issue
+		issues = append(issues, &found)
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].DeletedAt != issues[j].DeletedAt {
+			return issues[i].DeletedAt > issues[j].DeletedAt
+		}
+		return issues[i].ID < issues[j].ID
+	})
+	if len(issues) > limit {
+		issues = issues[:limit]
+	}
+	return issues, nil
+}
+
+func (s *MemoryStore) GetChannelIssues(channelID, status string, limit int) ([]*Issue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var issues []*Issue
+	for _, issue := range s.issues {
+		if issue.ChannelID != channelID || issue.Status != status || issue.DeletedAt != 0 {
+			continue
+		}
+		found := *issue
+		issues = append(issues, &found)
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		a, b := issues[i].CreateAt, issues[j].CreateAt
+		if status == StatusDone {
+			a, b = issues[i].CompletedAt, issues[j].CompletedAt
+		}
+		if a != b {
+			return a > b
+		}
+		return issues[i].ID < issues[j].ID
+	})
+	if len(issues) > limit {
+		issues = issues[:limit]
+	}
+	return issues, nil
+}
+
+// isAssigned returns true if issue is an open or pending todo assigned to one of userIDs, see
+// assignedCondition
+func isAssigned(issue *Issue, userIDs []string) bool {
+	return slices.Contains(userIDs, issue.AssigneeID) && (issue.Status == StatusPending || isActiveStatus(issue.Status)) &&
+		issue.DeletedAt == 0 && !issue.IsSenderCopy()
+}
+
+func (s *MemoryStore) GetAssignedIssues(userIDs []string, limit int) ([]*Issue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var issues []*Issue
+	for _, issue := range s.issues {
+		if isAssigned(issue, userIDs) {
+			found := *issue
+			issues = append(issues, &found)
+		}
+	}
+
+	byDue := &IssueQuery{SortBy: SortByDue}
+	sort.Slice(issues, func(i, j int) bool {
+		a, b := byDue.sortValue(issues[i]), byDue.sortValue(issues[j])
+		if a != b {
+			return a < b
+		}
+		return issues[i].ID < issues[j].ID
+	})
+	if len(issues) > limit {
+		issues = issues[:limit]
+	}
+	return issues, nil
+}
+
+func (s *MemoryStore) CountAssignedIssues(userIDs []string, now int64) (map[string]*AssigneeCounts, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := map[string]*AssigneeCounts{}
+	for _, issue := range s.issues {
+		if !isAssigned(issue, userIDs) {
+			continue
+		}
+		c, ok := counts[issue.AssigneeID]
+		if !ok {
+			c = &AssigneeCounts{UserID: issue.AssigneeID}
+			counts[issue.AssigneeID] = c
+		}
+		switch {
+		case issue.Status == StatusPending:
+			c.Pending++
+		case issue.DueAt > 0 && issue.DueAt < now:
+			c.Open++
+			c.Overdue++
+		default:
+			c.Open++
+		}
+	}
+	return counts, nil
+}
+
+func (s *MemoryStore) PurgeTrash(deletedBefore int64, purgeAuditLogs bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := map[string]bool{}
+	for id, issue := range s.issues {
+		if issue.DeletedAt != 0 && issue.DeletedAt < deletedBefore {
+			purged[id] = true
+			delete(s.issues, id)
+		}
+	}
+
+	for id, comment := range s.comments {
+		if purged[comment.TodoID] {
+			delete(s.comments, id)
+		}
+	}
+	for id, item := range s.checklists {
+		if purged[item.TodoID] {
+			delete(s.checklists, id)
+		}
+	}
+	for issueTag := range s.issueTags {
+		if purged[issueTag.todoID] {
+			delete(s.issueTags, issueTag)
+		}
+	}
+	for dependency := range s.dependencies {
+		if purged[dependency.todoID] || purged[dependency.blockerID] {
+			delete(s.dependencies, dependency)
+		}
+	}
+	for watcher := range s.watchers {
+		if purged[watcher.todoID] {
+			delete(s.watchers, watcher)
+		}
+	}
+
+	if purgeAuditLogs {
+		auditLogs := s.auditLogs[:0:0]
+		for _, log := range s.auditLogs {
+			if !purged[log.TodoID] {
+				auditLogs = append(auditLogs, log)
+			}
+		}
+		s.auditLogs = auditLogs
+	}
+
+	return len(purged), nil
+}
+
+func (s *MemoryStore) GetTag(userID, name string) (*Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, tag := range s.tags {
+		if tag.UserID == userID && tag.Name == name {
+			found := *tag
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) SaveTag(tag *Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.tags {
+		if existing.UserID == tag.UserID && existing.Name == tag.Name {
+			return errors.Errorf("tag %q already exists", tag.Name)
+		}
+	}
+	if tag.ID == "" {
+		tag.ID = model.NewId()
+	}
+	if tag.CreatedAt == 0 {
+		tag.CreatedAt = model.GetMillis()
+	}
+	saved := *tag
+	s.tags[tag.ID] = &saved
+	return nil
+}
+
+func (s *MemoryStore) GetTags(userID string) ([]*Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tags []*Tag
+	for _, tag := range s.tags {
+		if tag.UserID == userID {
+			found := *tag
+			tags = append(tags, &found)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+func (s *MemoryStore) AddIssueTag(todoID, tagID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.issueTags[memoryIssueTag{todoID: todoID, tagID: tagID}] = true
+	return nil
+}
+
+func (s *MemoryStore) RemoveIssueTag(todoID, tagID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.issueTags, memoryIssueTag{todoID: todoID, tagID: tagID})
+	return nil
+}
+
+func (s *MemoryStore) GetIssueTags(todoIDs []string) (map[string][]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := map[string]bool{}
+	for _, todoID := range todoIDs {
+		wanted[todoID] = true
+	}
+
+	tags := map[string][]string{}
+	for issueTag := range s.issueTags {
+		if tag, ok := s.tags[issueTag.tagID]; ok && wanted[issueTag.todoID] {
+			tags[issueTag.todoID] = append(tags[issueTag.todoID], tag.Name)
+		}
+	}
+	for _, names := range tags {
+		sort.Strings(names)
+	}
+	return tags, nil
+}
+
+// hasTag returns true if todoID has the tag of userID named name. The caller must hold the lock.
+func (s *MemoryStore) hasTag(todoID, userID, name string) bool {
+	for issueTag := range s.issueTags {
+		if tag, ok := s.tags[issueTag.tagID]; ok && issueTag.todoID == todoID && tag.UserID == userID && tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) AddDependency(todoID, blockerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dependencies[memoryDependency{todoID: todoID, blockerID: blockerID}] = true
+	return nil
+}
+
+func (s *MemoryStore) RemoveDependency(todoID, blockerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.dependencies, memoryDependency{todoID: todoID, blockerID: blockerID})
+	return nil
+}
+
+func (s *MemoryStore) GetBlockers(todoIDs []string) (map[string][]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := map[string]bool{}
+	for _, todoID := range todoIDs {
+		wanted[todoID] = true
+	}
+
+	blockers := map[string][]string{}
+	for dependency := range s.dependencies {
+		if wanted[dependency.todoID] {
+			blockers[dependency.todoID] = append(blockers[dependency.todoID], dependency.blockerID)
+		}
+	}
+	for _, ids := range blockers {
+		sort.Strings(ids)
+	}
+	return blockers, nil
+}
+
+func (s *MemoryStore) GetBlockedIssues(blockerIDs []string) (map[string][]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := map[string]bool{}
+	for _, blockerID := range blockerIDs {
+		wanted[blockerID] = true
+	}
+
+	blocked := map[string][]string{}
+	for dependency := range s.dependencies {
+		if wanted[dependency.blockerID] {
+			blocked[dependency.blockerID] = append(blocked[dependency.blockerID], dependency.todoID)
+		}
+	}
+	for _, ids := range blocked {
+		sort.Strings(ids)
+	}
+	return blocked, nil
+}
+
+func (s *MemoryStore) AddWatcher(todoID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watchers[memoryWatcher{todoID: todoID, userID: userID}] = true
+	return nil
+}
+
+func (s *MemoryStore) RemoveWatcher(todoID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.watchers, memoryWatcher{todoID: todoID, userID: userID})
+	return nil
+}
+
+func (s *MemoryStore) GetWatchers(todoID string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var userIDs []string
+	for watcher := range s.watchers {
+		if watcher.todoID == todoID {
+			userIDs = append(userIDs, watcher.userID)
+		}
+	}
+	sort.Strings(userIDs)
+	return userIDs, nil
+}
+
+func (s *MemoryStore) SaveCustomList(list *CustomList) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if list.ID == "" {
+		list.ID = model.NewId()
+	}
+	saved := *list
+	s.customLists[list.ID] = &saved
+	return nil
+}
+
+func (s *MemoryStore) GetCustomList(listID string) (*CustomList, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list, ok := s.customLists[listID]
+	if !ok {
+		return nil, nil
+	}
+	found := *list
+	return &found, nil
+}
+
+func (s *MemoryStore) GetCustomLists(userID string) ([]*CustomList, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var lists []*CustomList
+	for _, list := range s.customLists {
+		if list.UserID == userID {
+			found := *list
+			lists = append(lists, &found)
+		}
+	}
+	sortCustomLists(lists)
+	return lists, nil
+}
+
+func (s *MemoryStore) DeleteCustomList(listID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.customLists, listID)
+	for _, issue := range s.issues {
+		if issue.ListID == listID {
+			issue.ListID = MyListKey
+			issue.Version++
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) getPreferences(userID string) *memoryPreferences {
+	prefs, ok := s.preferences[userID]
+	if !ok {
+		prefs = &memoryPreferences{
+			reminderEnabled:   true,
+			allowIncomingTask: true,
+		}
+		s.preferences[userID] = prefs
+	}
+	return prefs
+}
+
+func (s *MemoryStore) SetReminderPreference(userID string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.getPreferences(userID).reminderEnabled = enabled
+	return nil
+}
+
+func (s *MemoryStore) GetReminderPreference(userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.getPreferences(userID).reminderEnabled
+}
+
+func (s *MemoryStore) SetLastReminderTime(userID string, time int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.getPreferences(userID).lastReminderAt = time
+	return nil
+}
+
+func (s *MemoryStore) GetLastReminderTime(userID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.getPreferences(userID).lastReminderAt, nil
+}
+
+func (s *MemoryStore) SetAllowIncomingTaskPreference(userID string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.getPreferences(userID).allowIncomingTask = enabled
+	return nil
+}
+
+func (s *MemoryStore) GetAllowIncomingTaskPreference(userID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.getPreferences(userID).allowIncomingTask, nil
+}
+
+func (s *MemoryStore) GetUsersToRemind() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := map[string]bool{}
+	var userIDs []string
+	for _, issue := range s.issues {
+		if !isActiveStatus(issue.Status) || issue.DeletedAt != 0 || issue.AssigneeID == "" || seen[issue.AssigneeID] {
+			continue
+		}
+		seen[issue.AssigneeID] = true
+		if s.getPreferences(issue.AssigneeID).reminderEnabled {
+			userIDs = append(userIDs, issue.AssigneeID)
+		}
+	}
+	sort.Strings(userIDs)
+	return userIDs, nil
+}
+
+func (s *MemoryStore) SaveComment(comment *Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if comment.ID == "" {
+		comment.ID = model.NewId()
+	}
+	if comment.CreatedAt == 0 {
+		comment.CreatedAt = model.GetMillis()
+	}
+	saved := *comment
+	s.comments[comment.ID] = &saved
+	return nil
+}
+
+func (s *MemoryStore) GetComments(todoID string) ([]*Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var comments []*Comment
+	for _, c := range s.comments {
+		if c.TodoID == todoID {
+			found := *c
+			comments = append(comments, &found)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].CreatedAt < comments[j].CreatedAt
+	})
+	return comments, nil
+}
+
+func (s *MemoryStore) GetComment(commentID string) (*Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.comments[commentID]
+	if !ok {
+		return nil, errors.New("cannot find comment")
+	}
+	found := *c
+	return &found, nil
+}
+
+func (s *MemoryStore) DeleteComment(commentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.comments, commentID)
+	return nil
+}
+
+func (s *MemoryStore) SaveChecklistItem(item *ChecklistItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if item.ID == "" {
+		item.ID = model.NewId()
+	}
+	if item.CreatedAt == 0 {
+		item.CreatedAt = model.GetMillis()
+	}
+	saved := *item
+	s.checklists[item.ID] = &saved
+	return nil
+}
+
+func (s *MemoryStore) GetChecklistItem(itemID string) (*ChecklistItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, ok := s.checklists[itemID]
+	if !ok {
+		return nil, errors.New("cannot find checklist item")
+	}
+	found := *item
+	return &found, nil
+}
+
+func (s *MemoryStore) GetChecklist(todoID string) ([]*ChecklistItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []*ChecklistItem
+	for _, item := range s.checklists {
+		if item.TodoID == todoID {
+			found := *item
+			items = append(items, &found)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].SortOrder != items[j].SortOrder {
+			return items[i].SortOrder < items[j].SortOrder
+		}
+		return items[i].CreatedAt < items[j].CreatedAt
+	})
+	return items, nil
+}
+
+func (s *MemoryStore) DeleteChecklistItem(itemID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.checklists, itemID)
+	return nil
+}
+
+func (s *MemoryStore) GetChecklistProgress(todoIDs []string) (map[string]*ChecklistProgress, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := map[string]bool{}
+	for _, todoID := range todoIDs {
+		wanted[todoID] = true
+	}
+
+	progress := map[string]*ChecklistProgress{}
+	for _, item := range s.checklists {
+		if !wanted[item.TodoID] {
+			continue
+		}
+		p, ok := progress[item.TodoID]
+		if !ok {
+			p = &ChecklistProgress{}
+			progress[item.TodoID] = p
+		}
+		p.Total++
+		if item.Checked {
+			p.Checked++
+		}
+	}
+	return progress, nil
+}
+
+func (s *MemoryStore) AddAuditLog(log *AuditLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if log.ID == "" {
+		log.ID = model.NewId()
+	}
+	if log.CreatedAt == 0 {
+		log.CreatedAt = model.GetMillis()
+	}
+	saved := *log
+	s.auditLogs = append(s.auditLogs, &saved)
+	return nil
+}
+
+func (s *MemoryStore) GetAuditLogs(todoID string) ([]*AuditLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var logs []*AuditLog
+	// Walk backwards to return the most recent logs first
+	for i := len(s.auditLogs) - 1; i >= 0; i-- {
+		if s.auditLogs[i].TodoID == todoID {
+			found := *s.auditLogs[i]
+			logs = append(logs, &found)
+		}
+	}
+	return logs, nil
+}
+
+func (s *MemoryStore) GetLastAuditLog(userID string, since int64) (*AuditLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// The audit logs are kept in the order they were recorded.
+	for i := len(s.auditLogs) - 1; i >= 0; i-- {
+		log := s.auditLogs[i]
+		if log.UserID != userID || slices.Contains(passiveAuditLogActions, log.Action) {
+			continue
+		}
+		if log.CreatedAt < since {
+			return nil, nil
+		}
+		found := *log
+		return &found, nil
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) GetJobState(name string) (*JobState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.jobStates[name]
+	if !ok {
+		return nil, nil
+	}
+	found := *state
+	return &found, nil
+}
+
+func (s *MemoryStore) SaveJobState(state *JobState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := *state
+	s.jobStates[state.Name] = &saved
+	return nil
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"database/sql"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+	"github.com/pkg/errors"
+)
+
+// migration is a versioned schema change. Each migration is applied once, in its own
+// transaction, and recorded in the todo_schema_migrations table. Migrations must never be
+// edited once released: add a new one instead.
+type migration struct {
+	Version int
+	Name    string
+	// Statements returns the statements to run for the dialect, in order
+	Statements func(d sqlDialect) []string
+}
+
+// The first migrations use IF NOT EXISTS, as their tables were created by the unversioned
+// migration runner on existing installations. MySQL does not support IF NOT EXISTS on
+// indexes, so those were never created there.
+var migrations = []migration{
+	{
+		Version: 1,
+		Name:    "create_todos",
+		Statements: func(sqlDialect) []string {
+			return []string{`
+				CREATE TABLE IF NOT EXISTS todos (
+					id VARCHAR(26) PRIMARY KEY,
+					message TEXT,
+					description TEXT,
+					creator_id VARCHAR(26),
+					assignee_id VARCHAR(26),
+					post_id VARCHAR(26),
+					priority INTEGER DEFAULT 0,
+					due_at BIGINT DEFAULT 0,
+					status VARCHAR(20) DEFAULT 'open',
+					created_at BIGINT,
+					updated_at BIGINT,
+					post_permalink TEXT,
+					foreign_issue_id VARCHAR(26),
+					foreign_user_id VARCHAR(26)
+				)`,
+			}
+		},
+	},
+	{
+		Version: 2,
+		Name:    "create_comments",
+		Statements: func(sqlDialect) []string {
+			return []string{`
+				CREATE TABLE IF NOT EXISTS todo_comments (
+					id VARCHAR(26) PRIMARY KEY,
+					todo_id VARCHAR(26),
+					user_id VARCHAR(26),
+					message TEXT,
+					created_at BIGINT
+				)`,
+			}
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create_audit_log",
+		Statements: func(sqlDialect) []string {
+			return []string{`
+				CREATE TABLE IF NOT EXISTS todo_audit_log (
+					id VARCHAR(26) PRIMARY KEY,
+					todo_id VARCHAR(26),
+					user_id VARCHAR(26),
+					action VARCHAR(50),
+					metadata TEXT,
+					created_at BIGINT
+				)`,
+			}
+		},
+	},
+	{
+		Version: 4,
+		Name:    "create_preferences",
+		Statements: func(sqlDialect) []string {
+			return []string{`
+				CREATE TABLE IF NOT EXISTS todo_preferences (
+					user_id VARCHAR(26) PRIMARY KEY,
+					reminder_enabled BOOLEAN DEFAULT TRUE,
+					last_reminder_at BIGINT DEFAULT 0,
+					allow_incoming_task BOOLEAN DEFAULT TRUE
+				)`,
+			}
+		},
+	},
+	{
+		Version: 5,
+		Name:    "create_jobs",
+		Statements: func(sqlDialect) []string {
+			return []string{`
+				CREATE TABLE IF NOT EXISTS todo_jobs (
+					name VARCHAR(64) PRIMARY KEY,
+					last_started_at BIGINT DEFAULT 0,
+					last_finished_at BIGINT DEFAULT 0,
+					last_error TEXT
+				)`,
+			}
+		},
+	},
+	{
+		Version: 6,
+		Name:    "create_indexes",
+		Statements: func(d sqlDialect) []string {
+			return []string{
+				d.CreateIndex("idx_todos_creator", "todos", []string{"creator_id"}, ""),
+				d.CreateIndex("idx_todos_assignee", "todos", []string{"assignee_id", "status"}, ""),
+				d.CreateIndex("idx_todo_comments_todo_id", "todo_comments", []string{"todo_id", "created_at"}, ""),
+				d.CreateIndex("idx_todo_audit_log_todo_id", "todo_audit_log", []string{"todo_id", "created_at"}, ""),
+				d.CreateIndex("idx_todos_due_at", "todos", []string{"due_at"}, "due_at > 0"),
+				d.CreateIndex("idx_todos_status", "todos", []string{"status"}, ""),
+			}
+		},
+	},
+	{
+		Version: 7,
+		Name:    "add_todo_version",
+		Statements: func(sqlDialect) []string {
+			return []string{
+				"ALTER TABLE todos ADD COLUMN version BIGINT NOT NULL DEFAULT 0",
+			}
+		},
+	},
+	{
+		Version: 8,
+		Name:    "create_text_indexes",
+		Statements: func(d sqlDialect) []string {
+			return []string{
+				d.CreateTextIndex("idx_todos_text", "todos", todoTextColumns),
+				d.CreateTextIndex("idx_todo_comments_text", "todo_comments", commentTextColumns),
+			}
+		},
+	},
+	{
+		Version: 9,
+		Name:    "add_done_status",
+		Statements: func(sqlDialect) []string {
+			return []string{
+				"ALTER TABLE todos ADD COLUMN completed_at BIGINT NOT NULL DEFAULT 0",
+				"UPDATE todos SET status = 'done', completed_at = updated_at WHERE status = 'completed'",
+			}
+		},
+	},
+	{
+		Version: 10,
+		Name:    "add_trash",
+		Statements: func(d sqlDialect) []string {
+			return []string{
+				"ALTER TABLE todos ADD COLUMN deleted_at BIGINT NOT NULL DEFAULT 0",
+				// Removed todos did not keep their previous status, they are restored to the My list.
+				"UPDATE todos SET deleted_at = updated_at, status = 'open' WHERE status = 'removed'",
+				d.CreateIndex("idx_todos_deleted_at", "todos", []string{"deleted_at"}, "deleted_at > 0"),
+			}
+		},
+	},
+	{
+		Version: 11,
+		Name:    "add_audit_log_snapshot",
+		Statements: func(d sqlDialect) []string {
+			return []string{
+				"ALTER TABLE todo_audit_log ADD COLUMN snapshot TEXT",
+				d.CreateIndex("idx_todo_audit_log_user_id", "todo_audit_log", []string{"user_id", "created_at"}, ""),
+			}
+		},
+	},
+	{
+		Version: 12,
+		Name:    "create_checklists",
+		Statements: func(d sqlDialect) []string {
+			return []string{
+				"ALTER TABLE todos ADD COLUMN auto_complete BOOLEAN NOT NULL DEFAULT FALSE",
+				`
+				CREATE TABLE IF NOT EXISTS todo_checklist_items (
+					id VARCHAR(26) PRIMARY KEY,
+					todo_id VARCHAR(26) NOT NULL,
+					message TEXT,
+					checked BOOLEAN NOT NULL DEFAULT FALSE,
+					sort_order INT NOT NULL DEFAULT 0,
+					created_at BIGINT,
+					checked_at BIGINT NOT NULL DEFAULT 0
+				)`,
+				d.CreateIndex("idx_todo_checklist_items_todo_id", "todo_checklist_items", []string{"todo_id", "sort_order"}, ""),
+			}
+		},
+	},
+	{
+		Version: 13,
+		Name:    "add_recurrence",
+		Statements: func(d sqlDialect) []string {
+			return []string{
+				"ALTER TABLE todos ADD COLUMN recurrence VARCHAR(255) NOT NULL DEFAULT ''",
+			}
+		},
+	},
+	{
+		Version: 14,
+		Name:    "create_tags",
+		Statements: func(d sqlDialect) []string {
+			return []string{
+				`
+				CREATE TABLE IF NOT EXISTS todo_tags (
+					id VARCHAR(26) PRIMARY KEY,
+					user_id VARCHAR(26) NOT NULL,
+					name VARCHAR(64) NOT NULL,
+					created_at BIGINT,
+					UNIQUE (user_id, name)
+				)`,
+				`
+				CREATE TABLE IF NOT EXISTS todo_issue_tags (
+					todo_id VARCHAR(26) NOT NULL,
+					tag_id VARCHAR(26) NOT NULL,
+					PRIMARY KEY (todo_id, tag_id)
+				)`,
+				d.CreateIndex("idx_todo_issue_tags_tag_id", "todo_issue_tags", []string{"tag_id"}, ""),
+			}
+		},
+	},
+	{
+		Version: 15,
+		Name:    "create_custom_lists",
+		Statements: func(d sqlDialect) []string {
+			return []string{
+				`
+				CREATE TABLE IF NOT EXISTS todo_lists (
+					id VARCHAR(26) PRIMARY KEY,
+					user_id VARCHAR(26) NOT NULL,
+					name VARCHAR(64) NOT NULL,
+					sort_order INT NOT NULL DEFAULT 0,
+					created_at BIGINT,
+					updated_at BIGINT
+				)`,
+				d.CreateIndex("idx_todo_lists_user_id", "todo_lists", []string{"user_id", "sort_order"}, ""),
+				"ALTER TABLE todos ADD COLUMN list_id VARCHAR(26) NOT NULL DEFAULT ''",
+			}
+		},
+	},
+	{
+		Version: 16,
+		Name:    "add_sort_rank",
+		Statements: func(d sqlDialect) []string {
+			// The existing todos get their rank the first time a todo is moved in their list, and
+			// keep their order until then. RANK is a reserved word in MySQL.
+			return []string{
+				"ALTER TABLE todos ADD COLUMN sort_rank VARCHAR(64) NOT NULL DEFAULT ''",
+			}
+		},
+	},
+	{
+		Version: 17,
+		Name:    "create_dependencies",
+		Statements: func(d sqlDialect) []string {
+			return []string{
+				`
+				CREATE TABLE IF NOT EXISTS todo_dependencies (
+					todo_id VARCHAR(26) NOT NULL,
+					blocked_by_id VARCHAR(26) NOT NULL,
+					created_at BIGINT,
+					PRIMARY KEY (todo_id, blocked_by_id)
+				)`,
+				d.CreateIndex("idx_todo_dependencies_blocked_by_id", "todo_dependencies", []string{"blocked_by_id"}, ""),
+			}
+		},
+	},
+	{
+		Version: 18,
+		Name:    "add_completion",
+		Statements: func(d sqlDialect) []string {
+			// The copies of the assignees of a shared todo are found from the sender's copy
+			// through their foreign issue.
+			return []string{
+				"ALTER TABLE todos ADD COLUMN completion VARCHAR(8) NOT NULL DEFAULT ''",
+				d.CreateIndex("idx_todos_foreign_issue_id", "todos", []string{"foreign_issue_id"}, ""),
+			}
+		},
+	},
+	{
+		Version: 19,
+		Name:    "add_channel_id",
+		Statements: func(d sqlDialect) []string {
+			return []string{
+				"ALTER TABLE todos ADD COLUMN channel_id VARCHAR(26) NOT NULL DEFAULT ''",
+				d.CreateIndex("idx_todos_channel_id", "todos", []string{"channel_id", "status"}, ""),
+			}
+		},
+	},
+	{
+		Version: 20,
+		Name:    "create_watchers",
+		Statements: func(d sqlDialect) []string {
+			return []string{
+				`
+				CREATE TABLE IF NOT EXISTS todo_watchers (
+					todo_id VARCHAR(26) NOT NULL,
+					user_id VARCHAR(26) NOT NULL,
+					created_at BIGINT,
+					PRIMARY KEY (todo_id, user_id)
+				)`,
+			}
+		},
+	},
+}
+
+// RunMigrations applies the pending migrations in order. It holds a cluster mutex, so only
+// one node migrates the schema when several are activating the plugin at the same time.
+func (s *SQLStore) RunMigrations() error {
+	mutex, err := cluster.NewMutex(s.api, "schema_migrations")
+	if err != nil {
+		return errors.Wrap(err, "failed to create migrations mutex")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, err = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS todo_schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			applied_at BIGINT NOT NULL
+		)`); err != nil {
+		return errors.Wrap(err, "failed to create the migrations table")
+	}
+
+	applied, err := s.getAppliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+
+		if err := s.applyMigration(m); err != nil {
+			s.api.LogError("Schema migration failed", "version", m.Version, "name", m.Name, "err", err.Error())
+			return errors.Wrapf(err, "migration %d (%s) failed", m.Version, m.Name)
+		}
+		s.api.LogInfo("Applied schema migration", "version", m.Version, "name", m.Name)
+	}
+
+	return nil
+}
+
+func (s *SQLStore) getAppliedMigrations() (map[int]bool, error) {
+	rows, err := s.db.Query("SELECT version FROM todo_schema_migrations")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the applied migrations")
+	}
+	defer rows.Close()
+
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// applyMigration runs the statements of m and records it in a single transaction. Note that
+// MySQL implicitly commits DDL statements, so a failed migration may be partially applied
+// there: the columns and indexes it added already are skipped when it is retried.
+func (s *SQLStore) applyMigration(m migration) (err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil && rollbackErr != sql.ErrTxDone {
+				s.api.LogError("Unable to rollback migration", "version", m.Version, "err", rollbackErr.Error())
+			}
+		}
+	}()
+
+	for _, statement := range m.Statements(s.dialect) {
+		var applied bool
+		applied, err = s.isApplied(tx, statement)
+		if err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+		if _, err = tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.Exec(s.replacePlaceholders("INSERT INTO todo_schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+		m.Version, m.Name, model.GetMillis()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// isApplied returns true if the column or index created by the migration statement exists
+// already, see sqlDialect.AppliedQuery
+func (s *SQLStore) isApplied(tx *sql.Tx, statement string) (bool, error) {
+	query, args := s.dialect.AppliedQuery(statement)
+	if query == "" {
+		return false, nil
+	}
+
+	var count int
+	if err := tx.QueryRow(s.replacePlaceholders(query), args...).Scan(&count); err != nil {
+		return false, errors.Wrap(err, "failed to check whether the migration statement was applied")
+	}
+	return count > 0, nil
+}
@@ -5,7 +5,8 @@ import (
 	"fmt"
 	"net/http"
 	"runtime/debug"
-	"strconv"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,6 +22,10 @@ const (
 	// WSEventRefresh is the WebSocket event for refreshing the Todo list
 	WSEventRefresh = "refresh"
 
+	// WSEventChannelRefresh is the WebSocket event for refreshing the Todo list of a channel,
+	// broadcast to the members of the channel
+	WSEventChannelRefresh = "refresh_channel"
+
 	// WSEventConfigUpdate is the WebSocket event to update the Todo list's configurations on webapp
 	WSEventConfigUpdate = "config_update"
 
@@ -33,32 +38,108 @@ type ListManager interface {
 	AddIssue(userID, message, postPermalink, description, postID string, dueAt int64, priority int) (*Issue, error)
 	// SendIssue sends the todo with the message from senderID to receiverID and returns the receiver's issueID
 	SendIssue(senderID, receiverID, message, postPermalink, description, postID string, dueAt int64, priority int) (string, error)
+	// SendSharedIssue sends the todo from senderID to several receivers, and returns the issueID of each receiver's copy
+	SendSharedIssue(senderID string, receiverIDs []string, completion, message, postPermalink, description, postID string, dueAt int64, priority int) (map[string]string, error)
+	// GetAssignees returns the progress of each assignee of a shared todo, or nil if the todo is not shared
+	GetAssignees(issueID string) ([]*Assignee, error)
+	// AddChannelIssue adds a todo to the list of a channel userID is a member of
+	AddChannelIssue(userID, channelID, message, description string) (*Issue, error)
+	// GetChannelIssues gets the open and done todos of the list of a channel userID is a member of
+	GetChannelIssues(userID, channelID string) (*ChannelIssues, error)
+	// CompleteChannelIssue completes a todo of the list of a channel userID is a member of
+	CompleteChannelIssue(userID, issueID string) (*Issue, error)
+	// GetTeamBoard gets the outstanding todos of the members of a team userID can manage, with their counts by assignee
+	GetTeamBoard(userID, teamID string) (*TeamBoard, error)
 	// GetIssueList gets the todos on listID for userID
 	GetIssueList(userID, listID string) ([]*ExtendedIssue, error)
 	// GetAllList get all issues
 	GetAllList(userID string) (*ListsIssue, error)
+	// QueryIssues gets a page of the todos of userID matching query
+	QueryIssues(userID string, query *IssueQuery) (*IssuePage, error)
+	// SearchIssues gets the todos userID is authorized on whose text or comments contain terms
+	SearchIssues(userID, terms string) ([]*Issue, error)
 	// CompleteIssue completes the todo issueID for userID, and returns the issue and the foreign ID if any
 	CompleteIssue(userID, issueID string) (issue *Issue, foreignID string, listToUpdate string, err error)
 	// AcceptIssue moves one the todo issueID of userID from inbox to myList, and returns the message and the foreignUserID if any
 	AcceptIssue(userID, issueID string) (todoMessage string, foreignUserID string, err error)
-	// RemoveIssue removes the todo issueID for userID and returns the issue, the foreign ID if any and whether the user sent the todo to someone else
+	// DeclineIssue sends the todo issueID received by userID back to its sender, declined for the optional reason
+	DeclineIssue(userID, issueID, reason string) (issue *Issue, senderID string, err error)
+	// RemoveIssue moves the todo issueID of userID to the trash and returns the issue, the foreign ID if any and whether the user sent the todo to someone else
 	RemoveIssue(userID, issueID string) (issue *Issue, foreignID string, isSender bool, listToUpdate string, err error)
+	// GetTrash gets the todos of userID in the trash, most recently removed first
+	GetTrash(userID string) ([]*Issue, error)
+	// RestoreIssue moves the todo issueID of userID out of the trash, and returns the issue, the foreign ID and list if the other party's copy was restored too
+	RestoreIssue(userID, issueID string) (issue *Issue, foreignID string, foreignList string, listToUpdate string, err error)
 	// PopIssue the first element of myList for userID and returns the issue and the foreign ID if any
 	PopIssue(userID string) (issue *Issue, foreignID string, err error)
 	// BumpIssue moves a issueID sent by userID to the top of its receiver inbox list
 	BumpIssue(userID string, issueID string) (todo *Issue, receiver string, foreignIssueID string, err error)
+	// UndoLastAction reverts the last action of userID if it is a complete, accept, remove, pop, bump or move made within the undo window, and logID when it is not empty. It returns its audit log and the reverted todos
+	UndoLastAction(userID, logID string) (log *AuditLog, issues []*Issue, err error)
+	// GetUndoableAction returns the ID of the audit log of the last action of userID if it can still be undone, or an empty string
+	GetUndoableAction(userID string) (string, error)
 	// Comments
 	AddComment(todoID, userID, message string) (*Comment, error)
 	GetIssueComments(todoID string) ([]*ExtendedComment, error)
 	DeleteComment(commentID, userID string) error
-	// EditIssue updates the message on an issue
-	EditIssue(userID string, issueID string, newMessage string, newDescription string, newDueAt int64, newPriority int) (foreignUserID string, list string, oldMessage string, err error)
-	// ChangeAssignment updates an issue to assign a different person
-	ChangeAssignment(issueID string, userID string, sendTo string) (issue *Issue, oldOwner string, err error)
+	// Checklists
+	AddChecklistItem(userID, todoID, message string) (*ChecklistItem, error)
+	GetChecklist(todoID string) ([]*ChecklistItem, error)
+	// CheckChecklistItem checks or unchecks an item, and returns the todo with the foreign ID and list to update if checking the item completed it
+	CheckChecklistItem(userID, itemID string, checked bool) (item *ChecklistItem, completed *Issue, foreignID string, listToUpdate string, err error)
+	DeleteChecklistItem(userID, itemID string) (*ChecklistItem, error)
+	// GetCustomLists returns the custom lists of a user, in their order
+	GetCustomLists(userID string) ([]*CustomList, error)
+	// CreateCustomList adds a custom list at the end of the lists of a user
+	CreateCustomList(userID, name string) (*CustomList, error)
+	// RenameCustomList renames a custom list of a user
+	RenameCustomList(userID, listID, name string) (*CustomList, error)
+	// ReorderCustomLists sorts all the custom lists of a user in the given order
+	ReorderCustomLists(userID string, listIDs []string) error
+	// DeleteCustomList deletes a custom list of a user and moves its todos back to the My list
+	DeleteCustomList(userID, listID string) error
+	// MoveIssue moves an open todo to the My list or a custom list, and returns the list it was in
+	MoveIssue(userID, issueID, listID string) (oldList string, err error)
+	// ReorderIssue moves an open or pending todo to a position in its list, and returns the list
+	ReorderIssue(userID, issueID string, position int) (list string, err error)
+	// TagIssue adds tags to a todo and returns all its tags
+	TagIssue(userID, issueID string, names []string) ([]string, error)
+	// UntagIssue removes a tag from a todo
+	UntagIssue(userID, issueID, name string) error
+	// GetTags returns the tags of a user
+	GetTags(userID string) ([]*Tag, error)
+	// AddDependency makes a todo blocked by another one, rejecting circular dependencies
+	AddDependency(userID, todoID, blockerID string) error
+	// RemoveDependency removes a todo from the todos blocking another one
+	RemoveDependency(userID, todoID, blockerID string) error
+	// GetUnblockedIssues returns the todos whose last blocker is the resolved todo blockerID
+	GetUnblockedIssues(blockerID string) ([]*Issue, error)
+	// SetRecurrence sets the recurrence rule of a todo, or stops its series with an empty rule
+	SetRecurrence(userID, issueID, rule string, version int64) (*Issue, error)
+	// SetIssueStatus changes the status of a todo following the workflow, and returns the issue, the foreign user if any and the list of the todo
+	SetIssueStatus(userID, issueID, status string, version int64) (issue *Issue, foreignID string, listToUpdate string, err error)
+	// SetChecklistAutoComplete sets whether the todo is completed when all its checklist items are checked
+	SetChecklistAutoComplete(userID, todoID string, enabled bool) error
+	// EditIssue updates the message on an issue.
+	// It returns ErrConflict if version is set and the issue is no longer at that version.
+	EditIssue(userID string, issueID string, newMessage string, newDescription string, newDueAt int64, newPriority int, version int64) (foreignUserID string, list string, oldMessage string, err error)
+	// ChangeAssignment updates an issue to assign a different person.
+	// It returns ErrConflict if version is set and the issue is no longer at that version.
+	ChangeAssignment(issueID string, userID string, sendTo string, version int64) (issue *Issue, oldOwner string, err error)
 	// GetUserName returns the readable username from userID
 	GetUserName(userID string) string
 	// IsAuthorized checks if the user has access to the todo
 	IsAuthorized(todoID string, userID string) (bool, error)
+	// IsAuthorizedToRead checks if the user can see the todo, as a watcher of it
+	IsAuthorizedToRead(todoID string, userID string) (bool, error)
+
+	// Watchers
+	// Watch makes watcherID a watcher of issueID, on behalf of userID
+	Watch(userID, issueID, watcherID string) error
+	// Unwatch stops watcherID from watching issueID, on behalf of userID
+	Unwatch(userID, issueID, watcherID string) error
+	// GetWatchers returns the watchers of issueID
+	GetWatchers(issueID string) ([]*Watcher, error)
 }
 
 // Plugin implements the interface expected by the Mattermost server to communicate between the server and plugin processes.
@@ -82,6 +163,8 @@ type Plugin struct {
 
 	telemetryClient telemetry.Client
 	tracker         telemetry.Tracker
+
+	jobRunner *jobRunner
 }
 
 func (p *Plugin) OnActivate() error {
@@ -114,10 +197,19 @@ func (p *Plugin) OnActivate() error {
 	}
 	p.store = sqlStore
 
+	if err = p.migrateLegacyKVData(); err != nil {
+		return errors.Wrap(err, "failed to migrate legacy todo data")
+	}
+
 	p.listManager = NewListManager(p.API, sqlStore)
 
 	p.initializeAPI()
 
+	p.jobRunner = newJobRunner(p.API, p.store)
+	p.jobRunner.Register(reminderJobName, reminderCheckInterval, p.sendDailyReminders)
+	p.jobRunner.Register(trashPurgeJobName, trashPurgeInterval, p.purgeTrash)
+	p.jobRunner.Start()
+
 	p.telemetryClient, err = telemetry.NewRudderClient()
 	if err != nil {
 		p.API.LogWarn("telemetry client not started", "error", err.Error())
@@ -127,6 +219,10 @@ func (p *Plugin) OnActivate() error {
 }
 
 func (p *Plugin) OnDeactivate() error {
+	if p.jobRunner != nil {
+		p.jobRunner.Stop()
+	}
+
 	if p.telemetryClient != nil {
 		err := p.telemetryClient.Close()
 		if err != nil {
@@ -143,13 +239,29 @@ func (p *Plugin) initializeAPI() {
 
 	p.router.Handle("/add", p.checkAuth(http.HandlerFunc(p.handleAdd))).Methods(http.MethodPost)
 	p.router.Handle("/lists", p.checkAuth(http.HandlerFunc(p.handleLists))).Methods(http.MethodGet)
+	p.router.Handle("/lists/custom", p.checkAuth(http.HandlerFunc(p.handleGetCustomLists))).Methods(http.MethodGet)
+	p.router.Handle("/lists/create", p.checkAuth(http.HandlerFunc(p.handleCreateCustomList))).Methods(http.MethodPost)
+	p.router.Handle("/lists/rename", p.checkAuth(http.HandlerFunc(p.handleRenameCustomList))).Methods(http.MethodPost)
+	p.router.Handle("/lists/reorder", p.checkAuth(http.HandlerFunc(p.handleReorderCustomLists))).Methods(http.MethodPost)
+	p.router.Handle("/lists/delete", p.checkAuth(http.HandlerFunc(p.handleDeleteCustomList))).Methods(http.MethodPost)
+	p.router.Handle("/move", p.checkAuth(http.HandlerFunc(p.handleMove))).Methods(http.MethodPost)
+	p.router.Handle("/reorder", p.checkAuth(http.HandlerFunc(p.handleReorder))).Methods(http.MethodPost)
+	p.router.Handle("/todos", p.checkAuth(http.HandlerFunc(p.handleQueryIssues))).Methods(http.MethodGet)
+	p.router.Handle("/search", p.checkAuth(http.HandlerFunc(p.handleSearch))).Methods(http.MethodGet)
 	p.router.Handle("/remove", p.checkAuth(http.HandlerFunc(p.handleRemove))).Methods(http.MethodPost)
+	p.router.Handle("/trash", p.checkAuth(http.HandlerFunc(p.handleTrash))).Methods(http.MethodGet)
+	p.router.Handle("/restore", p.checkAuth(http.HandlerFunc(p.handleRestore))).Methods(http.MethodPost)
+	p.router.Handle("/undo", p.checkAuth(http.HandlerFunc(p.handleUndo))).Methods(http.MethodPost)
 	p.router.Handle("/complete", p.checkAuth(http.HandlerFunc(p.handleComplete))).Methods(http.MethodPost)
 	p.router.Handle("/accept", p.checkAuth(http.HandlerFunc(p.handleAccept))).Methods(http.MethodPost)
+	p.router.Handle("/decline", p.checkAuth(http.HandlerFunc(p.handleDecline))).Methods(http.MethodPost)
 	p.router.Handle("/bump", p.checkAuth(http.HandlerFunc(p.handleBump))).Methods(http.MethodPost)
 	p.router.Handle("/telemetry", p.checkAuth(http.HandlerFunc(p.handleTelemetry))).Methods(http.MethodPost)
 	p.router.Handle("/config", p.checkAuth(http.HandlerFunc(p.handleConfig))).Methods(http.MethodGet)
 	p.router.Handle("/edit", p.checkAuth(http.HandlerFunc(p.handleEdit))).Methods(http.MethodPut)
+	p.router.Handle("/recurrence", p.checkAuth(http.HandlerFunc(p.handleSetRecurrence))).Methods(http.MethodPost)
+	p.router.Handle("/status", p.checkAuth(http.HandlerFunc(p.handleSetStatus))).Methods(http.MethodPost)
+	p.router.Handle("/team", p.checkAuth(http.HandlerFunc(p.handleTeamBoard))).Methods(http.MethodGet)
 	p.router.Handle("/change_assignment", p.checkAuth(http.HandlerFunc(p.handleChangeAssignment))).Methods(http.MethodPost)
 
 	commentsRouter := p.router.PathPrefix("/comments").Subrouter()
@@ -159,6 +271,45 @@ func (p *Plugin) initializeAPI() {
 	commentsRouter.HandleFunc("/add", p.handleAddComment).Methods(http.MethodPost)
 	commentsRouter.HandleFunc("/delete", p.handleDeleteComment).Methods(http.MethodPost)
 
+	checklistRouter := p.router.PathPrefix("/checklist").Subrouter()
+	checklistRouter.Use(p.checkAuth)
+
+	checklistRouter.HandleFunc("/get", p.handleGetChecklist).Methods(http.MethodGet)
+	checklistRouter.HandleFunc("/add", p.handleAddChecklistItem).Methods(http.MethodPost)
+	checklistRouter.HandleFunc("/check", p.handleCheckChecklistItem).Methods(http.MethodPost)
+	checklistRouter.HandleFunc("/delete", p.handleDeleteChecklistItem).Methods(http.MethodPost)
+	checklistRouter.HandleFunc("/auto_complete", p.handleSetChecklistAutoComplete).Methods(http.MethodPost)
+
+	tagsRouter := p.router.PathPrefix("/tags").Subrouter()
+	tagsRouter.Use(p.checkAuth)
+
+	tagsRouter.HandleFunc("", p.handleGetTags).Methods(http.MethodGet)
+	tagsRouter.HandleFunc("/add", p.handleAddTags).Methods(http.MethodPost)
+	tagsRouter.HandleFunc("/remove", p.handleRemoveTags).Methods(http.MethodPost)
+
+	dependenciesRouter := p.router.PathPrefix("/dependencies").Subrouter()
+	dependenciesRouter.Use(p.checkAuth)
+
+	dependenciesRouter.HandleFunc("/add", p.handleAddDependency).Methods(http.MethodPost)
+	dependenciesRouter.HandleFunc("/remove", p.handleRemoveDependency).Methods(http.MethodPost)
+
+	watchersRouter := p.router.PathPrefix("/watchers").Subrouter()
+	watchersRouter.Use(p.checkAuth)
+
+	watchersRouter.HandleFunc("", p.handleGetWatchers).Methods(http.MethodGet)
+	watchersRouter.HandleFunc("/subscribe", p.handleSubscribe).Methods(http.MethodPost)
+	watchersRouter.HandleFunc("/unsubscribe", p.handleUnsubscribe).Methods(http.MethodPost)
+
+	channelRouter := p.router.PathPrefix("/channel").Subrouter()
+	channelRouter.Use(p.checkAuth)
+
+	channelRouter.HandleFunc("/list", p.handleGetChannelIssues).Methods(http.MethodGet)
+	channelRouter.HandleFunc("/add", p.handleAddChannelIssue).Methods(http.MethodPost)
+	channelRouter.HandleFunc("/complete", p.handleCompleteChannelIssue).Methods(http.MethodPost)
+
+	// The dynamic list of the tag arguments of the slash command
+	p.router.Handle("/autocomplete/tags", p.checkAuth(http.HandlerFunc(p.handleAutocompleteTags))).Methods(http.MethodGet)
+
 	// 404 handler
 	p.router.Handle("{anything:.*}", http.NotFoundHandler())
 }
@@ -235,6 +386,11 @@ func (p *Plugin) handleAdd(w http.ResponseWriter, r *http.Request) {
 
 	senderName := p.listManager.GetUserName(userID)
 
+	if len(addRequest.Assignees) > 1 {
+		p.sendSharedIssue(w, userID, addRequest)
+		return
+	}
+
 	if addRequest.SendTo == "" {
 		_, err = p.listManager.AddIssue(userID, addRequest.Message, addRequest.PostPermalink, addRequest.Description, addRequest.PostID, addRequest.DueAt, addRequest.Priority)
 		if err != nil {
@@ -309,6 +465,63 @@ func (p *Plugin) handleAdd(w http.ResponseWriter, r *http.Request) {
 	p.postReplyIfNeeded(addRequest.PostID, replyMessage, addRequest.Message, addRequest.PostPermalink)
 }
 
+// sendSharedIssue sends the todo of addRequest from userID to each of its assignees
+func (p *Plugin) sendSharedIssue(w http.ResponseWriter, userID string, addRequest *AddAPIRequest) {
+	var receiverIDs []string
+	for _, username := range addRequest.Assignees {
+		receiver, appErr := p.API.GetUserByUsername(strings.TrimPrefix(username, "@"))
+		if appErr != nil {
+			msg := "Unable to find user"
+			p.API.LogError(msg, "err", appErr.Error())
+			p.handleErrorWithCode(w, http.StatusBadRequest, msg, appErr)
+			return
+		}
+
+		allowed, err := p.getAllowIncomingTaskRequestsPreference(receiver.Id)
+		if err != nil {
+			p.API.LogError("Error when getting allow incoming task request preference, err=", err)
+			allowed = true
+		}
+		if !allowed {
+			p.PostBotDM(userID, fmt.Sprintf("@%s has blocked Todo requests", receiver.Username))
+			return
+		}
+		receiverIDs = append(receiverIDs, receiver.Id)
+	}
+
+	receiverIssueIDs, err := p.listManager.SendSharedIssue(userID, receiverIDs, addRequest.Completion, addRequest.Message, addRequest.PostPermalink, addRequest.Description, addRequest.PostID, addRequest.DueAt, addRequest.Priority)
+	if errors.Is(err, ErrInvalidAssignees) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to send issue", err)
+		return
+	}
+	if err != nil {
+		msg := "Unable to send issue"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	p.trackSendIssue(userID, sourceWebapp, addRequest.PostID != "")
+	p.notifySharedIssueSent(userID, receiverIssueIDs, addRequest.Message, addRequest.PostPermalink)
+
+	senderName := p.listManager.GetUserName(userID)
+	replyMessage := fmt.Sprintf("@%s sent @%s a todo attached to this thread", senderName, strings.Join(addRequest.Assignees, ", @"))
+	p.postReplyIfNeeded(addRequest.PostID, replyMessage, addRequest.Message, addRequest.PostPermalink)
+}
+
+// notifySharedIssueSent refreshes the lists of the sender and the receivers of a shared todo,
+// and lets each receiver know about their copy of it
+func (p *Plugin) notifySharedIssueSent(senderID string, receiverIssueIDs map[string]string, message, postPermalink string) {
+	p.sendRefreshEvent(senderID, []string{OutListKey})
+
+	senderName := p.listManager.GetUserName(senderID)
+	receiverMessage := fmt.Sprintf("You have received a new Todo from @%s, shared with %d users", senderName, len(receiverIssueIDs))
+	for receiverID, issueID := range receiverIssueIDs {
+		p.sendRefreshEvent(receiverID, []string{InListKey})
+		p.PostBotCustomDM(receiverID, receiverMessage, message, postPermalink, issueID)
+	}
+}
+
 func (p *Plugin) postReplyIfNeeded(postID, message, todo, postPermalink string) {
 	if postID != "" {
 		err := p.ReplyPostBot(postID, message, todo, postPermalink)
@@ -329,34 +542,6 @@ func (p *Plugin) handleLists(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if allListIssue != nil && len(allListIssue.My) > 0 && r.URL.Query().Get("reminder") == "true" && p.getReminderPreference(userID) {
-		var lastReminderAt int64
-		lastReminderAt, err = p.getLastReminderTimeForUser(userID)
-		if err != nil {
-			msg := "Unable to send reminder"
-			p.API.LogError(msg, "err", err.Error())
-			p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
-			return
-		}
-
-		var timezone *time.Location
-		offset, _ := strconv.Atoi(r.Header.Get("X-Timezone-Offset"))
-		timezone = time.FixedZone("local", -60*offset)
-
-		// Post reminder message if it's the next day and been more than an hour since the last post
-		now := model.GetMillis()
-		nt := time.Unix(now/1000, 0).In(timezone)
-		lt := time.Unix(lastReminderAt/1000, 0).In(timezone)
-		if nt.Sub(lt).Hours() >= 1 && (nt.Day() != lt.Day() || nt.Month() != lt.Month() || nt.Year() != lt.Year()) {
-			p.PostBotDM(userID, "Daily Reminder:\n\n"+issuesListToString(allListIssue.My))
-			p.trackDailySummary(userID)
-			err = p.saveLastReminderTimeForUser(userID)
-			if err != nil {
-				p.API.LogError("Unable to save last reminder for user err=" + err.Error())
-			}
-		}
-	}
-
 	allListIssueJSON, err := json.Marshal(allListIssue)
 	if err != nil {
 		msg := "Unable marhsal all lists issues to json"
@@ -371,6 +556,69 @@ func (p *Plugin) handleLists(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (p *Plugin) handleQueryIssues(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	query, err := GetIssueQueryFromURL(r.URL.Query())
+	if err == nil {
+		err = query.IsValid()
+	}
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate the todos query.", err)
+		return
+	}
+
+	page, err := p.listManager.QueryIssues(userID, query)
+	if err != nil {
+		msg := "Unable to get todos for user"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	pageJSON, err := json.Marshal(page)
+	if err != nil {
+		msg := "Unable to marshal todos to json"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	if _, err = w.Write(pageJSON); err != nil {
+		p.API.LogError("Unable to write json response while querying todos", "err", err.Error())
+	}
+}
+
+func (p *Plugin) handleSearch(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	terms := strings.TrimSpace(r.URL.Query().Get("terms"))
+	if terms == "" {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Missing terms parameter", nil)
+		return
+	}
+
+	issues, err := p.listManager.SearchIssues(userID, terms)
+	if err != nil {
+		msg := "Unable to search todos"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	issuesJSON, err := json.Marshal(issues)
+	if err != nil {
+		msg := "Unable to marshal todos to json"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	if _, err = w.Write(issuesJSON); err != nil {
+		p.API.LogError("Unable to write json response while searching todos", "err", err.Error())
+	}
+}
+
 func (p *Plugin) handleEdit(w http.ResponseWriter, r *http.Request) {
 	userID := r.Header.Get("Mattermost-User-ID")
 
@@ -391,7 +639,14 @@ func (p *Plugin) handleEdit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	foreignUserID, list, oldMessage, err := p.listManager.EditIssue(userID, editRequest.ID, editRequest.Message, editRequest.Description, editRequest.DueAt, editRequest.Priority)
+	// The due date is read before the edit, to let the watchers know when it changes.
+	before, _ := p.store.GetIssue(editRequest.ID)
+
+	foreignUserID, list, oldMessage, err := p.listManager.EditIssue(userID, editRequest.ID, editRequest.Message, editRequest.Description, editRequest.DueAt, editRequest.Priority, editRequest.Version)
+	if errors.Is(err, ErrConflict) {
+		p.handleConflict(w, editRequest.ID, err)
+		return
+	}
 	if err != nil {
 		msg := "Unable to edit message"
 		p.API.LogError(msg, "err", err.Error())
@@ -402,6 +657,10 @@ func (p *Plugin) handleEdit(w http.ResponseWriter, r *http.Request) {
 	p.trackEditIssue(userID)
 	p.sendRefreshEvent(userID, []string{list})
 
+	if before != nil && before.DueAt != editRequest.DueAt {
+		p.notifyDueDateChanged(userID, editRequest.ID, SanitizeInput(editRequest.Message), editRequest.DueAt)
+	}
+
 	if foreignUserID != "" {
 		var lists []string
 		if list == OutListKey {
@@ -414,7 +673,103 @@ func (p *Plugin) handleEdit(w http.ResponseWriter, r *http.Request) {
 		userName := p.listManager.GetUserName(userID)
 		message := fmt.Sprintf("@%s modified a Todo from:\n%s\nTo:\n%s", userName, oldMessage, editRequest.Message)
 		p.PostBotDM(foreignUserID, message)
+		p.notifyAssignees(p.getAssignees(editRequest.ID), message, userID, foreignUserID)
+	}
+}
+
+func (p *Plugin) handleSetRecurrence(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	recurrenceRequest, err := GetRecurrencePayloadFromJSON(r.Body)
+	if err != nil {
+		msg := "Unable to get recurrence payload from JSON"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	if err = recurrenceRequest.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate recurrence payload.", err)
+		return
+	}
+
+	if !p.checkAuthorization(w, recurrenceRequest.ID, userID) {
+		return
+	}
+
+	issue, err := p.listManager.SetRecurrence(userID, recurrenceRequest.ID, recurrenceRequest.Recurrence, recurrenceRequest.Version)
+	if errors.Is(err, ErrConflict) {
+		p.handleConflict(w, recurrenceRequest.ID, err)
+		return
+	}
+	if err != nil {
+		msg := "Unable to set the recurrence"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	p.sendTodoRefreshEvents(userID, issue.ID)
+	p.writeJSON(w, issue)
+}
+
+func (p *Plugin) handleSetStatus(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	statusRequest, err := GetStatusPayloadFromJSON(r.Body)
+	if err != nil {
+		msg := "Unable to get status payload from JSON"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	if err = statusRequest.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate status payload.", err)
+		return
+	}
+
+	if !p.checkAuthorization(w, statusRequest.ID, userID) {
+		return
+	}
+
+	issue, foreignID, listToUpdate, err := p.listManager.SetIssueStatus(userID, statusRequest.ID, statusRequest.Status, statusRequest.Version)
+	if errors.Is(err, ErrConflict) {
+		p.handleConflict(w, statusRequest.ID, err)
+		return
+	}
+	if errors.Is(err, ErrInvalidTransition) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to change the status", err)
+		return
+	}
+	if err != nil {
+		msg := "Unable to change the status"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	p.writeJSON(w, issue)
+	p.notifyStatusChanged(userID, issue, foreignID, listToUpdate)
+}
+
+// notifyStatusChanged refreshes the lists and notifies the users involved in a todo whose
+// status was changed by userID
+func (p *Plugin) notifyStatusChanged(userID string, issue *Issue, foreignID, listToUpdate string) {
+	if issue.Status == StatusDone {
+		p.notifyIssueCompleted(userID, issue, foreignID, listToUpdate)
+		return
 	}
+
+	p.sendRefreshEvent(userID, []string{listToUpdate})
+	if foreignID == "" {
+		return
+	}
+
+	p.sendRefreshEvent(foreignID, []string{OutListKey})
+	userName := p.listManager.GetUserName(userID)
+	message := fmt.Sprintf("@%s marked a Todo you sent as %s: %s", userName, statusLabel(issue.Status), issue.Message)
+	p.PostBotDM(foreignID, message)
 }
 
 func (p *Plugin) handleChangeAssignment(w http.ResponseWriter, r *http.Request) {
@@ -445,7 +800,15 @@ func (p *Plugin) handleChangeAssignment(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	issue, oldOwner, err := p.listManager.ChangeAssignment(changeRequest.ID, userID, receiver.Id)
+	issue, oldOwner, err := p.listManager.ChangeAssignment(changeRequest.ID, userID, receiver.Id, changeRequest.Version)
+	if errors.Is(err, ErrConflict) {
+		p.handleConflict(w, changeRequest.ID, err)
+		return
+	}
+	if errors.Is(err, ErrInvalidAssignees) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to change the assignment of an issue", err)
+		return
+	}
 	if err != nil {
 		msg := "Unable to change the assignment of an issue"
 		p.API.LogError(msg, "err", err.Error())
@@ -468,6 +831,9 @@ func (p *Plugin) handleChangeAssignment(w http.ResponseWriter, r *http.Request)
 		oldOwnerMessage := fmt.Sprintf("@%s removed you from Todo:\n%s", userName, issue.Message)
 		p.PostBotDM(oldOwner, oldOwnerMessage)
 	}
+
+	watcherMessage := fmt.Sprintf("@%s assigned a Todo you watch to @%s: %s", userName, receiver.Username, issue.Message)
+	p.notifyWatchers(changeRequest.ID, watcherMessage, userID, receiver.Id)
 }
 
 func (p *Plugin) handleAccept(w http.ResponseWriter, r *http.Request) {
@@ -508,6 +874,62 @@ func (p *Plugin) handleAccept(w http.ResponseWriter, r *http.Request) {
 	p.PostBotDM(sender, message)
 }
 
+func (p *Plugin) handleDecline(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	declineRequest, err := GetDeclineRequestPayloadFromJSON(r.Body)
+	if err != nil {
+		msg := "Unable to get decline request payload from JSON"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	if err = declineRequest.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate decline request payload.", err)
+		return
+	}
+
+	if !p.checkAuthorization(w, declineRequest.ID, userID) {
+		return
+	}
+
+	issue, senderID, err := p.listManager.DeclineIssue(userID, declineRequest.ID, declineRequest.Reason)
+	if errors.Is(err, ErrConflict) {
+		p.handleConflict(w, declineRequest.ID, err)
+		return
+	}
+	if errors.Is(err, ErrInvalidTransition) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decline issue", err)
+		return
+	}
+	if err != nil {
+		msg := "Unable to decline issue"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	p.notifyIssueDeclined(userID, issue, senderID, declineRequest.Reason)
+}
+
+// notifyIssueDeclined refreshes the lists of the users involved in a todo declined by userID,
+// and lets its sender know why
+func (p *Plugin) notifyIssueDeclined(userID string, issue *Issue, senderID, reason string) {
+	p.sendRefreshEvent(userID, []string{InListKey})
+	p.sendRefreshEvent(senderID, []string{OutListKey})
+
+	userName := p.listManager.GetUserName(userID)
+	message := fmt.Sprintf("@%s declined a Todo you sent: %s", userName, issue.Message)
+	if reason = SanitizeInput(reason); reason != "" {
+		message = fmt.Sprintf("%s\nReason: %s", message, reason)
+	}
+	if issue.PostPermalink != "" {
+		message = fmt.Sprintf("%s\n[Permalink](%s)", message, issue.PostPermalink)
+	}
+	p.PostBotDM(senderID, message)
+}
+
 func (p *Plugin) handleComplete(w http.ResponseWriter, r *http.Request) {
 	userID := r.Header.Get("Mattermost-User-ID")
 
@@ -529,6 +951,14 @@ func (p *Plugin) handleComplete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	issue, foreignID, listToUpdate, err := p.listManager.CompleteIssue(userID, completeRequest.ID)
+	if errors.Is(err, ErrConflict) {
+		p.handleConflict(w, completeRequest.ID, err)
+		return
+	}
+	if errors.Is(err, ErrInvalidTransition) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to complete issue", err)
+		return
+	}
 	if err != nil {
 		msg := "Unable to complete issue"
 		p.API.LogError(msg, "err", err.Error())
@@ -536,28 +966,98 @@ func (p *Plugin) handleComplete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p.sendRefreshEvent(userID, []string{listToUpdate})
+	p.notifyIssueCompleted(userID, issue, foreignID, listToUpdate)
+}
+
+// notifyIssueCompleted refreshes the lists and notifies the users involved in a todo
+// completed by userID
+func (p *Plugin) notifyIssueCompleted(userID string, issue *Issue, foreignID, listToUpdate string) {
+	p.sendRefreshEvent(userID, []string{listToUpdate, DoneListKey})
+	p.notifyUnblocked(issue.ID)
 
 	p.trackCompleteIssue(userID)
 
 	userName := p.listManager.GetUserName(userID)
 	replyMessage := fmt.Sprintf("@%s completed a todo attached to this thread", userName)
 	p.postReplyIfNeeded(issue.PostID, replyMessage, issue.Message, issue.PostPermalink)
+	p.notifyWatchersCompleted(userID, issue)
 
 	if foreignID == "" {
 		return
 	}
 
-	p.sendRefreshEvent(foreignID, []string{OutListKey})
-
 	if issue.PostPermalink != "" {
 		issue.Message = fmt.Sprintf("%s\n[Permalink](%s)", issue.Message, issue.PostPermalink)
 	}
 
+	if issue.IsShared() {
+		p.notifySharedIssueCompleted(userID, issue)
+		return
+	}
+
+	p.sendRefreshEvent(foreignID, []string{OutListKey})
+
 	message := fmt.Sprintf("@%s completed a Todo you sent: %s", userName, issue.Message)
 	p.PostBotDM(foreignID, message)
 }
 
+// notifySharedIssueCompleted lets the sender of the shared todo issue, just completed by
+// userID, know about the progress of its assignees. The other assignees are notified too when
+// the todo is done for everyone.
+func (p *Plugin) notifySharedIssueCompleted(userID string, issue *Issue) {
+	assignees := p.getAssignees(issue.ID)
+	userName := p.listManager.GetUserName(userID)
+
+	done, total := 0, 0
+	for _, assignee := range assignees {
+		if assignee.Removed {
+			continue
+		}
+		total++
+		if assignee.Status == StatusDone {
+			done++
+		}
+	}
+
+	senderID := issue.CreatorID
+	if senderID != userID {
+		p.sendRefreshEvent(senderID, []string{OutListKey})
+		message := fmt.Sprintf("@%s completed a Todo you sent: %s", userName, issue.Message)
+		if done < total {
+			message = fmt.Sprintf("@%s completed a Todo you sent, %d of %d assignees are done: %s", userName, done, total, issue.Message)
+		}
+		p.PostBotDM(senderID, message)
+	}
+
+	if senderID == userID || issue.Completion == CompletionAny {
+		message := fmt.Sprintf("@%s completed a Todo shared with you: %s", userName, issue.Message)
+		p.notifyAssignees(assignees, message, userID)
+	}
+}
+
+// getAssignees returns the assignees of the shared todo issueID, or nil if the todo is not
+// shared or they cannot be found
+func (p *Plugin) getAssignees(issueID string) []*Assignee {
+	assignees, err := p.listManager.GetAssignees(issueID)
+	if err != nil {
+		p.API.LogError("Unable to get the assignees of the todo", "err", err.Error())
+		return nil
+	}
+	return assignees
+}
+
+// notifyAssignees refreshes the lists of the assignees of a shared todo who did not remove it
+// and sends them message, except to the users of skipIDs
+func (p *Plugin) notifyAssignees(assignees []*Assignee, message string, skipIDs ...string) {
+	for _, assignee := range assignees {
+		if assignee.Removed || slices.Contains(skipIDs, assignee.UserID) {
+			continue
+		}
+		p.sendRefreshEvent(assignee.UserID, []string{MyListKey, InListKey, DoneListKey})
+		p.PostBotDM(assignee.UserID, message)
+	}
+}
+
 func (p *Plugin) handleRemove(w http.ResponseWriter, r *http.Request) {
 	userID := r.Header.Get("Mattermost-User-ID")
 
@@ -578,7 +1078,14 @@ func (p *Plugin) handleRemove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The assignees of a shared todo are read before their copies are removed with it.
+	assignees := p.getAssignees(removeRequest.ID)
+
 	issue, foreignID, isSender, listToUpdate, err := p.listManager.RemoveIssue(userID, removeRequest.ID)
+	if errors.Is(err, ErrConflict) {
+		p.handleConflict(w, removeRequest.ID, err)
+		return
+	}
 	if err != nil {
 		msg := "Unable to remove issue"
 		p.API.LogError(msg, "err", err.Error())
@@ -612,23 +1119,194 @@ func (p *Plugin) handleRemove(w http.ResponseWriter, r *http.Request) {
 	p.sendRefreshEvent(foreignID, []string{list})
 
 	p.PostBotDM(foreignID, message)
+
+	if !isSender {
+		p.notifyAssignees(assignees, message, userID, foreignID)
+	}
 }
 
-func (p *Plugin) handleBump(w http.ResponseWriter, r *http.Request) {
+func (p *Plugin) handleTrash(w http.ResponseWriter, r *http.Request) {
 	userID := r.Header.Get("Mattermost-User-ID")
 
-	bumpRequest, err := GetBumpIssuePayloadFromJSON(r.Body)
+	issues, err := p.listManager.GetTrash(userID)
 	if err != nil {
-		msg := "Unable to get bump issue request payload from JSON"
+		msg := "Unable to get the trash"
 		p.API.LogError(msg, "err", err.Error())
-		p.handleErrorWithCode(w, http.StatusBadRequest, msg, err)
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
 		return
 	}
 
-	if err = bumpRequest.IsValid(); err != nil {
-		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate bump issue request payload.", err)
-		return
-	}
+	issuesJSON, err := json.Marshal(issues)
+	if err != nil {
+		msg := "Unable to marshal todos to json"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	if _, err = w.Write(issuesJSON); err != nil {
+		p.API.LogError("Unable to write json response while getting the trash", "err", err.Error())
+	}
+}
+
+func (p *Plugin) handleRestore(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	restoreRequest, err := GetRestoreIssuePayloadFromJSON(r.Body)
+	if err != nil {
+		msg := "Unable to get restore issue request payload from JSON"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	if err = restoreRequest.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate restore issue request payload.", err)
+		return
+	}
+
+	if !p.checkAuthorization(w, restoreRequest.ID, userID) {
+		return
+	}
+
+	issue, err := p.restoreIssue(userID, restoreRequest.ID)
+	if err != nil {
+		msg := "Unable to restore issue"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	issueJSON, err := json.Marshal(issue)
+	if err != nil {
+		msg := "Unable to marshal todo to json"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	if _, err = w.Write(issueJSON); err != nil {
+		p.API.LogError("Unable to write json response while restoring a todo", "err", err.Error())
+	}
+}
+
+// restoreIssue restores the todo issueID of userID from the trash and lets the other party
+// of a sent todo know it is back.
+func (p *Plugin) restoreIssue(userID, issueID string) (*Issue, error) {
+	issue, foreignID, foreignList, listToUpdate, err := p.listManager.RestoreIssue(userID, issueID)
+	if err != nil {
+		return nil, err
+	}
+	p.sendRefreshEvent(userID, []string{listToUpdate})
+
+	if foreignID == "" && !issue.IsShared() {
+		return issue, nil
+	}
+
+	userName := p.listManager.GetUserName(userID)
+	message := fmt.Sprintf("@%s restored a Todo: %s", userName, issue.Message)
+	if issue.PostPermalink != "" {
+		message = fmt.Sprintf("%s\n[Permalink](%s)", message, issue.PostPermalink)
+	}
+
+	if issue.IsShared() && issue.IsSenderCopy() {
+		// The assignees get their copies back with the sender's one.
+		p.notifyAssignees(p.getAssignees(issue.ID), message, userID)
+		return issue, nil
+	}
+
+	p.sendRefreshEvent(foreignID, []string{foreignList})
+	p.PostBotDM(foreignID, message)
+
+	return issue, nil
+}
+
+// handleUndo serves the Undo button of the bot responses. The action of the button is only
+// undone while it is still the last action of the user, and the outcome is returned as the
+// ephemeral text of a post action response.
+func (p *Plugin) handleUndo(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	var request model.PostActionIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		msg := "Unable to decode JSON"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, msg, err)
+		return
+	}
+	logID, _ := request.Context[undoContextKey].(string)
+	if logID == "" {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Missing action to undo", nil)
+		return
+	}
+
+	message, err := p.undoLastAction(userID, logID)
+	if err != nil {
+		msg := "Unable to undo the last action"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	responseJSON, err := json.Marshal(&model.PostActionIntegrationResponse{EphemeralText: message})
+	if err != nil {
+		msg := "Unable to marshal undo response to json"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	if _, err = w.Write(responseJSON); err != nil {
+		p.API.LogError("Unable to write json response while undoing an action", "err", err.Error())
+	}
+}
+
+// undoLastAction undoes the last action of userID, if its audit log is logID when it is not
+// empty, and returns the message telling them the outcome. Only unexpected failures are
+// returned as errors.
+func (p *Plugin) undoLastAction(userID, logID string) (string, error) {
+	log, issues, err := p.listManager.UndoLastAction(userID, logID)
+	switch {
+	case errors.Is(err, ErrNothingToUndo) && logID != "":
+		return fmt.Sprintf("This action can no longer be undone, only your last action from the last %d minutes can.", int(undoWindow.Minutes())), nil
+	case errors.Is(err, ErrNothingToUndo):
+		return fmt.Sprintf("There is nothing to undo from the last %d minutes.", int(undoWindow.Minutes())), nil
+	case errors.Is(err, ErrConflict):
+		return "The Todo changed since, it can no longer be undone.", nil
+	case err != nil:
+		return "", err
+	}
+
+	refreshed := map[string]bool{}
+	for _, issue := range issues {
+		ownerID := issue.OwnerID()
+		if !refreshed[ownerID] {
+			refreshed[ownerID] = true
+			p.sendRefreshEvent(ownerID, []string{MyListKey, InListKey, OutListKey, DoneListKey})
+		}
+	}
+
+	if len(issues) == 0 {
+		return fmt.Sprintf("Undid %s.", log.Action), nil
+	}
+	return fmt.Sprintf("Undid %s of Todo: %s", log.Action, issues[0].Message), nil
+}
+
+func (p *Plugin) handleBump(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	bumpRequest, err := GetBumpIssuePayloadFromJSON(r.Body)
+	if err != nil {
+		msg := "Unable to get bump issue request payload from JSON"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	if err = bumpRequest.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate bump issue request payload.", err)
+		return
+	}
 
 	if !p.checkAuthorization(w, bumpRequest.ID, userID) {
 		return
@@ -653,6 +1331,14 @@ func (p *Plugin) handleBump(w http.ResponseWriter, r *http.Request) {
 	userName := p.listManager.GetUserName(userID)
 	message := fmt.Sprintf("@%s bumped a Todo you received.", userName)
 	p.PostBotCustomDM(foreignUser, message, todo.Message, todo.PostPermalink, foreignIssueID)
+
+	for _, assignee := range p.getAssignees(foreignIssueID) {
+		if assignee.UserID == foreignUser || assignee.Removed || (assignee.Status != StatusPending && !isActiveStatus(assignee.Status)) {
+			continue
+		}
+		p.sendRefreshEvent(assignee.UserID, []string{InListKey})
+		p.PostBotCustomDM(assignee.UserID, message, todo.Message, todo.PostPermalink, assignee.IssueID)
+	}
 }
 
 // API endpoint to retrieve plugin configurations
@@ -693,6 +1379,15 @@ func (p *Plugin) sendRefreshEvent(userID string, lists []string) {
 	)
 }
 
+// sendChannelRefreshEvent lets the members of channelID know that its Todo list changed
+func (p *Plugin) sendChannelRefreshEvent(channelID string) {
+	p.API.PublishWebSocketEvent(
+		WSEventChannelRefresh,
+		map[string]interface{}{"channel_id": channelID},
+		&model.WebsocketBroadcast{ChannelId: channelID},
+	)
+}
+
 // Publish a WebSocket event to update the client config of the plugin on the webapp end.
 func (p *Plugin) sendConfigUpdateEvent() {
 	clientConfigMap := map[string]interface{}{
@@ -707,13 +1402,41 @@ func (p *Plugin) sendConfigUpdateEvent() {
 }
 
 func (p *Plugin) handleErrorWithCode(w http.ResponseWriter, code int, errTitle string, err error) {
+	details := ""
+	if err != nil {
+		details = err.Error()
+	}
+
 	w.WriteHeader(code)
 	b, _ := json.Marshal(struct {
 		Error   string `json:"error"`
 		Details string `json:"details"`
 	}{
 		Error:   errTitle,
+		Details: details,
+	})
+	_, _ = w.Write(b)
+}
+
+// handleConflict responds with the current state of the todo issueID, after an update based
+// on an outdated version of it.
+func (p *Plugin) handleConflict(w http.ResponseWriter, issueID string, err error) {
+	issue, getErr := p.store.GetIssue(issueID)
+	if getErr != nil {
+		p.handleErrorWithCode(w, http.StatusConflict, "The todo was modified in the meantime", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	b, _ := json.Marshal(struct {
+		Error   string `json:"error"`
+		Details string `json:"details"`
+		Issue   *Issue `json:"issue"`
+	}{
+		Error:   "The todo was modified in the meantime",
 		Details: err.Error(),
+		Issue:   issue,
 	})
 	_, _ = w.Write(b)
 }
@@ -727,7 +1450,7 @@ func (p *Plugin) handleGetComments(w http.ResponseWriter, r *http.Request) {
 
 	userID := r.Header.Get("Mattermost-User-ID")
 
-	if !p.checkAuthorization(w, todoID, userID) {
+	if !p.checkReadAuthorization(w, todoID, userID) {
 		return
 	}
 
@@ -776,14 +1499,213 @@ func (p *Plugin) handleAddComment(w http.ResponseWriter, r *http.Request) {
 		if otherUser != userID {
 			p.sendRefreshEvent(otherUser, []string{MyListKey, InListKey, OutListKey})
 		}
+
+		userName := p.listManager.GetUserName(userID)
+		p.notifyWatchers(req.TodoID, fmt.Sprintf("@%s commented on a Todo you watch: %s\n> %s", userName, issue.Message, comment.Message), userID)
 	}
 
 	b, _ := json.Marshal(comment)
 	w.Write(b)
 }
 
+func (p *Plugin) handleGetChecklist(w http.ResponseWriter, r *http.Request) {
+	todoID := r.URL.Query().Get("id")
+	if todoID == "" {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Missing id parameter", nil)
+		return
+	}
+
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	if !p.checkReadAuthorization(w, todoID, userID) {
+		return
+	}
+
+	items, err := p.listManager.GetChecklist(todoID)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get checklist", err)
+		return
+	}
+
+	p.writeJSON(w, items)
+}
+
+func (p *Plugin) handleAddChecklistItem(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetChecklistPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse checklist payload", err)
+		return
+	}
+
+	if req.TodoID == "" || req.Message == "" {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "TodoID and Message are required", nil)
+		return
+	}
+
+	if !p.checkAuthorization(w, req.TodoID, userID) {
+		return
+	}
+
+	item, err := p.listManager.AddChecklistItem(userID, req.TodoID, req.Message)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to add checklist item", err)
+		return
+	}
+
+	p.sendTodoRefreshEvents(userID, req.TodoID)
+	p.writeJSON(w, item)
+}
+
+func (p *Plugin) handleCheckChecklistItem(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetChecklistPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse checklist payload", err)
+		return
+	}
+
+	if !p.checkChecklistItemAuthorization(w, req.ID, userID) {
+		return
+	}
+
+	item, err := p.checkChecklistItem(userID, req.ID, req.Checked)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to check checklist item", err)
+		return
+	}
+
+	p.writeJSON(w, item)
+}
+
+// checkChecklistItem checks or unchecks a checklist item for userID, and notifies the users
+// involved in its todo, including when checking the item completed the todo
+func (p *Plugin) checkChecklistItem(userID, itemID string, checked bool) (*ChecklistItem, error) {
+	item, completed, foreignID, listToUpdate, err := p.listManager.CheckChecklistItem(userID, itemID, checked)
+	if err != nil {
+		return nil, err
+	}
+
+	if completed != nil {
+		p.notifyIssueCompleted(completed.OwnerID(), completed, foreignID, listToUpdate)
+	} else {
+		p.sendTodoRefreshEvents(userID, item.TodoID)
+	}
+	return item, nil
+}
+
+func (p *Plugin) handleDeleteChecklistItem(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetChecklistPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse checklist payload", err)
+		return
+	}
+
+	if !p.checkChecklistItemAuthorization(w, req.ID, userID) {
+		return
+	}
+
+	item, err := p.listManager.DeleteChecklistItem(userID, req.ID)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to delete checklist item", err)
+		return
+	}
+
+	p.sendTodoRefreshEvents(userID, item.TodoID)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *Plugin) handleSetChecklistAutoComplete(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetChecklistPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse checklist payload", err)
+		return
+	}
+
+	if req.TodoID == "" {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "TodoID is required", nil)
+		return
+	}
+
+	if !p.checkAuthorization(w, req.TodoID, userID) {
+		return
+	}
+
+	if err := p.listManager.SetChecklistAutoComplete(userID, req.TodoID, req.AutoComplete); err != nil {
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to set checklist auto-complete", err)
+		return
+	}
+
+	p.sendTodoRefreshEvents(userID, req.TodoID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkChecklistItemAuthorization checks that userID is authorized on the todo of the
+// checklist item itemID, and writes the error response otherwise
+func (p *Plugin) checkChecklistItemAuthorization(w http.ResponseWriter, itemID, userID string) bool {
+	if itemID == "" {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "ID is required", nil)
+		return false
+	}
+
+	item, err := p.store.GetChecklistItem(itemID)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Checklist item not found", err)
+		return false
+	}
+	return p.checkAuthorization(w, item.TodoID, userID)
+}
+
+// sendTodoRefreshEvents refreshes the lists of userID and of the other user involved in the
+// todo todoID, if any
+func (p *Plugin) sendTodoRefreshEvents(userID, todoID string) {
+	lists := []string{MyListKey, InListKey, OutListKey}
+	p.sendRefreshEvent(userID, lists)
+
+	issue, err := p.store.GetIssue(todoID)
+	if err != nil {
+		return
+	}
+	otherUser := issue.CreatorID
+	if userID == issue.CreatorID {
+		otherUser = issue.AssigneeID
+	}
+	if otherUser != userID {
+		p.sendRefreshEvent(otherUser, lists)
+	}
+}
+
+// writeJSON writes v as the JSON response
+func (p *Plugin) writeJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to marshal response to json", err)
+		return
+	}
+	if _, err = w.Write(b); err != nil {
+		p.API.LogError("Unable to write json response", "err", err.Error())
+	}
+}
+
 func (p *Plugin) checkAuthorization(w http.ResponseWriter, todoID, userID string) bool {
 	authorized, err := p.listManager.IsAuthorized(todoID, userID)
+	return p.writeAuthorization(w, authorized, err)
+}
+
+// checkReadAuthorization is checkAuthorization for the routes reading a todo, which its
+// watchers can use too
+func (p *Plugin) checkReadAuthorization(w http.ResponseWriter, todoID, userID string) bool {
+	authorized, err := p.listManager.IsAuthorizedToRead(todoID, userID)
+	return p.writeAuthorization(w, authorized, err)
+}
+
+func (p *Plugin) writeAuthorization(w http.ResponseWriter, authorized bool, err error) bool {
 	if err != nil {
 		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to check authorization", err)
 		return false
@@ -835,3 +1757,616 @@ func (p *Plugin) handleDeleteComment(w http.ResponseWriter, r *http.Request) {
 
 	w.Write([]byte(`{"status": "OK"}`))
 }
+
+func (p *Plugin) handleGetTags(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	tags, err := p.listManager.GetTags(userID)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get tags", err)
+		return
+	}
+	if tags == nil {
+		tags = []*Tag{}
+	}
+
+	p.writeJSON(w, tags)
+}
+
+func (p *Plugin) handleAddTags(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetTagsPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse tags payload", err)
+		return
+	}
+
+	if err = req.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate tags payload", err)
+		return
+	}
+
+	if !p.checkAuthorization(w, req.TodoID, userID) {
+		return
+	}
+
+	tags, err := p.listManager.TagIssue(userID, req.TodoID, req.Tags)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to add tags", err)
+		return
+	}
+
+	p.sendTodoRefreshEvents(userID, req.TodoID)
+	p.writeJSON(w, tags)
+}
+
+func (p *Plugin) handleRemoveTags(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetTagsPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse tags payload", err)
+		return
+	}
+
+	if err = req.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate tags payload", err)
+		return
+	}
+
+	if !p.checkAuthorization(w, req.TodoID, userID) {
+		return
+	}
+
+	for _, tag := range req.Tags {
+		if err := p.listManager.UntagIssue(userID, req.TodoID, tag); err != nil {
+			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to remove tags", err)
+			return
+		}
+	}
+
+	p.sendTodoRefreshEvents(userID, req.TodoID)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *Plugin) handleAddDependency(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetDependencyPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse dependency payload", err)
+		return
+	}
+
+	if err = req.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate dependency payload", err)
+		return
+	}
+
+	// The user must be involved in both todos, so the blocking todo of another user is one
+	// they sent or received.
+	if !p.checkAuthorization(w, req.TodoID, userID) || !p.checkAuthorization(w, req.BlockedByID, userID) {
+		return
+	}
+
+	err = p.listManager.AddDependency(userID, req.TodoID, req.BlockedByID)
+	if errors.Is(err, ErrInvalidDependency) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to add the dependency", err)
+		return
+	}
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to add the dependency", err)
+		return
+	}
+
+	p.sendTodoRefreshEvents(userID, req.TodoID)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *Plugin) handleRemoveDependency(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetDependencyPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse dependency payload", err)
+		return
+	}
+
+	if err = req.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate dependency payload", err)
+		return
+	}
+
+	if !p.checkAuthorization(w, req.TodoID, userID) {
+		return
+	}
+
+	if err = p.listManager.RemoveDependency(userID, req.TodoID, req.BlockedByID); err != nil {
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to remove the dependency", err)
+		return
+	}
+
+	p.sendTodoRefreshEvents(userID, req.TodoID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// notifyUnblocked sends a DM to the owners of the todos whose last blocker was the resolved
+// todo blockerID
+func (p *Plugin) notifyUnblocked(blockerID string) {
+	issues, err := p.listManager.GetUnblockedIssues(blockerID)
+	if err != nil {
+		p.API.LogError("Unable to get the unblocked todos", "err", err.Error())
+		return
+	}
+
+	for _, issue := range issues {
+		ownerID := issue.OwnerID()
+		list, _ := issue.ListFor(ownerID)
+		p.sendRefreshEvent(ownerID, []string{list})
+
+		message := fmt.Sprintf("Todo %s is no longer blocked: the Todos it depends on are done.", issue.Message)
+		if issue.PostPermalink != "" {
+			message = fmt.Sprintf("%s\n[Permalink](%s)", message, issue.PostPermalink)
+		}
+		p.PostBotDM(ownerID, message)
+	}
+}
+
+func (p *Plugin) handleAutocompleteTags(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	tags, err := p.listManager.GetTags(userID)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get tags", err)
+		return
+	}
+
+	items := make([]model.AutocompleteListItem, 0, len(tags))
+	for _, tag := range tags {
+		items = append(items, model.AutocompleteListItem{
+			Item:     "#" + tag.Name,
+			HelpText: "Your tag " + tag.Name,
+		})
+	}
+
+	p.writeJSON(w, items)
+}
+
+// handleCustomListError writes the response of an error of a custom list change
+func (p *Plugin) handleCustomListError(w http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrNoCustomList):
+		p.handleErrorWithCode(w, http.StatusNotFound, msg, err)
+	case errors.Is(err, ErrInvalidCustomList):
+		p.handleErrorWithCode(w, http.StatusBadRequest, msg, err)
+	default:
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+	}
+}
+
+func (p *Plugin) handleGetCustomLists(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	lists, err := p.listManager.GetCustomLists(userID)
+	if err != nil {
+		p.handleCustomListError(w, "Unable to get the lists", err)
+		return
+	}
+	if lists == nil {
+		lists = []*CustomList{}
+	}
+
+	p.writeJSON(w, lists)
+}
+
+func (p *Plugin) handleCreateCustomList(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetCustomListPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse list payload", err)
+		return
+	}
+
+	list, err := p.listManager.CreateCustomList(userID, req.Name)
+	if err != nil {
+		p.handleCustomListError(w, "Unable to create the list", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID, []string{list.ID})
+	p.writeJSON(w, list)
+}
+
+func (p *Plugin) handleRenameCustomList(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetCustomListPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse list payload", err)
+		return
+	}
+
+	list, err := p.listManager.RenameCustomList(userID, req.ID, req.Name)
+	if err != nil {
+		p.handleCustomListError(w, "Unable to rename the list", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID, []string{list.ID})
+	p.writeJSON(w, list)
+}
+
+func (p *Plugin) handleReorderCustomLists(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetCustomListPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse list payload", err)
+		return
+	}
+
+	if err := p.listManager.ReorderCustomLists(userID, req.IDs); err != nil {
+		p.handleCustomListError(w, "Unable to reorder the lists", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID, req.IDs)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *Plugin) handleDeleteCustomList(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetCustomListPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse list payload", err)
+		return
+	}
+
+	if err := p.listManager.DeleteCustomList(userID, req.ID); err != nil {
+		p.handleCustomListError(w, "Unable to delete the list", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID, []string{MyListKey, req.ID})
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *Plugin) handleMove(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetMovePayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse move payload", err)
+		return
+	}
+
+	if err = req.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate move payload", err)
+		return
+	}
+
+	oldList, err := p.listManager.MoveIssue(userID, req.ID, req.ListID)
+	if err != nil {
+		p.handleCustomListError(w, "Unable to move the Todo", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID, []string{oldList, req.ListID})
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *Plugin) handleReorder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetReorderPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse reorder payload", err)
+		return
+	}
+
+	if err = req.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate reorder payload", err)
+		return
+	}
+
+	list, err := p.listManager.ReorderIssue(userID, req.ID, req.Position)
+	if err != nil {
+		if errors.Is(err, ErrInvalidPosition) {
+			p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to reorder the Todo", err)
+			return
+		}
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to reorder the Todo", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID, []string{list})
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *Plugin) handleGetChannelIssues(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	channelID := r.URL.Query().Get("channel_id")
+	if channelID == "" {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "channel_id is required", nil)
+		return
+	}
+
+	issues, err := p.listManager.GetChannelIssues(userID, channelID)
+	if errors.Is(err, ErrNotChannelMember) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Unable to get the channel todos", err)
+		return
+	}
+	if err != nil {
+		msg := "Unable to get the channel todos"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	p.writeJSON(w, issues)
+}
+
+func (p *Plugin) handleAddChannelIssue(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetChannelIssuePayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse channel todo payload", err)
+		return
+	}
+
+	if err = req.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate channel todo payload", err)
+		return
+	}
+
+	issue, err := p.listManager.AddChannelIssue(userID, req.ChannelID, req.Message, req.Description)
+	if errors.Is(err, ErrNotChannelMember) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Unable to add the channel todo", err)
+		return
+	}
+	if err != nil {
+		msg := "Unable to add the channel todo"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	p.sendChannelRefreshEvent(issue.ChannelID)
+	p.writeJSON(w, issue)
+}
+
+func (p *Plugin) handleCompleteChannelIssue(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	completeRequest, err := GetCompleteIssuePayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse complete channel todo payload", err)
+		return
+	}
+
+	if err = completeRequest.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate complete channel todo payload", err)
+		return
+	}
+
+	if !p.checkAuthorization(w, completeRequest.ID, userID) {
+		return
+	}
+
+	issue, err := p.listManager.CompleteChannelIssue(userID, completeRequest.ID)
+	if errors.Is(err, ErrConflict) {
+		p.handleConflict(w, completeRequest.ID, err)
+		return
+	}
+	if errors.Is(err, ErrInvalidTransition) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to complete the channel todo", err)
+		return
+	}
+	if err != nil {
+		msg := "Unable to complete the channel todo"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	p.sendChannelRefreshEvent(issue.ChannelID)
+	p.notifyUnblocked(issue.ID)
+	p.notifyWatchersCompleted(userID, issue)
+	p.writeJSON(w, issue)
+}
+
+func (p *Plugin) handleTeamBoard(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	teamID := r.URL.Query().Get("team_id")
+	if teamID == "" {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "team_id is required", nil)
+		return
+	}
+
+	board, err := p.listManager.GetTeamBoard(userID, teamID)
+	if errors.Is(err, ErrNotTeamAdmin) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Unable to get the team board", err)
+		return
+	}
+	if err != nil {
+		msg := "Unable to get the team board"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	p.writeJSON(w, board)
+}
+
+func (p *Plugin) handleGetWatchers(w http.ResponseWriter, r *http.Request) {
+	todoID := r.URL.Query().Get("id")
+	if todoID == "" {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Missing id parameter", nil)
+		return
+	}
+
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	if !p.checkReadAuthorization(w, todoID, userID) {
+		return
+	}
+
+	watchers, err := p.listManager.GetWatchers(todoID)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get the watchers", err)
+		return
+	}
+
+	p.writeJSON(w, watchers)
+}
+
+func (p *Plugin) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetWatcherPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse watcher payload", err)
+		return
+	}
+
+	if err = req.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate watcher payload", err)
+		return
+	}
+
+	// Watchers can read the todo, so only the users working on it can add them.
+	if !p.checkAuthorization(w, req.TodoID, userID) {
+		return
+	}
+
+	watcherID := req.UserID
+	if watcherID == "" {
+		watcherID = userID
+	}
+
+	err = p.listManager.Watch(userID, req.TodoID, watcherID)
+	if errors.Is(err, ErrInvalidWatcher) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to add the watcher", err)
+		return
+	}
+	if err != nil {
+		msg := "Unable to add the watcher"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	p.notifyWatcherAdded(userID, req.TodoID, watcherID)
+	p.writeWatchers(w, req.TodoID)
+}
+
+func (p *Plugin) handleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+
+	req, err := GetWatcherPayloadFromJSON(r.Body)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse watcher payload", err)
+		return
+	}
+
+	if err = req.IsValid(); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to validate watcher payload", err)
+		return
+	}
+
+	// Watchers can stop watching a todo, and the users working on it can remove any watcher.
+	watcherID := req.UserID
+	if watcherID == "" || watcherID == userID {
+		watcherID = userID
+		if !p.checkReadAuthorization(w, req.TodoID, userID) {
+			return
+		}
+	} else if !p.checkAuthorization(w, req.TodoID, userID) {
+		return
+	}
+
+	if err = p.listManager.Unwatch(userID, req.TodoID, watcherID); err != nil {
+		msg := "Unable to remove the watcher"
+		p.API.LogError(msg, "err", err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, msg, err)
+		return
+	}
+
+	p.writeWatchers(w, req.TodoID)
+}
+
+// writeWatchers writes the watchers of todoID after a change of its watchers
+func (p *Plugin) writeWatchers(w http.ResponseWriter, todoID string) {
+	watchers, err := p.listManager.GetWatchers(todoID)
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get the watchers", err)
+		return
+	}
+
+	p.writeJSON(w, watchers)
+}
+
+// notifyWatcherAdded lets watcherID know that userID made them a watcher of the todo issueID
+func (p *Plugin) notifyWatcherAdded(userID, issueID, watcherID string) {
+	if watcherID == userID {
+		return
+	}
+	issue, err := p.store.GetIssue(issueID)
+	if err != nil {
+		p.API.LogError("Unable to get the watched todo", "err", err.Error())
+		return
+	}
+
+	userName := p.listManager.GetUserName(userID)
+	message := fmt.Sprintf("@%s added you as a watcher of a Todo: %s", userName, issue.Message)
+	if issue.PostPermalink != "" {
+		message = fmt.Sprintf("%s\n[Permalink](%s)", message, issue.PostPermalink)
+	}
+	p.PostBotDM(watcherID, message)
+}
+
+// notifyWatchers sends message to the watchers of the todo issueID, except to the users of
+// skipIDs
+func (p *Plugin) notifyWatchers(issueID, message string, skipIDs ...string) {
+	watchers, err := p.listManager.GetWatchers(issueID)
+	if err != nil {
+		p.API.LogError("Unable to get the watchers of the todo", "err", err.Error())
+		return
+	}
+
+	for _, watcher := range watchers {
+		if slices.Contains(skipIDs, watcher.UserID) {
+			continue
+		}
+		p.PostBotDM(watcher.UserID, message)
+	}
+}
+
+// notifyWatchersCompleted lets the watchers of issue know that userID completed it
+func (p *Plugin) notifyWatchersCompleted(userID string, issue *Issue) {
+	userName := p.listManager.GetUserName(userID)
+	message := fmt.Sprintf("@%s completed a Todo you watch: %s", userName, issue.Message)
+	if issue.PostPermalink != "" {
+		message = fmt.Sprintf("%s\n[Permalink](%s)", message, issue.PostPermalink)
+	}
+	p.notifyWatchers(issue.ID, message, userID)
+}
+
+// notifyDueDateChanged lets the watchers of the todo issueID know that userID changed its due
+// date to dueAt
+func (p *Plugin) notifyDueDateChanged(userID, issueID, todoMessage string, dueAt int64) {
+	userName := p.listManager.GetUserName(userID)
+	message := fmt.Sprintf("@%s removed the due date of a Todo you watch: %s", userName, todoMessage)
+	if dueAt != 0 {
+		dueDate := time.UnixMilli(dueAt).UTC().Format("January 2, 2006")
+		message = fmt.Sprintf("@%s changed the due date of a Todo you watch to %s: %s", userName, dueDate, todoMessage)
+	}
+	p.notifyWatchers(issueID, message, userID)
+}
@@ -1,11 +1,189 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi/experimental/telemetry"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestServeHTTP(t *testing.T) {
 	assert.True(t, true)
 }
+
+func setupTestPlugin(t *testing.T) (*Plugin, *plugintest.API) {
+	t.Helper()
+
+	api := &plugintest.API{}
+	api.On("GetUser", mock.AnythingOfType("string")).Return(func(userID string) *model.User {
+		return &model.User{Id: userID, Username: "user_" + userID[:4]}
+	}, nil).Maybe()
+	api.On("GetUserByUsername", mock.AnythingOfType("string")).Return(func(username string) *model.User {
+		return &model.User{Id: testOtherID, Username: username}
+	}, nil).Maybe()
+	api.On("PublishWebSocketEvent", WSEventRefresh, mock.Anything, mock.Anything).Maybe()
+	api.On("GetDirectChannel", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&model.Channel{Id: "dm"}, nil).Maybe()
+	api.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{}, nil).Maybe()
+
+	p := &Plugin{}
+	p.SetAPI(api)
+	p.store = NewMemoryStore()
+	p.listManager = NewListManager(api, p.store)
+	p.tracker = telemetry.NewTracker(nil, "", "", "", "", "", telemetry.TrackerConfig{}, nil)
+	p.initializeAPI()
+
+	return p, api
+}
+
+func doTestRequest(t *testing.T, p *Plugin, method, url, userID string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+
+	r := httptest.NewRequest(method, url, bytes.NewReader(payload))
+	if userID != "" {
+		r.Header.Set("Mattermost-User-ID", userID)
+	}
+	w := httptest.NewRecorder()
+	p.ServeHTTP(nil, w, r)
+	return w
+}
+
+func getTestLists(t *testing.T, p *Plugin, userID string) *ListsIssue {
+	t.Helper()
+
+	w := doTestRequest(t, p, http.MethodGet, "/lists", userID, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	lists := &ListsIssue{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), lists))
+	return lists
+}
+
+func TestHandleAddAndLists(t *testing.T) {
+	p, _ := setupTestPlugin(t)
+
+	w := doTestRequest(t, p, http.MethodPost, "/add", "", AddAPIRequest{Message: "be awesome"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doTestRequest(t, p, http.MethodPost, "/add", testUserID, AddAPIRequest{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doTestRequest(t, p, http.MethodPost, "/add", testUserID, AddAPIRequest{Message: "be awesome", Priority: 3})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	lists := getTestLists(t, p, testUserID)
+	require.Len(t, lists.My, 1)
+	assert.Equal(t, "be awesome", lists.My[0].Message)
+	assert.Equal(t, 3, lists.My[0].Priority)
+	assert.Empty(t, lists.In)
+	assert.Empty(t, lists.Out)
+}
+
+func TestHandleSendAndComplete(t *testing.T) {
+	p, api := setupTestPlugin(t)
+
+	w := doTestRequest(t, p, http.MethodPost, "/add", testUserID, AddAPIRequest{Message: "review the PR", SendTo: "other"})
+	require.Equal(t, http.StatusOK, w.Code)
+	api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+		return post.Type == "custom_todo"
+	}))
+
+	lists := getTestLists(t, p, testOtherID)
+	require.Len(t, lists.In, 1)
+	receiverIssueID := lists.In[0].ID
+
+	w = doTestRequest(t, p, http.MethodPost, "/complete", testThirdID, CompleteAPIRequest{ID: receiverIssueID})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doTestRequest(t, p, http.MethodPost, "/complete", testOtherID, CompleteAPIRequest{ID: receiverIssueID})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	assert.Empty(t, getTestLists(t, p, testOtherID).In)
+	assert.Empty(t, getTestLists(t, p, testUserID).Out)
+
+	w = doTestRequest(t, p, http.MethodPost, "/complete", testOtherID, CompleteAPIRequest{ID: receiverIssueID})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "a done todo cannot be completed again")
+}
+
+func TestHandleEditConflict(t *testing.T) {
+	p, _ := setupTestPlugin(t)
+
+	w := doTestRequest(t, p, http.MethodPost, "/add", testUserID, AddAPIRequest{Message: "be awesome"})
+	require.Equal(t, http.StatusOK, w.Code)
+	issue := getTestLists(t, p, testUserID).My[0]
+
+	w = doTestRequest(t, p, http.MethodPut, "/edit", testUserID, EditAPIRequest{ID: issue.ID, Message: "be great"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "the version is required")
+
+	w = doTestRequest(t, p, http.MethodPut, "/edit", testUserID, EditAPIRequest{ID: issue.ID, Message: "be great", Version: issue.Version})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = doTestRequest(t, p, http.MethodPut, "/edit", testUserID, EditAPIRequest{ID: issue.ID, Message: "be amazing", Version: issue.Version})
+	require.Equal(t, http.StatusConflict, w.Code)
+
+	var conflict struct {
+		Issue *Issue `json:"issue"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &conflict))
+	require.NotNil(t, conflict.Issue)
+	assert.Equal(t, "be great", conflict.Issue.Message)
+	assert.Greater(t, conflict.Issue.Version, issue.Version)
+
+	w = doTestRequest(t, p, http.MethodPost, "/change_assignment", testUserID, ChangeAssignmentAPIRequest{ID: issue.ID, SendTo: "other"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "the version is required")
+
+	w = doTestRequest(t, p, http.MethodPost, "/change_assignment", testUserID, ChangeAssignmentAPIRequest{ID: issue.ID, SendTo: "other", Version: issue.Version})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, []string{issue.ID}, issueIDs(getTestLists(t, p, testUserID).My), "a conflicting reassignment is not applied")
+}
+
+func TestHandleUndo(t *testing.T) {
+	p, _ := setupTestPlugin(t)
+
+	for _, message := range []string{"write the docs", "write the tests"} {
+		w := doTestRequest(t, p, http.MethodPost, "/add", testUserID, AddAPIRequest{Message: message})
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	myList := getTestLists(t, p, testUserID).My
+	require.Len(t, myList, 2)
+
+	w := doTestRequest(t, p, http.MethodPost, "/complete", testUserID, CompleteAPIRequest{ID: myList[0].ID})
+	require.Equal(t, http.StatusOK, w.Code)
+	completeLogID, err := p.listManager.GetUndoableAction(testUserID)
+	require.NoError(t, err)
+	w = doTestRequest(t, p, http.MethodPost, "/complete", testUserID, CompleteAPIRequest{ID: myList[1].ID})
+	require.Equal(t, http.StatusOK, w.Code)
+	lastLogID, err := p.listManager.GetUndoableAction(testUserID)
+	require.NoError(t, err)
+
+	w = doTestRequest(t, p, http.MethodPost, "/undo", testUserID, model.PostActionIntegrationRequest{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doTestRequest(t, p, http.MethodPost, "/undo", testUserID, model.PostActionIntegrationRequest{
+		Context: map[string]interface{}{undoContextKey: completeLogID},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "can no longer be undone")
+	assert.Empty(t, getTestLists(t, p, testUserID).My, "an older action does not undo the newest one")
+
+	w = doTestRequest(t, p, http.MethodPost, "/undo", testUserID, model.PostActionIntegrationRequest{
+		Context: map[string]interface{}{undoContextKey: lastLogID},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Undid complete")
+	assert.Equal(t, []string{myList[1].ID}, issueIDs(getTestLists(t, p, testUserID).My))
+}
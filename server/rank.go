@@ -0,0 +1,163 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Todos are sorted in their list by their rank, a string of base 36 digits compared
+// lexicographically. A todo is moved by giving it a rank between the ranks of its new
+// neighbours, so only the moved todo changes. Ranks never end with a 0, so there is always
+// room for a rank between two of them.
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// maxRankLength is the length above which the ranks of a list are spread evenly again
+const maxRankLength = 32
+
+// ErrInvalidPosition is returned when moving a todo that cannot be ordered manually
+var ErrInvalidPosition = errors.New("the todo cannot be moved in its list")
+
+// rankBetween returns a rank sorted after a and before b. An empty a is the start of the
+// list and an empty b its end.
+func rankBetween(a, b string) string {
+	if b != "" {
+		n := 0
+		for n < len(b) && rankDigitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + rankBetween(a[min(n, len(a)):], b[n:])
+		}
+	}
+
+	digitA := strings.IndexByte(rankDigits, rankDigitAt(a, 0))
+	digitB := len(rankDigits)
+	if b != "" {
+		digitB = strings.IndexByte(rankDigits, b[0])
+	}
+	if digitB-digitA > 1 {
+		return string(rankDigits[(digitA+digitB+1)/2])
+	}
+	if len(b) > 1 {
+		return b[:1]
+	}
+	return string(rankDigits[digitA]) + rankBetween(a[min(1, len(a)):], "")
+}
+
+// rankDigitAt returns the digit of rank at i, rank being padded with zeros
+func rankDigitAt(rank string, i int) byte {
+	if i < len(rank) {
+		return rank[i]
+	}
+	return rankDigits[0]
+}
+
+// spreadRanks returns n increasing ranks evenly spread, leaving room to insert todos
+// between them
+func spreadRanks(n int) []string {
+	width, size := 1, int64(len(rankDigits))
+	for size < int64(n+1)*int64(len(rankDigits)) {
+		width++
+		size *= int64(len(rankDigits))
+	}
+
+	ranks := make([]string, n)
+	step := size / int64(n+1)
+	for i := range ranks {
+		rank := strconv.FormatInt(step*int64(i+1), len(rankDigits))
+		rank = strings.Repeat("0", width-len(rank)) + rank
+		ranks[i] = strings.TrimRight(rank, "0")
+	}
+	return ranks
+}
+
+// ReorderIssue moves the open or pending todo issueID of userID to position in its list,
+// counted from 0 at the top
+func (l *listManager) ReorderIssue(userID, issueID string, position int) (list string, err error) {
+	err = l.transaction(func(tx *listManager) error {
+		issue, err := tx.store.GetIssue(issueID)
+		if err != nil {
+			return err
+		}
+		var ok bool
+		list, ok = issue.ListFor(userID)
+		if !ok || list == DoneListKey {
+			return ErrInvalidPosition
+		}
+		return tx.moveInList(userID, list, issueID, position)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return list, nil
+}
+
+// placeAtTop moves issueID to the top of the list of its owner, if it is in an ordered list
+func (l *listManager) placeAtTop(issueID string) error {
+	issue, err := l.store.GetIssue(issueID)
+	if err != nil {
+		return err
+	}
+	list, ok := issue.ListFor(issue.OwnerID())
+	if !ok || list == DoneListKey {
+		return nil
+	}
+	return l.moveInList(issue.OwnerID(), list, issueID, 0)
+}
+
+// moveInList ranks issueID at position in listID of userID. When there is no room between
+// its new neighbours, or some todos of the list have no rank yet, the whole list is ranked
+// again.
+func (l *listManager) moveInList(userID, listID, issueID string, position int) error {
+	refs, err := l.store.GetList(userID, listID)
+	if err != nil {
+		return err
+	}
+	others := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref.IssueID != issueID {
+			others = append(others, ref.IssueID)
+		}
+	}
+	position = max(0, min(position, len(others)))
+
+	var before, after string
+	if position > 0 {
+		if before, err = l.issueRank(others[position-1]); err != nil {
+			return err
+		}
+	}
+	if position < len(others) {
+		if after, err = l.issueRank(others[position]); err != nil {
+			return err
+		}
+	}
+
+	if (position == 0 || before != "") && (position == len(others) || after != "") && (after == "" || before < after) {
+		if rank := rankBetween(before, after); len(rank) <= maxRankLength {
+			return l.store.SetIssueRank(issueID, rank)
+		}
+	}
+
+	order := make([]string, 0, len(others)+1)
+	order = append(order, others[:position]...)
+	order = append(order, issueID)
+	order = append(order, others[position:]...)
+	for i, rank := range spreadRanks(len(order)) {
+		if err := l.store.SetIssueRank(order[i], rank); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *listManager) issueRank(issueID string) (string, error) {
+	issue, err := l.store.GetIssue(issueID)
+	if err != nil {
+		return "", err
+	}
+	return issue.Rank, nil
+}
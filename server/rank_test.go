@@ -0,0 +1,79 @@
+package main
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankBetween(t *testing.T) {
+	for _, tc := range []struct{ a, b string }{
+		{"", ""},
+		{"", "1"},
+		{"", "01"},
+		{"i", ""},
+		{"z", ""},
+		{"a", "b"},
+		{"a", "a1"},
+		{"az", "b"},
+		{"0i", "1"},
+	} {
+		rank := rankBetween(tc.a, tc.b)
+		assert.Less(t, tc.a, rank, tc)
+		if tc.b != "" {
+			assert.Less(t, rank, tc.b, tc)
+		}
+		assert.NotEqual(t, '0', rank[len(rank)-1], tc)
+	}
+
+	ranks := spreadRanks(100)
+	assert.True(t, sort.StringsAreSorted(ranks))
+	assert.NotContains(t, ranks, "")
+}
+
+func TestReorderIssue(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	var ids []string
+	for _, message := range []string{"first", "second", "third"} {
+		issue, err := l.AddIssue(testUserID, message, "", "", "", 0, 0)
+		require.NoError(t, err)
+		ids = append([]string{issue.ID}, ids...)
+	}
+	myList := func() []string {
+		issues, err := l.GetIssueList(testUserID, MyListKey)
+		require.NoError(t, err)
+		return issueIDs(issues)
+	}
+	require.Equal(t, ids, myList(), "new todos are added at the top")
+
+	list, err := l.ReorderIssue(testUserID, ids[0], 2)
+	require.NoError(t, err)
+	assert.Equal(t, MyListKey, list)
+	assert.Equal(t, []string{ids[1], ids[2], ids[0]}, myList())
+
+	_, err = l.ReorderIssue(testUserID, ids[2], 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ids[2], ids[1], ids[0]}, myList())
+
+	for i := 0; i < 200; i++ {
+		moved := myList()[2]
+		_, err = l.ReorderIssue(testUserID, moved, 1)
+		require.NoError(t, err)
+		require.Equal(t, moved, myList()[1])
+	}
+	for _, id := range ids {
+		issue, err := l.store.GetIssue(id)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(issue.Rank), maxRankLength, "the list is ranked again when ranks get too long")
+	}
+
+	_, err = l.ReorderIssue(testOtherID, ids[0], 0)
+	assert.ErrorIs(t, err, ErrInvalidPosition, "only the owner can reorder a todo")
+	_, _, _, err = l.CompleteIssue(testUserID, ids[0])
+	require.NoError(t, err)
+	_, err = l.ReorderIssue(testUserID, ids[0], 0)
+	assert.ErrorIs(t, err, ErrInvalidPosition, "the Done list is not ordered manually")
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+// Recurrence frequencies, as in RFC 5545
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+// recurrenceSearchYears bounds the search for the next occurrence past the interval, which
+// is enough to reach any valid date such as February 29.
+const recurrenceSearchYears = 8
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Recurrence is a recurrence rule of a todo. It supports the subset of RFC 5545 RRULE made
+// of FREQ (DAILY, WEEKLY, MONTHLY or YEARLY), INTERVAL, BYDAY without ordinals, BYMONTHDAY,
+// COUNT and UNTIL. Occurrences keep the time of day of the previous occurrence.
+type Recurrence struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	// Count is the number of occurrences left in the series, including the current one, or
+	// 0 if the series is not limited in number
+	Count int
+	// Until is the last day of the series in the owner's timezone, or the zero time
+	Until time.Time
+	// untilUTC is true if Until is a UTC date-time instead of a date
+	untilUTC bool
+}
+
+// ParseRecurrence parses an RRULE, optionally prefixed with "RRULE:", or one of the shortcuts
+// "daily", "weekdays", "weekly", "weekly <days>" and "monthly", where days are separated by
+// commas, like "weekly mon,thu".
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	rule = strings.TrimSpace(rule)
+	fields := strings.Fields(strings.ToLower(rule))
+	if len(fields) == 0 {
+		return nil, errors.New("the recurrence rule is empty")
+	}
+
+	switch fields[0] {
+	case "daily":
+		return &Recurrence{Freq: FreqDaily, Interval: 1}, nil
+	case "weekdays":
+		return &Recurrence{
+			Freq:     FreqWeekly,
+			Interval: 1,
+			ByDay:    []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		}, nil
+	case "weekly":
+		r := &Recurrence{Freq: FreqWeekly, Interval: 1}
+		if len(fields) > 1 {
+			days, err := parseWeekdays(strings.Join(fields[1:], ","))
+			if err != nil {
+				return nil, err
+			}
+			r.ByDay = days
+		}
+		return r, nil
+	case "monthly":
+		return &Recurrence{Freq: FreqMonthly, Interval: 1}, nil
+	}
+
+	return parseRRule(strings.TrimPrefix(strings.ToUpper(rule), "RRULE:"))
+}
+
+func parseRRule(rule string) (*Recurrence, error) {
+	r := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid recurrence rule part %q", part)
+		}
+
+		var err error
+		switch name {
+		case "FREQ":
+			if value != FreqDaily && value != FreqWeekly && value != FreqMonthly && value != FreqYearly {
+				return nil, errors.Errorf("unsupported recurrence frequency %q", value)
+			}
+			r.Freq = value
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err != nil || r.Interval < 1 {
+				return nil, errors.Errorf("invalid recurrence interval %q", value)
+			}
+		case "BYDAY":
+			r.ByDay, err = parseWeekdays(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseMonthDays(value)
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err != nil || r.Count < 1 {
+				return nil, errors.Errorf("invalid recurrence count %q", value)
+			}
+		case "UNTIL":
+			err = r.parseUntil(value)
+		case "WKST":
+			if value != "MO" {
+				return nil, errors.New("only weeks starting on Monday are supported")
+			}
+		default:
+			return nil, errors.Errorf("unsupported recurrence rule part %q", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, errors.New("the recurrence frequency is required")
+	}
+	if r.Count != 0 && !r.Until.IsZero() {
+		return nil, errors.New("COUNT and UNTIL cannot be both set")
+	}
+	if r.Freq == FreqWeekly && len(r.ByMonthDay) > 0 {
+		return nil, errors.New("BYMONTHDAY cannot be used with a weekly recurrence")
+	}
+	if r.Freq == FreqYearly && (len(r.ByDay) > 0 || len(r.ByMonthDay) > 0) {
+		return nil, errors.New("BYDAY and BYMONTHDAY cannot be used with a yearly recurrence")
+	}
+
+	return r, nil
+}
+
+func parseWeekdays(value string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, code := range strings.Split(value, ",") {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if len(code) < 2 {
+			return nil, errors.Errorf("invalid weekday %q", code)
+		}
+		day, ok := weekdayCodes[code[:2]]
+		if !ok || (len(code) > 2 && !strings.HasPrefix(strings.ToUpper(day.String()), code)) {
+			return nil, errors.Errorf("invalid weekday %q", code)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func parseMonthDays(value string) ([]int, error) {
+	var days []int
+	for _, s := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(s)
+		if err != nil || day == 0 || day < -31 || day > 31 {
+			return nil, errors.Errorf("invalid day of the month %q", s)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func (r *Recurrence) parseUntil(value string) error {
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		r.Until = until
+		r.untilUTC = true
+		return nil
+	}
+
+	until, err := time.Parse("20060102", value)
+	if err != nil {
+		return errors.Errorf("invalid recurrence end %q", value)
+	}
+	r.Until = until
+	return nil
+}
+
+// String returns the rule as an RRULE value
+func (r *Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			days = append(days, strings.ToUpper(day.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.untilUTC {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102T150405Z"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence after previous that is also after notBefore, and the
+// rule left for the series from that occurrence. Times are computed in the location of
+// previous. It returns false if the series has ended.
+func (r *Recurrence) Next(previous, notBefore time.Time) (time.Time, *Recurrence, bool) {
+	next := *r
+	occurrence := previous
+	for {
+		if next.Count == 1 {
+			return time.Time{}, nil, false
+		}
+
+		var ok bool
+		occurrence, ok = next.nextAfter(occurrence)
+		if !ok || next.isPastUntil(occurrence) {
+			return time.Time{}, nil, false
+		}
+		if next.Count > 0 {
+			next.Count--
+		}
+
+		if occurrence.After(notBefore) {
+			return occurrence, &next, true
+		}
+	}
+}
+
+// nextAfter returns the first day after previous matching the rule, at the time of day of
+// previous
+func (r *Recurrence) nextAfter(previous time.Time) (time.Time, bool) {
+	year, month, day := previous.Date()
+	anchor := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	searchDays := (r.Interval + recurrenceSearchYears) * 366
+	for i := 1; i <= searchDays; i++ {
+		candidate := anchor.AddDate(0, 0, i)
+		if r.matches(anchor, candidate) {
+			hour, minute, sec := previous.Clock()
+			return time.Date(candidate.Year(), candidate.Month(), candidate.Day(), hour, minute, sec, 0, previous.Location()), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// matches returns whether the day is an occurrence of the series with an occurrence on
+// anchor. Both are UTC midnights of dates in the owner's timezone.
+func (r *Recurrence) matches(anchor, day time.Time) bool {
+	switch r.Freq {
+	case FreqDaily:
+		if int(day.Sub(anchor).Hours()/24)%r.Interval != 0 {
+			return false
+		}
+	case FreqWeekly:
+		weeks := int(startOfWeek(day).Sub(startOfWeek(anchor)).Hours() / (24 * 7))
+		if weeks%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			return day.Weekday() == anchor.Weekday()
+		}
+	case FreqMonthly:
+		months := (day.Year()-anchor.Year())*12 + int(day.Month()-anchor.Month())
+		if months%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 && day.Day() != anchor.Day() {
+			return false
+		}
+	case FreqYearly:
+		return (day.Year()-anchor.Year())%r.Interval == 0 && day.Month() == anchor.Month() && day.Day() == anchor.Day()
+	}
+
+	if len(r.ByMonthDay) > 0 && !matchesMonthDay(day, r.ByMonthDay) {
+		return false
+	}
+	if len(r.ByDay) > 0 && !containsWeekday(r.ByDay, day.Weekday()) {
+		return false
+	}
+	return true
+}
+
+func (r *Recurrence) isPastUntil(occurrence time.Time) bool {
+	if r.Until.IsZero() {
+		return false
+	}
+	if r.untilUTC {
+		return occurrence.After(r.Until)
+	}
+
+	year, month, day := occurrence.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).After(r.Until)
+}
+
+func startOfWeek(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+func matchesMonthDay(day time.Time, monthDays []int) bool {
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, monthDay := range monthDays {
+		if monthDay == day.Day() || (monthDay < 0 && daysInMonth+monthDay+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRecurrence sets the recurrence rule of issueID, parsed with ParseRecurrence, and returns
+// the updated issue. An empty rule stops the series. As only the open occurrence of a series
+// carries its rule forward, this changes the rule for all the following occurrences.
+func (l *listManager) SetRecurrence(userID, issueID, rule string, version int64) (issue *Issue, err error) {
+	recurrence := ""
+	if strings.TrimSpace(rule) != "" {
+		parsed, err := ParseRecurrence(rule)
+		if err != nil {
+			return nil, err
+		}
+		recurrence = parsed.String()
+	}
+
+	err = l.transaction(func(tx *listManager) error {
+		issue, err = tx.store.GetIssue(issueID)
+		if err != nil {
+			return err
+		}
+		if err = checkVersion(issue, version); err != nil {
+			return err
+		}
+
+		issue.Recurrence = recurrence
+		issue.UpdateAt = model.GetMillis()
+		if err = tx.store.UpdateIssue(issue); err != nil {
+			return err
+		}
+
+		metadata := recurrence
+		if metadata == "" {
+			metadata = "off"
+		}
+		return tx.recordAuditLog(issueID, userID, "set_recurrence", metadata)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
+
+// addNextOccurrence adds the occurrence following the completed issue to the My list of
+// userID, if the issue is recurring and its series has not ended. The next due date is
+// computed in the timezone of userID from the due date of the issue, or from the completion
+// time if it has none, and skips the occurrences already past. Its checklist is copied
+// unchecked and its tags are kept. It returns nil if no occurrence is added.
+func (l *listManager) addNextOccurrence(userID string, issue *Issue) (*Issue, error) {
+	if issue.Recurrence == "" {
+		return nil, nil
+	}
+
+	recurrence, err := ParseRecurrence(issue.Recurrence)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid recurrence rule")
+	}
+
+	location := time.UTC
+	if user, appErr := l.api.GetUser(userID); appErr == nil {
+		location = user.GetTimezoneLocation()
+	}
+
+	completedAt := time.UnixMilli(issue.CompletedAt).In(location)
+	previous := completedAt
+	if issue.DueAt != 0 {
+		previous = time.UnixMilli(issue.DueAt).In(location)
+	}
+
+	dueAt, nextRecurrence, ok := recurrence.Next(previous, completedAt)
+	if !ok {
+		return nil, nil
+	}
+
+	next := newIssue(issue.Message, issue.PostPermalink, issue.Description, issue.PostID, userID, userID, StatusOpen, dueAt.UnixMilli(), issue.Priority)
+	next.AutoComplete = issue.AutoComplete
+	next.Recurrence = nextRecurrence.String()
+	if err := l.store.SaveIssue(next); err != nil {
+		return nil, err
+	}
+	if err := l.store.AddReference(userID, next.ID, MyListKey, "", ""); err != nil {
+		return nil, err
+	}
+	if err := l.placeAtTop(next.ID); err != nil {
+		return nil, err
+	}
+
+	items, err := l.store.GetChecklist(issue.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if err := l.store.SaveChecklistItem(&ChecklistItem{TodoID: next.ID, Message: item.Message, SortOrder: item.SortOrder}); err != nil {
+			return nil, err
+		}
+	}
+
+	tags, err := l.store.GetIssueTags([]string{issue.ID})
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range tags[issue.ID] {
+		tag, err := l.getOrCreateTag(userID, name)
+		if err != nil {
+			return nil, err
+		}
+		if err := l.store.AddIssueTag(next.ID, tag.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := l.recordAuditLog(next.ID, userID, "recur", issue.ID); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	for rule, expected := range map[string]string{
+		"daily":                             "FREQ=DAILY",
+		"weekdays":                          "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		"Weekly mon,thursday":               "FREQ=WEEKLY;BYDAY=MO,TH",
+		"monthly":                           "FREQ=MONTHLY",
+		"RRULE:FREQ=WEEKLY;INTERVAL=2":      "FREQ=WEEKLY;INTERVAL=2",
+		"FREQ=MONTHLY;BYMONTHDAY=-1":        "FREQ=MONTHLY;BYMONTHDAY=-1",
+		"freq=daily;count=3":                "FREQ=DAILY;COUNT=3",
+		"FREQ=DAILY;UNTIL=20240131":         "FREQ=DAILY;UNTIL=20240131",
+		"FREQ=DAILY;UNTIL=20240131T120000Z": "FREQ=DAILY;UNTIL=20240131T120000Z",
+	} {
+		recurrence, err := ParseRecurrence(rule)
+		require.NoError(t, err, rule)
+		assert.Equal(t, expected, recurrence.String(), rule)
+	}
+
+	for _, rule := range []string{
+		"",
+		"hourly",
+		"weekly funday",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240131",
+		"FREQ=MONTHLY;BYDAY=1MO",
+		"INTERVAL=2",
+	} {
+		_, err := ParseRecurrence(rule)
+		assert.Error(t, err, rule)
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 30, 0, 0, location)
+	}
+
+	for _, tc := range []struct {
+		rule     string
+		previous time.Time
+		expected time.Time
+	}{
+		{"daily", at(2024, 3, 9), at(2024, 3, 10)},
+		{"weekdays", at(2024, 3, 8), at(2024, 3, 11)},
+		{"weekly", at(2024, 3, 6), at(2024, 3, 13)},
+		{"weekly mon,thu", at(2024, 3, 11), at(2024, 3, 14)},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", at(2024, 3, 15), at(2024, 3, 25)},
+		{"monthly", at(2024, 1, 31), at(2024, 3, 31)},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", at(2024, 1, 31), at(2024, 2, 29)},
+		{"FREQ=YEARLY", at(2024, 2, 29), at(2028, 2, 29)},
+	} {
+		recurrence, err := ParseRecurrence(tc.rule)
+		require.NoError(t, err, tc.rule)
+
+		next, _, ok := recurrence.Next(tc.previous, tc.previous)
+		require.True(t, ok, tc.rule)
+		assert.Equal(t, tc.expected, next, tc.rule)
+	}
+}
+
+func TestRecurrenceNextSkipsPastOccurrences(t *testing.T) {
+	recurrence, err := ParseRecurrence("FREQ=DAILY;COUNT=5")
+	require.NoError(t, err)
+
+	previous := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	next, rest, ok := recurrence.Next(previous, previous.AddDate(0, 0, 2))
+	require.True(t, ok)
+	assert.Equal(t, previous.AddDate(0, 0, 3), next)
+	assert.Equal(t, "FREQ=DAILY;COUNT=2", rest.String())
+
+	_, _, ok = recurrence.Next(previous, previous.AddDate(0, 0, 4))
+	assert.False(t, ok, "the series ends after its count")
+
+	recurrence, err = ParseRecurrence("FREQ=DAILY;UNTIL=20240102")
+	require.NoError(t, err)
+	_, _, ok = recurrence.Next(previous.AddDate(0, 0, 1), previous.AddDate(0, 0, 1))
+	assert.False(t, ok, "the series ends after its last day")
+}
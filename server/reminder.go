@@ -0,0 +1,86 @@
+package main
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// reminderJobName is the name of the background job sending the daily reminders
+	reminderJobName = "daily_reminder"
+	// reminderCheckInterval is how often the job looks for users due a daily reminder
+	reminderCheckInterval = 15 * time.Minute
+	// reminderHour is the local hour of the day from which the daily reminder is sent
+	reminderHour = 9
+)
+
+// sendDailyReminders sends the daily reminder to every user that is due one.
+func (p *Plugin) sendDailyReminders() error {
+	userIDs, err := p.store.GetUsersToRemind()
+	if err != nil {
+		return err
+	}
+
+	now := time.UnixMilli(model.GetMillis())
+	for _, userID := range userIDs {
+		if err := p.sendDailyReminder(userID, now); err != nil {
+			p.API.LogError("Unable to send daily reminder", "user_id", userID, "err", err.Error())
+		}
+	}
+	return nil
+}
+
+// sendDailyReminder posts the daily reminder to userID if it is past the reminder hour
+// in the user's timezone and the user has not been reminded yet on that day.
+func (p *Plugin) sendDailyReminder(userID string, now time.Time) error {
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		return appErr
+	}
+	if user.IsBot || user.DeleteAt != 0 {
+		return nil
+	}
+
+	timezone := user.GetTimezoneLocation()
+	lastReminderAt, err := p.getLastReminderTimeForUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if !shouldSendReminder(now.In(timezone), time.UnixMilli(lastReminderAt).In(timezone)) {
+		return nil
+	}
+
+	issues, err := p.listManager.GetIssueList(userID, MyListKey)
+	if err != nil {
+		return err
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+
+	// Record the reminder once it is posted, so a reminder that failed is sent again on the
+	// next check.
+	if err := p.postBotDM(userID, "Daily Reminder:\n\n"+issuesListToString(issues)); err != nil {
+		return err
+	}
+	if err := p.saveLastReminderTimeForUser(userID); err != nil {
+		return err
+	}
+	p.trackDailySummary(userID)
+
+	return nil
+}
+
+// shouldSendReminder returns true when now is past the reminder hour on a later day than
+// the last reminder. Both times must be in the user's timezone.
+func shouldSendReminder(now, last time.Time) bool {
+	if now.Hour() < reminderHour {
+		return false
+	}
+	if now.Sub(last).Hours() < 1 {
+		return false
+	}
+	return now.YearDay() != last.YearDay() || now.Year() != last.Year()
+}
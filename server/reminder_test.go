@@ -0,0 +1,142 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldSendReminder(t *testing.T) {
+	utc := time.UTC
+	east := time.FixedZone("UTC+10", 10*60*60)
+	west := time.FixedZone("UTC-8", -8*60*60)
+
+	for name, tc := range map[string]struct {
+		now      time.Time
+		last     time.Time
+		location *time.Location
+		expected bool
+	}{
+		"never reminded": {
+			now:      time.Date(2024, 3, 10, 9, 0, 0, 0, utc),
+			last:     time.UnixMilli(0),
+			location: utc,
+			expected: true,
+		},
+		"before the reminder hour": {
+			now:      time.Date(2024, 3, 10, 8, 59, 0, 0, utc),
+			last:     time.Date(2024, 3, 9, 9, 0, 0, 0, utc),
+			location: utc,
+			expected: false,
+		},
+		"reminded already today": {
+			now:      time.Date(2024, 3, 10, 18, 0, 0, 0, utc),
+			last:     time.Date(2024, 3, 10, 9, 0, 0, 0, utc),
+			location: utc,
+			expected: false,
+		},
+		"reminded yesterday": {
+			now:      time.Date(2024, 3, 10, 9, 0, 0, 0, utc),
+			last:     time.Date(2024, 3, 9, 9, 0, 0, 0, utc),
+			location: utc,
+			expected: true,
+		},
+		"reminded within the last hour": {
+			now:      time.Date(2024, 3, 10, 9, 30, 0, 0, utc),
+			last:     time.Date(2024, 3, 10, 8, 45, 0, 0, utc),
+			location: utc,
+			expected: false,
+		},
+		"year rollover": {
+			now:      time.Date(2025, 1, 1, 9, 0, 0, 0, utc),
+			last:     time.Date(2024, 12, 31, 9, 0, 0, 0, utc),
+			location: utc,
+			expected: true,
+		},
+		"same day of another year": {
+			now:      time.Date(2025, 3, 10, 9, 0, 0, 0, utc),
+			last:     time.Date(2024, 3, 10, 9, 0, 0, 0, utc),
+			location: utc,
+			expected: true,
+		},
+		"ahead of UTC, reminder hour on the next UTC day is already past locally": {
+			// 23:00 UTC is 09:00 on the next day in UTC+10
+			now:      time.Date(2024, 3, 9, 23, 0, 0, 0, utc),
+			last:     time.Date(2024, 3, 8, 23, 0, 0, 0, utc),
+			location: east,
+			expected: true,
+		},
+		"ahead of UTC, before the reminder hour locally": {
+			// 22:00 UTC is 08:00 in UTC+10
+			now:      time.Date(2024, 3, 9, 22, 0, 0, 0, utc),
+			last:     time.Date(2024, 3, 8, 23, 0, 0, 0, utc),
+			location: east,
+			expected: false,
+		},
+		"behind UTC, before the reminder hour locally": {
+			// 16:00 UTC is 08:00 in UTC-8
+			now:      time.Date(2024, 3, 10, 16, 0, 0, 0, utc),
+			last:     time.Date(2024, 3, 9, 17, 0, 0, 0, utc),
+			location: west,
+			expected: false,
+		},
+		"behind UTC, same local day across the UTC day rollover": {
+			// 17:00 UTC and 02:00 UTC on the next day are both on March 9 in UTC-8
+			now:      time.Date(2024, 3, 10, 2, 0, 0, 0, utc),
+			last:     time.Date(2024, 3, 9, 17, 0, 0, 0, utc),
+			location: west,
+			expected: false,
+		},
+		"behind UTC, next local day": {
+			now:      time.Date(2024, 3, 10, 17, 0, 0, 0, utc),
+			last:     time.Date(2024, 3, 9, 17, 0, 0, 0, utc),
+			location: west,
+			expected: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, shouldSendReminder(tc.now.In(tc.location), tc.last.In(tc.location)))
+		})
+	}
+}
+
+func TestSendDailyReminder(t *testing.T) {
+	now := time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC)
+
+	t.Run("a failed DM is sent again on the next check", func(t *testing.T) {
+		api := &plugintest.API{}
+		api.On("GetUser", testUserID).Return(&model.User{Id: testUserID}, nil)
+		api.On("GetDirectChannel", testUserID, mock.AnythingOfType("string")).Return(nil, &model.AppError{Message: "unavailable"})
+		api.On("LogError", mock.Anything, mock.Anything, mock.Anything).Maybe()
+
+		p := &Plugin{}
+		p.SetAPI(api)
+		p.store = NewMemoryStore()
+		p.listManager = NewListManager(api, p.store)
+		_, err := p.listManager.AddIssue(testUserID, "write the tests", "", "", "", 0, 0)
+		require.NoError(t, err)
+
+		require.Error(t, p.sendDailyReminder(testUserID, now))
+		lastReminderAt, err := p.getLastReminderTimeForUser(testUserID)
+		require.NoError(t, err)
+		assert.Zero(t, lastReminderAt)
+		api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("a posted reminder is recorded", func(t *testing.T) {
+		p, api := setupTestPlugin(t)
+		_, err := p.listManager.AddIssue(testUserID, "write the tests", "", "", "", 0, 0)
+		require.NoError(t, err)
+
+		require.NoError(t, p.sendDailyReminder(testUserID, now))
+		api.AssertCalled(t, "CreatePost", mock.AnythingOfType("*model.Post"))
+		lastReminderAt, err := p.getLastReminderTimeForUser(testUserID)
+		require.NoError(t, err)
+		assert.NotZero(t, lastReminderAt)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 
+	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/pkg/errors"
 )
 
@@ -41,6 +42,11 @@ type AddAPIRequest struct {
 	PostID        string `json:"post_id"`
 	DueAt         int64  `json:"due_at"`
 	Priority      int    `json:"priority"`
+	// Assignees are the usernames of the receivers of a todo sent to several users, instead
+	// of SendTo
+	Assignees []string `json:"assignees"`
+	// Completion is CompletionAll or CompletionAny for a todo sent to several users
+	Completion string `json:"completion"`
 }
 
 func GetAddIssuePayloadFromJSON(data io.Reader) (*AddAPIRequest, error) {
@@ -70,6 +76,8 @@ type EditAPIRequest struct {
 	Description string `json:"description"`
 	DueAt       int64  `json:"due_at"`
 	Priority    int    `json:"priority"`
+	// Version is the version of the todo being edited
+	Version int64 `json:"version"`
 }
 
 func GetEditIssuePayloadFromJSON(data io.Reader) (*EditAPIRequest, error) {
@@ -90,12 +98,18 @@ func (e *EditAPIRequest) IsValid() error {
 		return errors.New("id is required")
 	}
 
+	if e.Version == 0 {
+		return errors.New("version is required")
+	}
+
 	return nil
 }
 
 type ChangeAssignmentAPIRequest struct {
 	ID     string `json:"id"`
 	SendTo string `json:"send_to"`
+	// Version is the version of the todo being reassigned
+	Version int64 `json:"version"`
 }
 
 func GetChangeAssignmentPayloadFromJSON(data io.Reader) (*ChangeAssignmentAPIRequest, error) {
@@ -120,6 +134,10 @@ func (c *ChangeAssignmentAPIRequest) IsValid() error {
 		return errors.New("no user specified")
 	}
 
+	if c.Version == 0 {
+		return errors.New("version is required")
+	}
+
 	return nil
 }
 
@@ -148,6 +166,33 @@ func (a *AcceptAPIRequest) IsValid() error {
 	return nil
 }
 
+type DeclineAPIRequest struct {
+	ID string `json:"id"`
+	// Reason is shown to the sender of the todo, and is optional
+	Reason string `json:"reason"`
+}
+
+func GetDeclineRequestPayloadFromJSON(data io.Reader) (*DeclineAPIRequest, error) {
+	body := &DeclineAPIRequest{}
+	if err := json.NewDecoder(data).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (d *DeclineAPIRequest) IsValid() error {
+	if d == nil {
+		return errors.New("invalid request body")
+	}
+
+	if d.ID == "" {
+		return errors.New("id is required")
+	}
+
+	return nil
+}
+
 type CompleteAPIRequest struct {
 	ID string `json:"id"`
 }
@@ -198,6 +243,31 @@ func (r *RemoveAPIRequest) IsValid() error {
 	return nil
 }
 
+type RestoreAPIRequest struct {
+	ID string `json:"id"`
+}
+
+func GetRestoreIssuePayloadFromJSON(data io.Reader) (*RestoreAPIRequest, error) {
+	body := &RestoreAPIRequest{}
+	if err := json.NewDecoder(data).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (r *RestoreAPIRequest) IsValid() error {
+	if r == nil {
+		return errors.New("invalid request body")
+	}
+
+	if r.ID == "" {
+		return errors.New("id is required")
+	}
+
+	return nil
+}
+
 type BumpAPIRequest struct {
 	ID string `json:"id"`
 }
@@ -223,6 +293,24 @@ func (b *BumpAPIRequest) IsValid() error {
 	return nil
 }
 
+type ChecklistAPIRequest struct {
+	ID      string `json:"id"`
+	TodoID  string `json:"todo_id"`
+	Message string `json:"message"`
+	Checked bool   `json:"checked"`
+	// AutoComplete completes the todo when all its checklist items are checked
+	AutoComplete bool `json:"auto_complete"`
+}
+
+func GetChecklistPayloadFromJSON(data io.Reader) (*ChecklistAPIRequest, error) {
+	body := &ChecklistAPIRequest{}
+	if err := json.NewDecoder(data).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
 type CommentAPIRequest struct {
 	ID      string `json:"id"`
 	TodoID  string `json:"todo_id"`
@@ -244,3 +332,277 @@ func (c *CommentAPIRequest) IsValid() error {
 	}
 	return nil
 }
+
+type RecurrenceAPIRequest struct {
+	ID string `json:"id"`
+	// Recurrence is the recurrence rule, or empty to stop the series
+	Recurrence string `json:"recurrence"`
+	// Version is the version of the todo being changed, or 0 to skip the conflict check
+	Version int64 `json:"version"`
+}
+
+func GetRecurrencePayloadFromJSON(data io.Reader) (*RecurrenceAPIRequest, error) {
+	body := &RecurrenceAPIRequest{}
+	if err := json.NewDecoder(data).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (r *RecurrenceAPIRequest) IsValid() error {
+	if r == nil {
+		return errors.New("invalid request body")
+	}
+
+	if r.ID == "" {
+		return errors.New("id is required")
+	}
+
+	if r.Recurrence != "" {
+		if _, err := ParseRecurrence(r.Recurrence); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type StatusAPIRequest struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	// Version is the version of the todo being changed, or 0 to skip the conflict check
+	Version int64 `json:"version"`
+}
+
+func GetStatusPayloadFromJSON(data io.Reader) (*StatusAPIRequest, error) {
+	body := &StatusAPIRequest{}
+	if err := json.NewDecoder(data).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (s *StatusAPIRequest) IsValid() error {
+	if s == nil {
+		return errors.New("invalid request body")
+	}
+
+	if s.ID == "" {
+		return errors.New("id is required")
+	}
+
+	if s.Status != StatusDone && !isActiveStatus(s.Status) {
+		return errors.Errorf("invalid status %q", s.Status)
+	}
+
+	return nil
+}
+
+type DependencyAPIRequest struct {
+	TodoID string `json:"todo_id"`
+	// BlockedByID is the todo that must be done before the todo TodoID
+	BlockedByID string `json:"blocked_by_id"`
+}
+
+func GetDependencyPayloadFromJSON(data io.Reader) (*DependencyAPIRequest, error) {
+	body := &DependencyAPIRequest{}
+	if err := json.NewDecoder(data).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (d *DependencyAPIRequest) IsValid() error {
+	if d == nil {
+		return errors.New("invalid request body")
+	}
+
+	if d.TodoID == "" {
+		return errors.New("todo_id is required")
+	}
+
+	if d.BlockedByID == "" {
+		return errors.New("blocked_by_id is required")
+	}
+
+	return nil
+}
+
+type WatcherAPIRequest struct {
+	TodoID string `json:"todo_id"`
+	// UserID is the watcher, the user making the request if empty
+	UserID string `json:"user_id"`
+}
+
+func GetWatcherPayloadFromJSON(data io.Reader) (*WatcherAPIRequest, error) {
+	body := &WatcherAPIRequest{}
+	if err := json.NewDecoder(data).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (w *WatcherAPIRequest) IsValid() error {
+	if w == nil {
+		return errors.New("invalid request body")
+	}
+
+	if w.TodoID == "" {
+		return errors.New("todo_id is required")
+	}
+
+	if w.UserID != "" && !model.IsValidId(w.UserID) {
+		return errors.New("user_id is not valid")
+	}
+
+	return nil
+}
+
+type TagsAPIRequest struct {
+	TodoID string   `json:"todo_id"`
+	Tags   []string `json:"tags"`
+}
+
+func GetTagsPayloadFromJSON(data io.Reader) (*TagsAPIRequest, error) {
+	body := &TagsAPIRequest{}
+	if err := json.NewDecoder(data).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (t *TagsAPIRequest) IsValid() error {
+	if t == nil {
+		return errors.New("invalid request body")
+	}
+
+	if t.TodoID == "" {
+		return errors.New("todo_id is required")
+	}
+
+	if len(t.Tags) == 0 {
+		return errors.New("tags are required")
+	}
+
+	for _, tag := range t.Tags {
+		if !isValidTagName(normalizeTagName(tag)) {
+			return errors.Errorf("invalid tag %q", tag)
+		}
+	}
+
+	return nil
+}
+
+type CustomListAPIRequest struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// IDs are the IDs of all the custom lists of the user in their new order, when reordering
+	IDs []string `json:"ids"`
+}
+
+func GetCustomListPayloadFromJSON(data io.Reader) (*CustomListAPIRequest, error) {
+	body := &CustomListAPIRequest{}
+	if err := json.NewDecoder(data).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+type MoveAPIRequest struct {
+	ID string `json:"id"`
+	// ListID is the custom list to move the todo to, or empty for the My list
+	ListID string `json:"list_id"`
+}
+
+func GetMovePayloadFromJSON(data io.Reader) (*MoveAPIRequest, error) {
+	body := &MoveAPIRequest{}
+	if err := json.NewDecoder(data).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (m *MoveAPIRequest) IsValid() error {
+	if m == nil {
+		return errors.New("invalid request body")
+	}
+
+	if m.ID == "" {
+		return errors.New("id is required")
+	}
+
+	if isBuiltInList(m.ListID) && m.ListID != MyListKey {
+		return errors.New("todos can only be moved to the My list or a custom list")
+	}
+
+	return nil
+}
+
+type ReorderAPIRequest struct {
+	ID string `json:"id"`
+	// Position is the new position of the todo in its list, 0 being the top
+	Position int `json:"position"`
+}
+
+func GetReorderPayloadFromJSON(data io.Reader) (*ReorderAPIRequest, error) {
+	body := &ReorderAPIRequest{}
+	if err := json.NewDecoder(data).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (r *ReorderAPIRequest) IsValid() error {
+	if r == nil {
+		return errors.New("invalid request body")
+	}
+
+	if r.ID == "" {
+		return errors.New("id is required")
+	}
+
+	if r.Position < 0 {
+		return errors.New("position must not be negative")
+	}
+
+	return nil
+}
+
+type ChannelIssueAPIRequest struct {
+	ChannelID   string `json:"channel_id"`
+	Message     string `json:"message"`
+	Description string `json:"description"`
+}
+
+func GetChannelIssuePayloadFromJSON(data io.Reader) (*ChannelIssueAPIRequest, error) {
+	body := &ChannelIssueAPIRequest{}
+	if err := json.NewDecoder(data).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (c *ChannelIssueAPIRequest) IsValid() error {
+	if c == nil {
+		return errors.New("invalid request body")
+	}
+
+	if c.ChannelID == "" {
+		return errors.New("channel_id is required")
+	}
+
+	if c.Message == "" {
+		return errors.New("message is required")
+	}
+
+	return nil
+}
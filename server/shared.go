@@ -0,0 +1,341 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+// assigneesLimit is the maximum number of assignees of a shared todo
+const assigneesLimit = 10
+
+// ErrInvalidAssignees is wrapped by the errors of shared todos that cannot be sent or
+// reassigned
+var ErrInvalidAssignees = errors.New("invalid assignees")
+
+// Assignee is the progress of an assignee of a shared todo on their copy of it
+type Assignee struct {
+	UserID      string `json:"user_id"`
+	Username    string `json:"username"`
+	IssueID     string `json:"issue_id"`
+	Status      string `json:"status"`
+	CompletedAt int64  `json:"completed_at"`
+	// Removed is set when the assignee declined or removed the todo
+	Removed bool `json:"removed"`
+}
+
+// isUnfinishedCopy returns true if the copy of a sent todo still has to be completed
+func isUnfinishedCopy(issue *Issue) bool {
+	return issue.DeletedAt == 0 && (issue.Status == StatusPending || isActiveStatus(issue.Status))
+}
+
+// SendSharedIssue sends the todo from senderID to each of receiverIDs, who accept and
+// complete their own copy of it. With CompletionAll the todo is done for its sender once all
+// the assignees completed it, and with CompletionAny once one of them did. It returns the
+// IDs of the receivers' copies, by receiver.
+func (l *listManager) SendSharedIssue(senderID string, receiverIDs []string, completion, message, postPermalink, description, postID string, dueAt int64, priority int) (map[string]string, error) {
+	if completion == "" {
+		completion = CompletionAll
+	}
+	if completion != CompletionAll && completion != CompletionAny {
+		return nil, fmt.Errorf("%w: unknown completion %q", ErrInvalidAssignees, completion)
+	}
+
+	var receivers []string
+	for _, receiverID := range receiverIDs {
+		if receiverID == senderID {
+			return nil, fmt.Errorf("%w: a todo cannot be shared with its sender", ErrInvalidAssignees)
+		}
+		if !slices.Contains(receivers, receiverID) {
+			receivers = append(receivers, receiverID)
+		}
+	}
+	if len(receivers) < 2 {
+		return nil, fmt.Errorf("%w: a shared todo has at least two assignees", ErrInvalidAssignees)
+	}
+	if len(receivers) > assigneesLimit {
+		return nil, fmt.Errorf("%w: a todo cannot be shared with more than %d users", ErrInvalidAssignees, assigneesLimit)
+	}
+
+	message = SanitizeInput(message)
+	description = SanitizeMultiline(description)
+	senderIssue := newIssue(message, postPermalink, description, postID, senderID, receivers[0], StatusPending, dueAt, priority)
+	senderIssue.Completion = completion
+
+	var receiverIssueIDs map[string]string
+	err := l.transaction(func(tx *listManager) error {
+		receiverIssueIDs = map[string]string{}
+		if err := tx.store.SaveIssue(senderIssue); err != nil {
+			return err
+		}
+
+		for _, receiverID := range receivers {
+			receiverIssue := newIssue(message, postPermalink, description, postID, senderID, receiverID, StatusPending, dueAt, priority)
+			receiverIssue.Completion = completion
+			if err := tx.store.SaveIssue(receiverIssue); err != nil {
+				return err
+			}
+			if err := tx.store.AddReference(receiverID, receiverIssue.ID, InListKey, senderID, senderIssue.ID); err != nil {
+				return err
+			}
+			if err := tx.placeAtTop(receiverIssue.ID); err != nil {
+				return err
+			}
+			if err := tx.recordAuditLog(receiverIssue.ID, receiverID, "receive", senderID); err != nil {
+				return err
+			}
+			receiverIssueIDs[receiverID] = receiverIssue.ID
+		}
+
+		// The sender's copy is linked to the copy of the first assignee, like a todo sent to
+		// a single user, and finds the other copies through GetLinkedIssues.
+		if err := tx.store.AddReference(senderID, senderIssue.ID, OutListKey, receivers[0], receiverIssueIDs[receivers[0]]); err != nil {
+			return err
+		}
+		if err := tx.placeAtTop(senderIssue.ID); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(senderIssue.ID, senderID, "send", strings.Join(receivers, ","))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return receiverIssueIDs, nil
+}
+
+// GetAssignees returns the progress of each assignee of the shared todo issueID, which is
+// any copy of the todo. It returns nil if the todo is not shared.
+func (l *listManager) GetAssignees(issueID string) ([]*Assignee, error) {
+	issue, err := l.store.GetIssue(issueID)
+	if err != nil {
+		return nil, err
+	}
+	if !issue.IsShared() {
+		return nil, nil
+	}
+
+	senderIssueID := issue.ForeignIssueID
+	if issue.IsSenderCopy() {
+		senderIssueID = issue.ID
+	}
+	copies, err := l.store.GetLinkedIssues(senderIssueID)
+	if err != nil {
+		return nil, err
+	}
+
+	assignees := make([]*Assignee, 0, len(copies))
+	for _, c := range copies {
+		assignees = append(assignees, &Assignee{
+			UserID:      c.AssigneeID,
+			Username:    l.GetUserName(c.AssigneeID),
+			IssueID:     c.ID,
+			Status:      c.Status,
+			CompletedAt: c.CompletedAt,
+			Removed:     c.DeletedAt != 0 || c.Status == StatusDeclined,
+		})
+	}
+	return assignees, nil
+}
+
+// addAssignees sets the progress of the assignees of the shared todos of issues sent by the
+// user
+func (l *listManager) addAssignees(issues []*ExtendedIssue) error {
+	for _, issue := range issues {
+		if !issue.IsShared() || !issue.IsSenderCopy() {
+			continue
+		}
+		assignees, err := l.GetAssignees(issue.ID)
+		if err != nil {
+			return err
+		}
+		issue.Assignees = assignees
+	}
+	return nil
+}
+
+// otherAssigneeCopies returns the unfinished copies of the assignees of the todo sent as
+// senderIssueID, other than the copy skipID. It returns nil if the todo is not shared.
+func (l *listManager) otherAssigneeCopies(senderIssueID, skipID string) ([]*Issue, error) {
+	senderIssue, err := l.store.GetIssue(senderIssueID)
+	if err != nil || !senderIssue.IsShared() {
+		return nil, err
+	}
+
+	copies, err := l.store.GetLinkedIssues(senderIssueID)
+	if err != nil {
+		return nil, err
+	}
+	var others []*Issue
+	for _, c := range copies {
+		if c.ID != skipID && isUnfinishedCopy(c) {
+			others = append(others, c)
+		}
+	}
+	return others, nil
+}
+
+// linkedCopies returns the copies of the other parties of the sent todo issue: the copy of
+// the other party, or the sender's copy and the copies of the other assignees of a shared
+// todo. Copies that no longer exist are skipped.
+func (l *listManager) linkedCopies(issue *Issue) ([]*Issue, error) {
+	if issue.ForeignIssueID == "" {
+		return nil, nil
+	}
+	if !issue.IsShared() {
+		return l.snapshotIssues(issue.ForeignIssueID), nil
+	}
+
+	senderIssueID := issue.ID
+	var copies []*Issue
+	if !issue.IsSenderCopy() {
+		senderIssueID = issue.ForeignIssueID
+		copies = l.snapshotIssues(senderIssueID)
+	}
+
+	linked, err := l.store.GetLinkedIssues(senderIssueID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range linked {
+		if c.ID != issue.ID {
+			copies = append(copies, c)
+		}
+	}
+	return copies, nil
+}
+
+// completeSharedIssue completes the copies of the shared todo issue, which was just completed
+// by one of its parties. The todo is done for everyone when its sender completes it, or when
+// any assignee does with CompletionAny. With CompletionAll, only the sender's copy is done,
+// once no assignee has the todo left to complete.
+func (l *listManager) completeSharedIssue(issue *Issue, copies []*Issue) error {
+	if issue.IsSenderCopy() || issue.Completion == CompletionAny {
+		for _, c := range copies {
+			if !isUnfinishedCopy(c) {
+				continue
+			}
+			if err := l.completeCopy(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return l.finishSharedIssue(append(copies, issue))
+}
+
+// finishSharedIssue completes the sender's copy among copies, all the copies of a shared
+// todo, once an assignee completed the todo and none has it left to complete
+func (l *listManager) finishSharedIssue(copies []*Issue) error {
+	var senderIssue *Issue
+	completed := false
+	for _, c := range copies {
+		switch {
+		case c.IsSenderCopy():
+			senderIssue = c
+		case isUnfinishedCopy(c):
+			return nil
+		case c.Status == StatusDone && c.DeletedAt == 0:
+			completed = true
+		}
+	}
+
+	if senderIssue == nil || !completed || !isUnfinishedCopy(senderIssue) {
+		return nil
+	}
+	return l.completeCopy(senderIssue)
+}
+
+// completeCopy completes a copy of a sent todo for its owner
+func (l *listManager) completeCopy(issue *Issue) error {
+	if issue.IsSenderCopy() {
+		if err := l.store.RemoveReference(issue.CreatorID, issue.ID, OutListKey); err != nil {
+			return err
+		}
+	}
+	_, err := l.setIssueStatus(issue.ID, StatusDone)
+	return err
+}
+
+// declineSharedIssue updates the sender's copy among copies of the shared todo issue, just
+// declined by one of its assignees. The todo is done for its sender when the assignees left
+// completed it, and declined when every assignee declined or removed it.
+func (l *listManager) declineSharedIssue(issue *Issue, copies []*Issue, reason string) error {
+	if err := l.finishSharedIssue(append(copies, issue)); err != nil {
+		return err
+	}
+
+	var senderIssue *Issue
+	for _, c := range copies {
+		if c.IsSenderCopy() {
+			senderIssue = c
+			continue
+		}
+		if isUnfinishedCopy(c) || (c.Status == StatusDone && c.DeletedAt == 0) {
+			return nil
+		}
+	}
+	if senderIssue == nil || !isUnfinishedCopy(senderIssue) {
+		return nil
+	}
+
+	if _, err := l.setIssueStatus(senderIssue.ID, StatusDeclined); err != nil {
+		return err
+	}
+	return l.recordAuditLog(senderIssue.ID, issue.AssigneeID, "decline", reason)
+}
+
+// restoreSharedIssue restores the copies of the shared todo issue, which is being restored
+// from the trash. Restoring the sender's copy restores the copies of the assignees trashed
+// with it, and restoring the copy of an assignee makes them an assignee again if the todo is
+// still sent. It returns the sender and their list when the copy of an assignee is restored.
+func (l *listManager) restoreSharedIssue(issue *Issue) (senderID string, senderList string, err error) {
+	if !issue.IsSenderCopy() {
+		senderIssue, err := l.store.GetIssue(issue.ForeignIssueID)
+		if err != nil || senderIssue.DeletedAt != 0 || !isUnfinishedCopy(senderIssue) {
+			detachIssue(issue)
+			return "", "", nil
+		}
+		senderList, _ = senderIssue.ListFor(senderIssue.CreatorID)
+		return senderIssue.CreatorID, senderList, nil
+	}
+
+	copies, err := l.store.GetLinkedIssues(issue.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	restored := false
+	for _, c := range copies {
+		// The copies declined before the todo was removed stay in the trash.
+		if c.DeletedAt < issue.DeletedAt {
+			continue
+		}
+		c.DeletedAt = 0
+		c.UpdateAt = model.GetMillis()
+		if err := l.store.UpdateIssue(c); err != nil {
+			return "", "", err
+		}
+		restored = true
+	}
+	if !restored {
+		detachIssue(issue)
+	}
+	return "", "", nil
+}
+
+// detachIssue makes the copy of a sent todo whose other copies no longer exist a todo of its
+// owner only
+func detachIssue(issue *Issue) {
+	issue.AssigneeID = issue.OwnerID()
+	issue.ForeignIssueID = ""
+	issue.ForeignUserID = ""
+	issue.Completion = ""
+	if issue.Status == StatusPending {
+		issue.Status = StatusOpen
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendSharedIssue(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	_, err := l.SendSharedIssue(testUserID, []string{testOtherID}, CompletionAll, "review the PR", "", "", "", 0, 0)
+	assert.ErrorIs(t, err, ErrInvalidAssignees)
+	_, err = l.SendSharedIssue(testUserID, []string{testOtherID, testUserID}, CompletionAll, "review the PR", "", "", "", 0, 0)
+	assert.ErrorIs(t, err, ErrInvalidAssignees)
+
+	t.Run("all assignees complete", func(t *testing.T) {
+		ids, err := l.SendSharedIssue(testUserID, []string{testOtherID, testThirdID}, CompletionAll, "review the PR", "", "", "", 0, 0)
+		require.NoError(t, err)
+		require.Len(t, ids, 2)
+
+		_, _, err = l.AcceptIssue(testOtherID, ids[testOtherID])
+		require.NoError(t, err)
+		_, _, _, err = l.CompleteIssue(testOtherID, ids[testOtherID])
+		require.NoError(t, err)
+
+		out, err := l.GetIssueList(testUserID, OutListKey)
+		require.NoError(t, err)
+		require.Len(t, out, 1, "the todo is sent until all the assignees complete it")
+		require.Len(t, out[0].Assignees, 2)
+		statuses := map[string]string{}
+		for _, assignee := range out[0].Assignees {
+			statuses[assignee.UserID] = assignee.Status
+		}
+		assert.Equal(t, map[string]string{testOtherID: StatusDone, testThirdID: StatusPending}, statuses)
+
+		_, _, err = l.ChangeAssignment(out[0].ID, testUserID, testOtherID, 0)
+		assert.ErrorIs(t, err, ErrInvalidAssignees)
+
+		_, _, _, err = l.CompleteIssue(testThirdID, ids[testThirdID])
+		require.NoError(t, err)
+		out, err = l.GetIssueList(testUserID, OutListKey)
+		require.NoError(t, err)
+		assert.Empty(t, out)
+	})
+
+	t.Run("any assignee completes", func(t *testing.T) {
+		ids, err := l.SendSharedIssue(testUserID, []string{testOtherID, testThirdID}, CompletionAny, "deploy the fix", "", "", "", 0, 0)
+		require.NoError(t, err)
+
+		_, _, _, err = l.CompleteIssue(testThirdID, ids[testThirdID])
+		require.NoError(t, err)
+
+		out, err := l.GetIssueList(testUserID, OutListKey)
+		require.NoError(t, err)
+		assert.Empty(t, out)
+		other, err := l.store.GetIssue(ids[testOtherID])
+		require.NoError(t, err)
+		assert.Equal(t, StatusDone, other.Status, "the todo is done for every assignee")
+	})
+
+	t.Run("assignees decline", func(t *testing.T) {
+		ids, err := l.SendSharedIssue(testUserID, []string{testOtherID, testThirdID}, CompletionAll, "write the docs", "", "", "", 0, 0)
+		require.NoError(t, err)
+
+		_, foreignID, isSender, _, err := l.RemoveIssue(testThirdID, ids[testThirdID])
+		require.NoError(t, err)
+		assert.Equal(t, testUserID, foreignID)
+		assert.True(t, isSender)
+		in, err := l.GetIssueList(testOtherID, InListKey)
+		require.NoError(t, err)
+		assert.Len(t, in, 1, "declining only removes the copy of the assignee")
+
+		_, _, _, err = l.CompleteIssue(testOtherID, ids[testOtherID])
+		require.NoError(t, err)
+		out, err := l.GetIssueList(testUserID, OutListKey)
+		require.NoError(t, err)
+		assert.Empty(t, out, "the assignees who declined have nothing to complete")
+	})
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// sqlDialect generates the SQL that differs between the supported database drivers.
+// Queries are written with ? placeholders and rebound for the driver.
+type sqlDialect interface {
+	// Rebind replaces the ? placeholders of query with the driver's bind variables
+	Rebind(query string) string
+	// Upsert builds an insert of columns into table that updates updateColumns instead
+	// when a row with the same conflictColumns already exists
+	Upsert(table string, conflictColumns, columns, updateColumns []string) string
+	// CreateIndex builds a statement creating the index name on table. The where clause makes
+	// a partial index on drivers supporting them, and indexes every row otherwise.
+	CreateIndex(name, table string, columns []string, where string) string
+	// Limit builds the clause returning limit rows after skipping offset rows
+	Limit(limit, offset int) string
+	// CreateTextIndex builds a statement creating the full-text index name on columns of table
+	CreateTextIndex(name, table string, columns []string) string
+	// MatchText builds a condition matching the rows whose columns contain the words bound
+	// to its single placeholder, using the index created by CreateTextIndex
+	MatchText(columns []string) string
+	// AppliedQuery builds a query counting the columns or indexes that the migration statement
+	// creates and that exist already, or returns an empty query if the statement can always
+	// be run
+	AppliedQuery(statement string) (query string, args []interface{})
+}
+
+func newSQLDialect(driverName string) (sqlDialect, error) {
+	switch driverName {
+	case model.DatabaseDriverPostgres:
+		return postgresDialect{}, nil
+	case model.DatabaseDriverMysql:
+		return mysqlDialect{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driverName)
+	}
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Rebind(query string) string {
+	var b strings.Builder
+	n := 1
+	for _, r := range query {
+		if r == '?' {
+			fmt.Fprintf(&b, "$%d", n)
+			n++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (postgresDialect) Upsert(table string, conflictColumns, columns, updateColumns []string) string {
+	updates := make([]string, 0, len(updateColumns))
+	for _, column := range updateColumns {
+		updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+	}
+	return fmt.Sprintf("%s ON CONFLICT (%s) DO UPDATE SET %s",
+		insertStatement(table, columns), strings.Join(conflictColumns, ", "), strings.Join(updates, ", "))
+}
+
+func (postgresDialect) CreateIndex(name, table string, columns []string, where string) string {
+	query := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", name, table, strings.Join(columns, ", "))
+	if where != "" {
+		query += " WHERE " + where
+	}
+	return query
+}
+
+func (postgresDialect) Limit(limit, offset int) string {
+	return limitClause(limit, offset)
+}
+
+func (postgresDialect) CreateTextIndex(name, table string, columns []string) string {
+	return fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING GIN (%s)", name, table, textVector(columns))
+}
+
+func (postgresDialect) MatchText(columns []string) string {
+	return textVector(columns) + " @@ plainto_tsquery('simple', ?)"
+}
+
+// AppliedQuery returns an empty query, as Postgres rolls back the statements of a failed
+// migration.
+func (postgresDialect) AppliedQuery(string) (string, []interface{}) {
+	return "", nil
+}
+
+// textVector returns the text search vector of columns. The conditions must use the same
+// expression as the index for Postgres to use it.
+func textVector(columns []string) string {
+	values := make([]string, 0, len(columns))
+	for _, column := range columns {
+		values = append(values, fmt.Sprintf("COALESCE(%s, '')", column))
+	}
+	return fmt.Sprintf("to_tsvector('simple', %s)", strings.Join(values, " || ' ' || "))
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Rebind(query string) string {
+	return query
+}
+
+func (mysqlDialect) Upsert(table string, _, columns, updateColumns []string) string {
+	updates := make([]string, 0, len(updateColumns))
+	for _, column := range updateColumns {
+		updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", column, column))
+	}
+	return fmt.Sprintf("%s ON DUPLICATE KEY UPDATE %s", insertStatement(table, columns), strings.Join(updates, ", "))
+}
+
+// CreateIndex ignores the where clause, as MySQL has neither partial indexes nor
+// CREATE INDEX IF NOT EXISTS.
+func (mysqlDialect) CreateIndex(name, table string, columns []string, _ string) string {
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", name, table, strings.Join(columns, ", "))
+}
+
+func (mysqlDialect) Limit(limit, offset int) string {
+	return limitClause(limit, offset)
+}
+
+func (mysqlDialect) CreateTextIndex(name, table string, columns []string) string {
+	return fmt.Sprintf("CREATE FULLTEXT INDEX %s ON %s (%s)", name, table, strings.Join(columns, ", "))
+}
+
+func (mysqlDialect) MatchText(columns []string) string {
+	return fmt.Sprintf("MATCH (%s) AGAINST (? IN NATURAL LANGUAGE MODE)", strings.Join(columns, ", "))
+}
+
+var (
+	addColumnRegexp   = regexp.MustCompile(`^\s*ALTER TABLE (\w+) ADD COLUMN (\w+)`)
+	createIndexRegexp = regexp.MustCompile(`^\s*CREATE (?:FULLTEXT )?INDEX (\w+) ON (\w+)`)
+)
+
+// AppliedQuery looks up the columns and indexes in the information schema, as MySQL commits
+// each DDL statement implicitly: a failed migration may have added some of them already, and
+// cannot add them again when it is retried.
+func (mysqlDialect) AppliedQuery(statement string) (string, []interface{}) {
+	if match := addColumnRegexp.FindStringSubmatch(statement); match != nil {
+		return "SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?",
+			[]interface{}{match[1], match[2]}
+	}
+	if match := createIndexRegexp.FindStringSubmatch(statement); match != nil {
+		return "SELECT COUNT(*) FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME = ?",
+			[]interface{}{match[2], match[1]}
+	}
+	return "", nil
+}
+
+func insertStatement(table string, columns []string) string {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), placeholders(len(columns)))
+}
+
+// placeholders returns a list of n ? placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func limitClause(limit, offset int) string {
+	if offset > 0 {
+		return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+	}
+	return fmt.Sprintf("LIMIT %d", limit)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSQLDialect(t *testing.T) {
+	d, err := newSQLDialect(model.DatabaseDriverPostgres)
+	require.NoError(t, err)
+	assert.IsType(t, postgresDialect{}, d)
+
+	d, err = newSQLDialect(model.DatabaseDriverMysql)
+	require.NoError(t, err)
+	assert.IsType(t, mysqlDialect{}, d)
+
+	_, err = newSQLDialect("sqlite3")
+	assert.Error(t, err)
+}
+
+func TestSQLDialects(t *testing.T) {
+	tests := []struct {
+		name        string
+		dialect     sqlDialect
+		rebind      string
+		upsert      string
+		index       string
+		partial     string
+		limit       string
+		limitOffset string
+		textIndex   string
+		matchText   string
+	}{
+		{
+			name:        "postgres",
+			dialect:     postgresDialect{},
+			rebind:      "SELECT id FROM todos WHERE assignee_id = $1 AND status = $2",
+			upsert:      "INSERT INTO todo_preferences (user_id, reminder_enabled) VALUES (?, ?) ON CONFLICT (user_id) DO UPDATE SET reminder_enabled = EXCLUDED.reminder_enabled",
+			index:       "CREATE INDEX IF NOT EXISTS idx_todos_assignee ON todos (assignee_id, status)",
+			partial:     "CREATE INDEX IF NOT EXISTS idx_todos_due_at ON todos (due_at) WHERE due_at > 0",
+			limit:       "LIMIT 10",
+			limitOffset: "LIMIT 10 OFFSET 20",
+			textIndex:   "CREATE INDEX IF NOT EXISTS idx_todos_text ON todos USING GIN (to_tsvector('simple', COALESCE(message, '') || ' ' || COALESCE(description, '')))",
+			matchText:   "to_tsvector('simple', COALESCE(message, '') || ' ' || COALESCE(description, '')) @@ plainto_tsquery('simple', ?)",
+		},
+		{
+			name:        "mysql",
+			dialect:     mysqlDialect{},
+			rebind:      "SELECT id FROM todos WHERE assignee_id = ? AND status = ?",
+			upsert:      "INSERT INTO todo_preferences (user_id, reminder_enabled) VALUES (?, ?) ON DUPLICATE KEY UPDATE reminder_enabled = VALUES(reminder_enabled)",
+			index:       "CREATE INDEX idx_todos_assignee ON todos (assignee_id, status)",
+			partial:     "CREATE INDEX idx_todos_due_at ON todos (due_at)",
+			limit:       "LIMIT 10",
+			limitOffset: "LIMIT 10 OFFSET 20",
+			textIndex:   "CREATE FULLTEXT INDEX idx_todos_text ON todos (message, description)",
+			matchText:   "MATCH (message, description) AGAINST (? IN NATURAL LANGUAGE MODE)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.rebind, tt.dialect.Rebind("SELECT id FROM todos WHERE assignee_id = ? AND status = ?"))
+			assert.Equal(t, tt.upsert, tt.dialect.Upsert("todo_preferences", []string{"user_id"}, []string{"user_id", "reminder_enabled"}, []string{"reminder_enabled"}))
+			assert.Equal(t, tt.index, tt.dialect.CreateIndex("idx_todos_assignee", "todos", []string{"assignee_id", "status"}, ""))
+			assert.Equal(t, tt.partial, tt.dialect.CreateIndex("idx_todos_due_at", "todos", []string{"due_at"}, "due_at > 0"))
+			assert.Equal(t, tt.limit, tt.dialect.Limit(10, 0))
+			assert.Equal(t, tt.limitOffset, tt.dialect.Limit(10, 20))
+			assert.Equal(t, tt.textIndex, tt.dialect.CreateTextIndex("idx_todos_text", "todos", []string{"message", "description"}))
+			assert.Equal(t, tt.matchText, tt.dialect.MatchText([]string{"message", "description"}))
+		})
+	}
+}
+
+func TestAppliedQuery(t *testing.T) {
+	query, _ := postgresDialect{}.AppliedQuery("ALTER TABLE todos ADD COLUMN channel_id VARCHAR(26) NOT NULL DEFAULT ''")
+	assert.Empty(t, query, "Postgres rolls back failed migrations")
+
+	d := mysqlDialect{}
+	query, args := d.AppliedQuery("ALTER TABLE todos ADD COLUMN channel_id VARCHAR(26) NOT NULL DEFAULT ''")
+	assert.Contains(t, query, "information_schema.COLUMNS")
+	assert.Equal(t, []interface{}{"todos", "channel_id"}, args)
+
+	query, args = d.AppliedQuery(d.CreateIndex("idx_todos_channel_id", "todos", []string{"channel_id", "status"}, ""))
+	assert.Contains(t, query, "information_schema.STATISTICS")
+	assert.Equal(t, []interface{}{"todos", "idx_todos_channel_id"}, args)
+
+	query, args = d.AppliedQuery(d.CreateTextIndex("idx_todos_text", "todos", todoTextColumns))
+	assert.Contains(t, query, "information_schema.STATISTICS")
+	assert.Equal(t, []interface{}{"todos", "idx_todos_text"}, args)
+
+	query, _ = d.AppliedQuery("UPDATE todos SET status = 'done', completed_at = updated_at WHERE status = 'completed'")
+	assert.Empty(t, query)
+}
+
+func TestMigrationsStatements(t *testing.T) {
+	for _, d := range []sqlDialect{postgresDialect{}, mysqlDialect{}} {
+		version := 0
+		for _, m := range migrations {
+			assert.Greater(t, m.Version, version, "migrations must be sorted by version")
+			version = m.Version
+			assert.NotEmpty(t, m.Statements(d), "migration %d has no statements", m.Version)
+		}
+	}
+
+	// On MySQL, every column and index added by a migration must be found by AppliedQuery,
+	// so a partially applied migration can be retried.
+	d := mysqlDialect{}
+	for _, m := range migrations {
+		for _, statement := range m.Statements(d) {
+			if strings.Contains(statement, "ADD COLUMN") || strings.Contains(statement, "INDEX") {
+				query, _ := d.AppliedQuery(statement)
+				assert.NotEmpty(t, query, "migration %d cannot be retried: %s", m.Version, statement)
+			}
+		}
+	}
+}
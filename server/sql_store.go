@@ -13,11 +13,29 @@ import (
 )
 
 type SQLStore struct {
-	db         *sql.DB
+	db *sql.DB
+	// q runs the store queries. It is the database, or the transaction when the store is
+	// bound to one by Transaction.
+	q          queryer
+	inTx       bool
 	api        plugin.API
 	driverName string
+	dialect    sqlDialect
 }
 
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// issueColumns are the todos columns, in the order they are scanned into an Issue
+var issueColumns = []string{"id", "message", "description", "post_permalink", "created_at", "updated_at", "post_id", "creator_id", "assignee_id", "priority", "due_at", "status", "foreign_issue_id", "foreign_user_id", "completed_at", "deleted_at", "auto_complete", "recurrence", "list_id", "sort_rank", "completion", "channel_id", "version"}
+
+// issueUpdateColumns are the todos columns updated when saving an existing issue
+var issueUpdateColumns = []string{"message", "description", "post_permalink", "updated_at", "assignee_id", "priority", "due_at", "status", "foreign_issue_id", "foreign_user_id", "completed_at", "deleted_at", "auto_complete", "recurrence", "list_id", "sort_rank", "completion", "channel_id", "version"}
+
 func NewSQLStore(api plugin.API) (*SQLStore, error) {
 	config := api.GetUnsanitizedConfig()
 	if config == nil {
@@ -33,6 +51,11 @@ func NewSQLStore(api plugin.API) (*SQLStore, error) {
 		return nil, fmt.Errorf("sql data source is nil")
 	}
 
+	dialect, err := newSQLDialect(driverName)
+	if err != nil {
+		return nil, err
+	}
+
 	db, err := sql.Open(driverName, *config.SqlSettings.DataSource)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to open database")
@@ -40,8 +63,10 @@ func NewSQLStore(api plugin.API) (*SQLStore, error) {
 
 	s := &SQLStore{
 		db:         db,
+		q:          db,
 		api:        api,
 		driverName: driverName,
+		dialect:    dialect,
 	}
 
 	if err := s.RunMigrations(); err != nil {
@@ -51,156 +76,103 @@ func NewSQLStore(api plugin.API) (*SQLStore, error) {
 	return s, nil
 }
 
-func (s *SQLStore) RunMigrations() error {
-	// Simple migration runner
-	migrations := []struct {
-		Name string
-		SQL  string
-	}{
-		{
-			Name: "000001_create_todos",
-			SQL: `
-				CREATE TABLE IF NOT EXISTS todos (
-					id VARCHAR(26) PRIMARY KEY,
-					message TEXT,
-					description TEXT,
-					creator_id VARCHAR(26),
-					assignee_id VARCHAR(26),
-					post_id VARCHAR(26),
-					priority INTEGER DEFAULT 0,
-					due_at BIGINT DEFAULT 0,
-					status VARCHAR(20) DEFAULT 'open',
-					created_at BIGINT,
-					updated_at BIGINT,
-					post_permalink TEXT,
-					foreign_issue_id VARCHAR(26),
-					foreign_user_id VARCHAR(26)
-				);
-			`,
-		},
-		{
-			Name: "000002_create_comments",
-			SQL: `
-				CREATE TABLE IF NOT EXISTS todo_comments (
-					id VARCHAR(26) PRIMARY KEY,
-					todo_id VARCHAR(26),
-					user_id VARCHAR(26),
-					message TEXT,
-					created_at BIGINT
-				);
-			`,
-		},
-		{
-			Name: "000003_create_audit_log",
-			SQL: `
-				CREATE TABLE IF NOT EXISTS todo_audit_log (
-					id VARCHAR(26) PRIMARY KEY,
-					todo_id VARCHAR(26),
-					user_id VARCHAR(26),
-					action VARCHAR(50),
-					metadata TEXT,
-					created_at BIGINT
-				);
-			`,
-		},
-		{
-			Name: "000004_create_preferences",
-			SQL: `
-				CREATE TABLE IF NOT EXISTS todo_preferences (
-					user_id VARCHAR(26) PRIMARY KEY,
-					reminder_enabled BOOLEAN DEFAULT TRUE,
-					last_reminder_at BIGINT DEFAULT 0,
-					allow_incoming_task BOOLEAN DEFAULT TRUE
-				);
-			`,
-		},
-	}
-
-	for _, m := range migrations {
-		if _, err := s.db.Exec(m.SQL); err != nil {
-			return errors.Wrapf(err, "failed to run migration %s", m.Name)
+// ListStore Implementation
+
+// Transaction runs fn with a copy of the store bound to a database transaction. Nested
+// transactions join the outer one.
+func (s *SQLStore) Transaction(fn func(store ListStore) error) (err error) {
+	if s.inTx {
+		return fn(s)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil && rollbackErr != sql.ErrTxDone {
+				s.api.LogError("Unable to rollback transaction", "err", rollbackErr.Error())
+			}
 		}
+	}()
+
+	txStore := *s
+	txStore.q = tx
+	txStore.inTx = true
+	if err = fn(&txStore); err != nil {
+		return err
 	}
 
-	// Add indexes (separate to handle IF NOT EXISTS variations or failure gracefully)
-	s.db.Exec("CREATE INDEX IF NOT EXISTS idx_todos_creator ON todos (creator_id);")
-	s.db.Exec("CREATE INDEX IF NOT EXISTS idx_todos_assignee ON todos (assignee_id, status);")
-	s.db.Exec("CREATE INDEX IF NOT EXISTS idx_todo_comments_todo_id ON todo_comments (todo_id, created_at);")
-	s.db.Exec("CREATE INDEX IF NOT EXISTS idx_todo_audit_log_todo_id ON todo_audit_log (todo_id, created_at);")
-	s.db.Exec("CREATE INDEX IF NOT EXISTS idx_todos_due_at ON todos (due_at) WHERE due_at > 0;")
-	s.db.Exec("CREATE INDEX IF NOT EXISTS idx_todos_status ON todos (status);")
+	return tx.Commit()
+}
 
+func (s *SQLStore) SaveIssue(issue *Issue) error {
+	query := s.dialect.Upsert("todos", []string{"id"}, issueColumns, issueUpdateColumns)
+	_, err := s.q.Exec(s.replacePlaceholders(query),
+		issue.ID, issue.Message, issue.Description, issue.PostPermalink, issue.CreateAt, issue.UpdateAt, issue.PostID, issue.CreatorID, issue.AssigneeID, issue.Priority, issue.DueAt, issue.Status, issue.ForeignIssueID, issue.ForeignUserID, issue.CompletedAt, issue.DeletedAt, issue.AutoComplete, issue.Recurrence, issue.ListID, issue.Rank, issue.Completion, issue.ChannelID, issue.Version+1)
+	if err != nil {
+		return err
+	}
+	issue.Version++
 	return nil
 }
 
-// ListStore Implementation
+func (s *SQLStore) UpdateIssue(issue *Issue) error {
+	assignments := make([]string, 0, len(issueUpdateColumns))
+	for _, column := range issueUpdateColumns {
+		assignments = append(assignments, column+" = ?")
+	}
 
-func (s *SQLStore) SaveIssue(issue *Issue) error {
-	var query string
-	if s.driverName == "postgres" {
-		query = `
-			INSERT INTO todos (id, message, description, post_permalink, created_at, updated_at, post_id, creator_id, assignee_id, priority, due_at, status, foreign_issue_id, foreign_user_id)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-			ON CONFLICT (id) DO UPDATE SET
-				message = EXCLUDED.message,
-				description = EXCLUDED.description,
-				post_permalink = EXCLUDED.post_permalink,
-				updated_at = EXCLUDED.updated_at,
-				assignee_id = EXCLUDED.assignee_id,
-				priority = EXCLUDED.priority,
-				due_at = EXCLUDED.due_at,
-				status = EXCLUDED.status,
-				foreign_issue_id = EXCLUDED.foreign_issue_id,
-				foreign_user_id = EXCLUDED.foreign_user_id;
-		`
-	} else { // Assuming MySQL for other drivers
-		query = `
-			INSERT INTO todos (id, message, description, post_permalink, created_at, updated_at, post_id, creator_id, assignee_id, priority, due_at, status, foreign_issue_id, foreign_user_id)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-			ON DUPLICATE KEY UPDATE
-				message = VALUES(message),
-				description = VALUES(description),
-				post_permalink = VALUES(post_permalink),
-				updated_at = VALUES(updated_at),
-				assignee_id = VALUES(assignee_id),
-				priority = VALUES(priority),
-				due_at = VALUES(due_at),
-				status = VALUES(status),
-				foreign_issue_id = VALUES(foreign_issue_id),
-				foreign_user_id = VALUES(foreign_user_id);
-		`
-	}
-
-	_, err := s.db.Exec(s.replacePlaceholders(query), 
-		issue.ID, issue.Message, issue.Description, issue.PostPermalink, issue.CreateAt, issue.UpdateAt, issue.PostID, issue.CreatorID, issue.AssigneeID, issue.Priority, issue.DueAt, issue.Status, issue.ForeignIssueID, issue.ForeignUserID)
-	return err
+	result, err := s.q.Exec(s.replacePlaceholders("UPDATE todos SET "+strings.Join(assignments, ", ")+" WHERE id = ? AND version = ?"),
+		issue.Message, issue.Description, issue.PostPermalink, issue.UpdateAt, issue.AssigneeID, issue.Priority, issue.DueAt, issue.Status, issue.ForeignIssueID, issue.ForeignUserID, issue.CompletedAt, issue.DeletedAt, issue.AutoComplete, issue.Recurrence, issue.ListID, issue.Rank, issue.Completion, issue.ChannelID, issue.Version+1, issue.ID, issue.Version)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		if _, err := s.GetIssue(issue.ID); err != nil {
+			return err
+		}
+		return ErrConflict
+	}
+
+	issue.Version++
+	return nil
 }
 
 func (s *SQLStore) replacePlaceholders(query string) string {
-	if s.driverName != model.DatabaseDriverPostgres {
-		return query
-	}
+	return s.dialect.Rebind(query)
+}
 
-	n := 1
-	for strings.Contains(query, "?") {
-		query = strings.Replace(query, "?", fmt.Sprintf("$%d", n), 1)
-		n++
-	}
-	return query
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-func (s *SQLStore) GetIssue(issueID string) (*Issue, error) {
+// scanIssue scans the issueColumns of row into an Issue
+func scanIssue(row rowScanner) (*Issue, error) {
 	issue := &Issue{}
-	err := s.db.QueryRow(s.replacePlaceholders("SELECT id, message, description, post_permalink, created_at, updated_at, post_id, creator_id, assignee_id, priority, due_at, status, foreign_issue_id, foreign_user_id FROM todos WHERE id = ?"), issueID).
-		Scan(&issue.ID, &issue.Message, &issue.Description, &issue.PostPermalink, &issue.CreateAt, &issue.UpdateAt, &issue.PostID, &issue.CreatorID, &issue.AssigneeID, &issue.Priority, &issue.DueAt, &issue.Status, &issue.ForeignIssueID, &issue.ForeignUserID)
+	var foreignIssueID, foreignUserID sql.NullString
+	err := row.Scan(&issue.ID, &issue.Message, &issue.Description, &issue.PostPermalink, &issue.CreateAt, &issue.UpdateAt, &issue.PostID, &issue.CreatorID, &issue.AssigneeID, &issue.Priority, &issue.DueAt, &issue.Status, &foreignIssueID, &foreignUserID, &issue.CompletedAt, &issue.DeletedAt, &issue.AutoComplete, &issue.Recurrence, &issue.ListID, &issue.Rank, &issue.Completion, &issue.ChannelID, &issue.Version)
 	if err != nil {
 		return nil, err
 	}
+	issue.ForeignIssueID = foreignIssueID.String
+	issue.ForeignUserID = foreignUserID.String
 	return issue, nil
 }
 
+func (s *SQLStore) GetIssue(issueID string) (*Issue, error) {
+	return scanIssue(s.q.QueryRow(s.replacePlaceholders("SELECT "+strings.Join(issueColumns, ", ")+" FROM todos WHERE id = ?"), issueID))
+}
+
 func (s *SQLStore) RemoveIssue(issueID string) error {
-	_, err := s.db.Exec(s.replacePlaceholders("DELETE FROM todos WHERE id = ?"), issueID)
+	_, err := s.q.Exec(s.replacePlaceholders("DELETE FROM todos WHERE id = ?"), issueID)
 	return err
 }
 
@@ -213,122 +185,675 @@ func (s *SQLStore) GetAndRemoveIssue(issueID string) (*Issue, error) {
 	return issue, err
 }
 
+// senderCopyCondition matches the copy of a sent todo kept by its sender, see Issue.IsSenderCopy
+const senderCopyCondition = "(COALESCE(foreign_user_id, '') <> '' AND foreign_user_id = assignee_id AND creator_id <> assignee_id)"
+
+// statusIn returns the condition matching the todos with one of statuses. The statuses are
+// constants, so they are inlined in the query.
+func statusIn(statuses ...string) string {
+	return "status IN ('" + strings.Join(statuses, "', '") + "')"
+}
+
+// listCondition returns the condition matching the todos of userID in listID, which is a
+// built-in list or a custom list, and its arguments. It must match Issue.ListFor.
+func listCondition(userID, listID string) (string, []interface{}) {
+	switch listID {
+	case InListKey:
+		return "assignee_id = ? AND " + statusIn(StatusPending) + " AND deleted_at = 0 AND NOT " + senderCopyCondition, []interface{}{userID}
+	case OutListKey:
+		return "creator_id = ? AND " + statusIn(append([]string{StatusPending, StatusDeclined}, activeStatuses...)...) + " AND deleted_at = 0 AND " + senderCopyCondition, []interface{}{userID}
+	case DoneListKey:
+		return "assignee_id = ? AND " + statusIn(StatusDone) + " AND deleted_at = 0 AND NOT " + senderCopyCondition, []interface{}{userID}
+	default:
+		// The My list holds the active todos without a custom list, as MyListKey is empty.
+		return "assignee_id = ? AND " + statusIn(activeStatuses...) + " AND list_id = ? AND deleted_at = 0 AND NOT " + senderCopyCondition, []interface{}{userID, listID}
+	}
+}
+
+// listOrderBy returns the ORDER BY clause of the todos of listID. It must match
+// Issue.sortsBefore.
+func listOrderBy(listID string) string {
+	if listID == DoneListKey {
+		return "completed_at DESC, id"
+	}
+	return "sort_rank, updated_at DESC, id"
+}
+
 func (s *SQLStore) AddReference(userID, issueID, listID, foreignUserID, foreignIssueID string) error {
-    // In SQL world, "Adding a reference" means updating the creator/assignee/status.
-    // listID "" (My), "_in" (In), "_out" (Out)
-    
-    status := "open"
-    if listID == "_in" {
-        status = "pending"
-    }
-    
-    _, err := s.db.Exec(s.replacePlaceholders("UPDATE todos SET assignee_id = ?, status = ?, foreign_user_id = ?, foreign_issue_id = ? WHERE id = ?"), 
-        userID, status, foreignUserID, foreignIssueID, issueID)
-    return err
+	// In the SQL store the list of a todo is derived from its creator, assignee and status.
+	query := "UPDATE todos SET assignee_id = ?, status = 'open', list_id = '', foreign_user_id = ?, foreign_issue_id = ?, version = version + 1 WHERE id = ?"
+	args := []interface{}{userID, foreignUserID, foreignIssueID, issueID}
+	switch listID {
+	case InListKey:
+		query = "UPDATE todos SET assignee_id = ?, status = 'pending', list_id = '', foreign_user_id = ?, foreign_issue_id = ?, version = version + 1 WHERE id = ?"
+	case OutListKey:
+		query = "UPDATE todos SET creator_id = ?, assignee_id = ?, status = 'pending', list_id = '', foreign_user_id = ?, foreign_issue_id = ?, version = version + 1 WHERE id = ?"
+		args = []interface{}{userID, foreignUserID, foreignUserID, foreignIssueID, issueID}
+	}
+
+	_, err := s.q.Exec(s.replacePlaceholders(query), args...)
+	return err
 }
 
 func (s *SQLStore) RemoveReference(userID, issueID, listID string) error {
 	// For SQL, removing reference means archiving it so it doesn't show in active lists.
-	_, err := s.db.Exec(s.replacePlaceholders("UPDATE todos SET status = 'archived', updated_at = ? WHERE id = ? AND (assignee_id = ? OR creator_id = ?) AND status IN ('open', 'pending')"),
-		model.GetMillis(), issueID, userID, userID)
+	condition, args := listCondition(userID, listID)
+	_, err := s.q.Exec(s.replacePlaceholders("UPDATE todos SET status = 'archived', updated_at = ?, version = version + 1 WHERE id = ? AND "+condition),
+		append([]interface{}{model.GetMillis(), issueID}, args...)...)
 	return err
 }
 
 func (s *SQLStore) PopReference(userID, listID string) (*IssueRef, error) {
-    // Not very SQL-friendly but needed for interface.
-    // Get the first one and return it as a reference.
-    var issueID string
-    err := s.db.QueryRow(s.replacePlaceholders("SELECT id FROM todos WHERE assignee_id = ? AND status = 'open' ORDER BY created_at ASC LIMIT 1"), userID).Scan(&issueID)
-    if err != nil {
-        return nil, err
-    }
-    return &IssueRef{IssueID: issueID}, nil
-}
-
-func (s *SQLStore) BumpReference(userID, issueID, listID string) error {
-    // Update updated_at to bring it to top
-    _, err := s.db.Exec(s.replacePlaceholders("UPDATE todos SET updated_at = ? WHERE id = ?"), model.GetMillis(), issueID)
-    return err
-}
-
-func (s *SQLStore) GetIssueReference(userID, issueID, listID string) (*IssueRef, int, error) {
-    // Return reference if it belongs to user
-    issue, err := s.GetIssue(issueID)
-    if err != nil {
-        return nil, 0, err
-    }
-    if issue.AssigneeID != userID {
-        return nil, 0, errors.New("not assigned to this user")
-    }
-    return &IssueRef{IssueID: issueID}, 0, nil
-}
-
-func (s *SQLStore) GetIssueListAndReference(userID, issueID string) (string, *IssueRef, int) {
-    issue, err := s.GetIssue(issueID)
-    if err != nil {
-        return "", nil, 0
-    }
-    if issue.AssigneeID == userID {
-        if issue.Status == "pending" {
-            return "_in", &IssueRef{IssueID: issueID}, 0
-        }
-        return "", &IssueRef{IssueID: issueID}, 0
-    }
-    if issue.CreatorID == userID && issue.AssigneeID != userID {
-        return "_out", &IssueRef{IssueID: issueID}, 0
-    }
-    return "", nil, 0
+	refs, err := s.GetList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, errors.New("cannot find issue")
+	}
+
+	if err := s.RemoveReference(userID, refs[0].IssueID, listID); err != nil {
+		return nil, err
+	}
+	return refs[0], nil
+}
+
+func (s *SQLStore) SetIssueRank(issueID, rank string) error {
+	_, err := s.q.Exec(s.replacePlaceholders("UPDATE todos SET sort_rank = ? WHERE id = ?"), rank, issueID)
+	return err
+}
+
+func (s *SQLStore) GetIssueReference(userID, issueID, listID string) (*IssueRef, error) {
+	list, ir := s.GetIssueListAndReference(userID, issueID)
+	if ir == nil || list != listID {
+		return nil, errors.New("cannot find issue reference")
+	}
+	return ir, nil
+}
+
+func (s *SQLStore) GetLinkedIssues(issueID string) ([]*Issue, error) {
+	rows, err := s.q.Query(s.replacePlaceholders("SELECT "+strings.Join(issueColumns, ", ")+" FROM todos WHERE foreign_issue_id = ? ORDER BY created_at, id"), issueID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []*Issue
+	for rows.Next() {
+		issue, err := scanIssue(rows)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, issue)
+	}
+	return issues, rows.Err()
+}
+
+func (s *SQLStore) GetIssueListAndReference(userID, issueID string) (string, *IssueRef) {
+	issue, err := s.GetIssue(issueID)
+	if err != nil {
+		return "", nil
+	}
+
+	list, ok := issue.ListFor(userID)
+	if !ok {
+		return "", nil
+	}
+	return list, issue.Reference()
 }
 
 func (s *SQLStore) GetList(userID, listID string) ([]*IssueRef, error) {
-    var query string
-    if listID == "" { // My
-        query = "SELECT id FROM todos WHERE assignee_id = ? AND status = 'open' ORDER BY updated_at DESC"
-    } else if listID == "_in" {
-        query = "SELECT id FROM todos WHERE assignee_id = ? AND status = 'pending' ORDER BY updated_at DESC"
-    } else if listID == "_out" {
-        query = "SELECT id FROM todos WHERE creator_id = ? AND assignee_id != ? AND status = 'pending' ORDER BY updated_at DESC"
-    }
-    
-    	var rows *sql.Rows
-	var err error
-	if listID == "_out" {
-		rows, err = s.db.Query(s.replacePlaceholders(query), userID, userID)
-	} else {
-		rows, err = s.db.Query(s.replacePlaceholders(query), userID)
+	return s.getList(userID, listID, "")
+}
+
+func (s *SQLStore) GetListHead(userID, listID string, limit int) ([]*IssueRef, error) {
+	return s.getList(userID, listID, s.dialect.Limit(limit, 0))
+}
+
+// getList returns the IssueRefs in listID for userID, restricted by the LIMIT clause limit
+// if it is not empty
+func (s *SQLStore) getList(userID, listID, limit string) ([]*IssueRef, error) {
+	condition, args := listCondition(userID, listID)
+	rows, err := s.q.Query(s.replacePlaceholders("SELECT id, foreign_issue_id, foreign_user_id FROM todos WHERE "+condition+" ORDER BY "+listOrderBy(listID)+" "+limit), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []*IssueRef
+	for rows.Next() {
+		ref := &IssueRef{}
+		var foreignIssueID, foreignUserID sql.NullString
+		if err := rows.Scan(&ref.IssueID, &foreignIssueID, &foreignUserID); err != nil {
+			return nil, err
+		}
+		ref.ForeignIssueID = foreignIssueID.String
+		ref.ForeignUserID = foreignUserID.String
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// ownerCondition matches the todos owned by the user bound to its two placeholders. It
+// must match Issue.OwnerID.
+const ownerCondition = "((assignee_id = ? AND NOT " + senderCopyCondition + ") OR (creator_id = ? AND " + senderCopyCondition + "))"
+
+// sortExpressions are the expressions the todos are sorted on for each IssueQuery sort.
+// They must match IssueQuery.sortValue.
+var sortExpressions = map[string]string{
+	SortByUpdated:   "updated_at",
+	SortByCreated:   "created_at",
+	SortByDue:       "CASE WHEN due_at = 0 THEN 9223372036854775807 ELSE due_at END",
+	SortByPriority:  "priority",
+	SortByCompleted: "completed_at",
+}
+
+func (s *SQLStore) QueryIssues(userID string, query *IssueQuery, limit int) ([]*Issue, error) {
+	conditions := []string{ownerCondition, "deleted_at = 0"}
+	args := []interface{}{userID, userID}
+
+	statuses := query.statuses()
+	conditions = append(conditions, "status IN ("+placeholders(len(statuses))+")")
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+
+	if len(query.Priorities) > 0 {
+		conditions = append(conditions, "priority IN ("+placeholders(len(query.Priorities))+")")
+		for _, priority := range query.Priorities {
+			args = append(args, priority)
+		}
+	}
+	if query.DueAfter != 0 {
+		conditions = append(conditions, "due_at >= ?")
+		args = append(args, query.DueAfter)
+	}
+	if query.DueBefore != 0 {
+		conditions = append(conditions, "due_at > 0 AND due_at <= ?")
+		args = append(args, query.DueBefore)
+	}
+	if query.CreatorID != "" {
+		conditions = append(conditions, "creator_id = ?")
+		args = append(args, query.CreatorID)
+	}
+	if query.AssigneeID != "" {
+		conditions = append(conditions, "assignee_id = ?")
+		args = append(args, query.AssigneeID)
+	}
+	if query.Tag != "" {
+		conditions = append(conditions, "id IN (SELECT it.todo_id FROM todo_issue_tags it JOIN todo_tags t ON t.id = it.tag_id WHERE t.user_id = ? AND t.name = ?)")
+		args = append(args, userID, query.Tag)
+	}
+
+	sortExpression := sortExpressions[query.SortBy]
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if query.After != nil {
+		// Keyset pagination: the todos after the cursor, which are ordered by the sort and then the ID.
+		conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", sortExpression, comparison))
+		args = append(args, query.After.SortValue, query.After.SortValue, query.After.ID)
+	}
+
+	rows, err := s.q.Query(s.replacePlaceholders(fmt.Sprintf("SELECT %s FROM todos WHERE %s ORDER BY %s %s, id %s %s",
+		strings.Join(issueColumns, ", "), strings.Join(conditions, " AND "), sortExpression, direction, direction, s.dialect.Limit(limit, 0))), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []*Issue
+	for rows.Next() {
+		issue, err := scanIssue(rows)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, issue)
+	}
+	return issues, rows.Err()
+}
+
+// todoTextColumns and commentTextColumns are the columns searched by SearchIssues
+var (
+	todoTextColumns    = []string{"message", "description"}
+	commentTextColumns = []string{"message"}
+)
+
+func (s *SQLStore) SearchIssues(userID string, channelIDs []string, terms string, limit int) ([]*Issue, error) {
+	issues, err := s.searchIssues(userID, channelIDs, s.dialect.MatchText(todoTextColumns), []interface{}{terms},
+		s.dialect.MatchText(commentTextColumns), []interface{}{terms}, limit)
+	if err != nil || len(issues) > 0 {
+		return issues, err
+	}
+
+	// Fall back to substring matching, which finds partial words and the words ignored by
+	// the full-text indexes, such as stop words and short words on MySQL.
+	pattern := "%" + escapeLike(strings.ToLower(terms)) + "%"
+	return s.searchIssues(userID, channelIDs, likeCondition(todoTextColumns), []interface{}{pattern, pattern},
+		likeCondition(commentTextColumns), []interface{}{pattern}, limit)
+}
+
+// searchIssues returns the issues of userID and channelIDs matching todoCondition, or having
+// a comment matching commentCondition
+func (s *SQLStore) searchIssues(userID string, channelIDs []string, todoCondition string, todoArgs []interface{}, commentCondition string, commentArgs []interface{}, limit int) ([]*Issue, error) {
+	conditions := []string{"deleted_at = 0", "(" + todoCondition + " OR id IN (SELECT todo_id FROM todo_comments WHERE " + commentCondition + "))"}
+	args := append(append([]interface{}{}, todoArgs...), commentArgs...)
+	if userID != "" {
+		scope := "(channel_id = '' AND " + ownerCondition + ")"
+		scopeArgs := []interface{}{userID, userID}
+		if len(channelIDs) > 0 {
+			scope = "(" + scope + " OR channel_id IN (" + placeholders(len(channelIDs)) + "))"
+			for _, channelID := range channelIDs {
+				scopeArgs = append(scopeArgs, channelID)
+			}
+		}
+		conditions = append([]string{scope}, conditions...)
+		args = append(scopeArgs, args...)
+	}
+
+	rows, err := s.q.Query(s.replacePlaceholders(fmt.Sprintf("SELECT %s FROM todos WHERE %s ORDER BY updated_at DESC %s",
+		strings.Join(issueColumns, ", "), strings.Join(conditions, " AND "), s.dialect.Limit(limit, 0))), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []*Issue
+	for rows.Next() {
+		issue, err := scanIssue(rows)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, issue)
+	}
+	return issues, rows.Err()
+}
+
+func (s *SQLStore) GetTrash(userID string, limit int) ([]*Issue, error) {
+	rows, err := s.q.Query(s.replacePlaceholders(fmt.Sprintf("SELECT %s FROM todos WHERE %s AND deleted_at > 0 ORDER BY deleted_at DESC, id %s",
+		strings.Join(issueColumns, ", "), ownerCondition, s.dialect.Limit(limit, 0))), userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []*Issue
+	for rows.Next() {
+		issue, err := scanIssue(rows)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, issue)
+	}
+	return issues, rows.Err()
+}
+
+func (s *SQLStore) GetChannelIssues(channelID, status string, limit int) ([]*Issue, error) {
+	orderBy := "created_at DESC, id"
+	if status == StatusDone {
+		orderBy = "completed_at DESC, id"
+	}
+
+	rows, err := s.q.Query(s.replacePlaceholders(fmt.Sprintf("SELECT %s FROM todos WHERE channel_id = ? AND status = ? AND deleted_at = 0 ORDER BY %s %s",
+		strings.Join(issueColumns, ", "), orderBy, s.dialect.Limit(limit, 0))), channelID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []*Issue
+	for rows.Next() {
+		issue, err := scanIssue(rows)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, issue)
+	}
+	return issues, rows.Err()
+}
+
+// assignedCondition matches the open and pending todos assigned to the users bound to n
+// placeholders, like the My, custom and In lists of the users
+func assignedCondition(n int) string {
+	return "assignee_id IN (" + placeholders(n) + ") AND " + statusIn(append([]string{StatusPending}, activeStatuses...)...) + " AND deleted_at = 0 AND NOT " + senderCopyCondition
+}
+
+func (s *SQLStore) GetAssignedIssues(userIDs []string, limit int) ([]*Issue, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, 0, len(userIDs))
+	for _, userID := range userIDs {
+		args = append(args, userID)
+	}
+	rows, err := s.q.Query(s.replacePlaceholders(fmt.Sprintf("SELECT %s FROM todos WHERE %s ORDER BY %s, id %s",
+		strings.Join(issueColumns, ", "), assignedCondition(len(userIDs)), sortExpressions[SortByDue], s.dialect.Limit(limit, 0))), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []*Issue
+	for rows.Next() {
+		issue, err := scanIssue(rows)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, issue)
+	}
+	return issues, rows.Err()
+}
+
+func (s *SQLStore) CountAssignedIssues(userIDs []string, now int64) (map[string]*AssigneeCounts, error) {
+	counts := map[string]*AssigneeCounts{}
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	args := []interface{}{now}
+	for _, userID := range userIDs {
+		args = append(args, userID)
+	}
+	active := statusIn(activeStatuses...)
+	rows, err := s.q.Query(s.replacePlaceholders(`
+		SELECT assignee_id,
+			SUM(CASE WHEN `+active+` THEN 1 ELSE 0 END),
+			SUM(CASE WHEN `+active+` AND due_at > 0 AND due_at < ? THEN 1 ELSE 0 END),
+			SUM(CASE WHEN `+statusIn(StatusPending)+` THEN 1 ELSE 0 END)
+		FROM todos WHERE `+assignedCondition(len(userIDs))+`
+		GROUP BY assignee_id`), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c := &AssigneeCounts{}
+		if err := rows.Scan(&c.UserID, &c.Open, &c.Overdue, &c.Pending); err != nil {
+			return nil, err
+		}
+		counts[c.UserID] = c
+	}
+	return counts, rows.Err()
+}
+
+func (s *SQLStore) PurgeTrash(deletedBefore int64, purgeAuditLogs bool) (int, error) {
+	const trashed = "SELECT id FROM todos WHERE deleted_at > 0 AND deleted_at < ?"
+
+	statements := []string{
+		"DELETE FROM todo_comments WHERE todo_id IN (" + trashed + ")",
+		"DELETE FROM todo_checklist_items WHERE todo_id IN (" + trashed + ")",
+		"DELETE FROM todo_issue_tags WHERE todo_id IN (" + trashed + ")",
+		"DELETE FROM todo_dependencies WHERE todo_id IN (" + trashed + ")",
+		"DELETE FROM todo_dependencies WHERE blocked_by_id IN (" + trashed + ")",
+		"DELETE FROM todo_watchers WHERE todo_id IN (" + trashed + ")",
+	}
+	if purgeAuditLogs {
+		statements = append(statements, "DELETE FROM todo_audit_log WHERE todo_id IN ("+trashed+")")
+	}
+	for _, statement := range statements {
+		if _, err := s.q.Exec(s.replacePlaceholders(statement), deletedBefore); err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := s.q.Exec(s.replacePlaceholders("DELETE FROM todos WHERE deleted_at > 0 AND deleted_at < ?"), deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(deleted), nil
+}
+
+// likeCondition returns a condition matching the rows where one of columns matches the
+// pattern bound to its placeholders, one per column
+func likeCondition(columns []string) string {
+	conditions := make([]string, 0, len(columns))
+	for _, column := range columns {
+		conditions = append(conditions, "LOWER("+column+") LIKE ?")
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Tags implementation
+
+func (s *SQLStore) GetTag(userID, name string) (*Tag, error) {
+	tag := &Tag{}
+	err := s.q.QueryRow(s.replacePlaceholders("SELECT id, user_id, name, created_at FROM todo_tags WHERE user_id = ? AND name = ?"), userID, name).
+		Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (s *SQLStore) SaveTag(tag *Tag) error {
+	if tag.ID == "" {
+		tag.ID = model.NewId()
+	}
+	if tag.CreatedAt == 0 {
+		tag.CreatedAt = model.GetMillis()
+	}
+	_, err := s.q.Exec(s.replacePlaceholders("INSERT INTO todo_tags (id, user_id, name, created_at) VALUES (?, ?, ?, ?)"),
+		tag.ID, tag.UserID, tag.Name, tag.CreatedAt)
+	return err
+}
+
+func (s *SQLStore) GetTags(userID string) ([]*Tag, error) {
+	rows, err := s.q.Query(s.replacePlaceholders("SELECT id, user_id, name, created_at FROM todo_tags WHERE user_id = ? ORDER BY name ASC"), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []*Tag
+	for rows.Next() {
+		tag := &Tag{}
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (s *SQLStore) AddIssueTag(todoID, tagID string) error {
+	query := s.dialect.Upsert("todo_issue_tags", []string{"todo_id", "tag_id"}, []string{"todo_id", "tag_id"}, []string{"tag_id"})
+	_, err := s.q.Exec(s.replacePlaceholders(query), todoID, tagID)
+	return err
+}
+
+func (s *SQLStore) RemoveIssueTag(todoID, tagID string) error {
+	_, err := s.q.Exec(s.replacePlaceholders("DELETE FROM todo_issue_tags WHERE todo_id = ? AND tag_id = ?"), todoID, tagID)
+	return err
+}
+
+func (s *SQLStore) GetIssueTags(todoIDs []string) (map[string][]string, error) {
+	tags := map[string][]string{}
+	if len(todoIDs) == 0 {
+		return tags, nil
+	}
+
+	args := make([]interface{}, 0, len(todoIDs))
+	for _, todoID := range todoIDs {
+		args = append(args, todoID)
+	}
+	rows, err := s.q.Query(s.replacePlaceholders("SELECT it.todo_id, t.name FROM todo_issue_tags it JOIN todo_tags t ON t.id = it.tag_id WHERE it.todo_id IN ("+placeholders(len(todoIDs))+") ORDER BY t.name ASC"), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID, name string
+		if err := rows.Scan(&todoID, &name); err != nil {
+			return nil, err
+		}
+		tags[todoID] = append(tags[todoID], name)
+	}
+	return tags, rows.Err()
+}
+
+// Dependencies implementation
+
+func (s *SQLStore) AddDependency(todoID, blockerID string) error {
+	query := s.dialect.Upsert("todo_dependencies", []string{"todo_id", "blocked_by_id"}, []string{"todo_id", "blocked_by_id", "created_at"}, []string{"created_at"})
+	_, err := s.q.Exec(s.replacePlaceholders(query), todoID, blockerID, model.GetMillis())
+	return err
+}
+
+func (s *SQLStore) RemoveDependency(todoID, blockerID string) error {
+	_, err := s.q.Exec(s.replacePlaceholders("DELETE FROM todo_dependencies WHERE todo_id = ? AND blocked_by_id = ?"), todoID, blockerID)
+	return err
+}
+
+func (s *SQLStore) GetBlockers(todoIDs []string) (map[string][]string, error) {
+	return s.getDependencies("todo_id", "blocked_by_id", todoIDs)
+}
+
+func (s *SQLStore) GetBlockedIssues(blockerIDs []string) (map[string][]string, error) {
+	return s.getDependencies("blocked_by_id", "todo_id", blockerIDs)
+}
+
+// getDependencies returns the values of the column to of the dependencies whose column from
+// is one of ids, grouped by the value of from
+func (s *SQLStore) getDependencies(from, to string, ids []string) (map[string][]string, error) {
+	dependencies := map[string][]string{}
+	if len(ids) == 0 {
+		return dependencies, nil
+	}
+
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	rows, err := s.q.Query(s.replacePlaceholders("SELECT "+from+", "+to+" FROM todo_dependencies WHERE "+from+" IN ("+placeholders(len(ids))+") ORDER BY "+to), args...)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		dependencies[key] = append(dependencies[key], value)
+	}
+	return dependencies, rows.Err()
+}
+
+// Watchers implementation
+
+func (s *SQLStore) AddWatcher(todoID, userID string) error {
+	query := s.dialect.Upsert("todo_watchers", []string{"todo_id", "user_id"}, []string{"todo_id", "user_id", "created_at"}, []string{"created_at"})
+	_, err := s.q.Exec(s.replacePlaceholders(query), todoID, userID, model.GetMillis())
+	return err
+}
+
+func (s *SQLStore) RemoveWatcher(todoID, userID string) error {
+	_, err := s.q.Exec(s.replacePlaceholders("DELETE FROM todo_watchers WHERE todo_id = ? AND user_id = ?"), todoID, userID)
+	return err
+}
+
+func (s *SQLStore) GetWatchers(todoID string) ([]string, error) {
+	rows, err := s.q.Query(s.replacePlaceholders("SELECT user_id FROM todo_watchers WHERE todo_id = ? ORDER BY user_id"), todoID)
 	if err != nil {
 		return nil, err
 	}
-    
-    defer rows.Close()
-    
-    var refs []*IssueRef
-    for rows.Next() {
-        var id string
-        if err := rows.Scan(&id); err != nil {
-            return nil, err
-        }
-        refs = append(refs, &IssueRef{IssueID: id})
-    }
-    return refs, nil
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// Custom lists implementation
+
+// customListColumns are the todo_lists columns, in the order they are scanned into a CustomList
+var customListColumns = []string{"id", "user_id", "name", "sort_order", "created_at", "updated_at"}
+
+func scanCustomList(row rowScanner) (*CustomList, error) {
+	list := &CustomList{}
+	if err := row.Scan(&list.ID, &list.UserID, &list.Name, &list.SortOrder, &list.CreatedAt, &list.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *SQLStore) SaveCustomList(list *CustomList) error {
+	if list.ID == "" {
+		list.ID = model.NewId()
+	}
+	query := s.dialect.Upsert("todo_lists", []string{"id"}, customListColumns, []string{"name", "sort_order", "updated_at"})
+	_, err := s.q.Exec(s.replacePlaceholders(query), list.ID, list.UserID, list.Name, list.SortOrder, list.CreatedAt, list.UpdatedAt)
+	return err
+}
+
+func (s *SQLStore) GetCustomList(listID string) (*CustomList, error) {
+	list, err := scanCustomList(s.q.QueryRow(s.replacePlaceholders("SELECT "+strings.Join(customListColumns, ", ")+" FROM todo_lists WHERE id = ?"), listID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return list, err
+}
+
+func (s *SQLStore) GetCustomLists(userID string) ([]*CustomList, error) {
+	rows, err := s.q.Query(s.replacePlaceholders("SELECT "+strings.Join(customListColumns, ", ")+" FROM todo_lists WHERE user_id = ? ORDER BY sort_order ASC, created_at ASC, id ASC"), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lists []*CustomList
+	for rows.Next() {
+		list, err := scanCustomList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	return lists, rows.Err()
+}
+
+func (s *SQLStore) DeleteCustomList(listID string) error {
+	if _, err := s.q.Exec(s.replacePlaceholders("UPDATE todos SET list_id = '', version = version + 1 WHERE list_id = ?"), listID); err != nil {
+		return err
+	}
+	_, err := s.q.Exec(s.replacePlaceholders("DELETE FROM todo_lists WHERE id = ?"), listID)
+	return err
 }
 
 // Preferences implementation
 
 func (s *SQLStore) SetReminderPreference(userID string, enabled bool) error {
-	query := "INSERT INTO todo_preferences (user_id, reminder_enabled) VALUES (?, ?) ON CONFLICT (user_id) DO UPDATE SET reminder_enabled = EXCLUDED.reminder_enabled"
-	if s.driverName == model.DatabaseDriverMysql {
-		query = "INSERT INTO todo_preferences (user_id, reminder_enabled) VALUES (?, ?) ON DUPLICATE KEY UPDATE reminder_enabled = VALUES(reminder_enabled)"
-	}
-	_, err := s.db.Exec(s.replacePlaceholders(query), userID, enabled)
+	query := s.dialect.Upsert("todo_preferences", []string{"user_id"}, []string{"user_id", "reminder_enabled"}, []string{"reminder_enabled"})
+	_, err := s.q.Exec(s.replacePlaceholders(query), userID, enabled)
 	return err
 }
 
 func (s *SQLStore) GetReminderPreference(userID string) bool {
 	var enabled bool
-	err := s.db.QueryRow(s.replacePlaceholders("SELECT reminder_enabled FROM todo_preferences WHERE user_id = ?"), userID).Scan(&enabled)
+	err := s.q.QueryRow(s.replacePlaceholders("SELECT reminder_enabled FROM todo_preferences WHERE user_id = ?"), userID).Scan(&enabled)
 	if err != nil {
 		return true // Default
 	}
@@ -336,17 +861,14 @@ func (s *SQLStore) GetReminderPreference(userID string) bool {
 }
 
 func (s *SQLStore) SetLastReminderTime(userID string, time int64) error {
-	query := "INSERT INTO todo_preferences (user_id, last_reminder_at) VALUES (?, ?) ON CONFLICT (user_id) DO UPDATE SET last_reminder_at = EXCLUDED.last_reminder_at"
-	if s.driverName == model.DatabaseDriverMysql {
-		query = "INSERT INTO todo_preferences (user_id, last_reminder_at) VALUES (?, ?) ON DUPLICATE KEY UPDATE last_reminder_at = VALUES(last_reminder_at)"
-	}
-	_, err := s.db.Exec(s.replacePlaceholders(query), userID, time)
+	query := s.dialect.Upsert("todo_preferences", []string{"user_id"}, []string{"user_id", "last_reminder_at"}, []string{"last_reminder_at"})
+	_, err := s.q.Exec(s.replacePlaceholders(query), userID, time)
 	return err
 }
 
 func (s *SQLStore) GetLastReminderTime(userID string) (int64, error) {
 	var last int64
-	err := s.db.QueryRow(s.replacePlaceholders("SELECT last_reminder_at FROM todo_preferences WHERE user_id = ?"), userID).Scan(&last)
+	err := s.q.QueryRow(s.replacePlaceholders("SELECT last_reminder_at FROM todo_preferences WHERE user_id = ?"), userID).Scan(&last)
 	if err != nil {
 		return 0, nil
 	}
@@ -354,23 +876,42 @@ func (s *SQLStore) GetLastReminderTime(userID string) (int64, error) {
 }
 
 func (s *SQLStore) SetAllowIncomingTaskPreference(userID string, enabled bool) error {
-	query := "INSERT INTO todo_preferences (user_id, allow_incoming_task) VALUES (?, ?) ON CONFLICT (user_id) DO UPDATE SET allow_incoming_task = EXCLUDED.allow_incoming_task"
-	if s.driverName == model.DatabaseDriverMysql {
-		query = "INSERT INTO todo_preferences (user_id, allow_incoming_task) VALUES (?, ?) ON DUPLICATE KEY UPDATE allow_incoming_task = VALUES(allow_incoming_task)"
-	}
-	_, err := s.db.Exec(s.replacePlaceholders(query), userID, enabled)
+	query := s.dialect.Upsert("todo_preferences", []string{"user_id"}, []string{"user_id", "allow_incoming_task"}, []string{"allow_incoming_task"})
+	_, err := s.q.Exec(s.replacePlaceholders(query), userID, enabled)
 	return err
 }
 
 func (s *SQLStore) GetAllowIncomingTaskPreference(userID string) (bool, error) {
 	var enabled bool
-	err := s.db.QueryRow(s.replacePlaceholders("SELECT allow_incoming_task FROM todo_preferences WHERE user_id = ?"), userID).Scan(&enabled)
+	err := s.q.QueryRow(s.replacePlaceholders("SELECT allow_incoming_task FROM todo_preferences WHERE user_id = ?"), userID).Scan(&enabled)
 	if err != nil {
 		return true, nil
 	}
 	return enabled, nil
 }
 
+func (s *SQLStore) GetUsersToRemind() ([]string, error) {
+	// Users without a preferences row get the default, which is to be reminded.
+	rows, err := s.q.Query(`
+		SELECT DISTINCT t.assignee_id FROM todos t
+		LEFT JOIN todo_preferences p ON p.user_id = t.assignee_id
+		WHERE t.` + statusIn(activeStatuses...) + ` AND t.deleted_at = 0 AND t.assignee_id <> '' AND (p.reminder_enabled IS NULL OR p.reminder_enabled = TRUE)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
 func (s *SQLStore) SaveComment(comment *Comment) error {
 	if comment.ID == "" {
 		comment.ID = model.NewId()
@@ -378,13 +919,13 @@ func (s *SQLStore) SaveComment(comment *Comment) error {
 	if comment.CreatedAt == 0 {
 		comment.CreatedAt = model.GetMillis()
 	}
-	_, err := s.db.Exec(s.replacePlaceholders("INSERT INTO todo_comments (id, todo_id, user_id, message, created_at) VALUES (?, ?, ?, ?, ?)"),
+	_, err := s.q.Exec(s.replacePlaceholders("INSERT INTO todo_comments (id, todo_id, user_id, message, created_at) VALUES (?, ?, ?, ?, ?)"),
 		comment.ID, comment.TodoID, comment.UserID, comment.Message, comment.CreatedAt)
 	return err
 }
 
 func (s *SQLStore) GetComments(todoID string) ([]*Comment, error) {
-	rows, err := s.db.Query(s.replacePlaceholders("SELECT id, todo_id, user_id, message, created_at FROM todo_comments WHERE todo_id = ? ORDER BY created_at ASC"), todoID)
+	rows, err := s.q.Query(s.replacePlaceholders("SELECT id, todo_id, user_id, message, created_at FROM todo_comments WHERE todo_id = ? ORDER BY created_at ASC"), todoID)
 	if err != nil {
 		return nil, err
 	}
@@ -403,7 +944,7 @@ func (s *SQLStore) GetComments(todoID string) ([]*Comment, error) {
 
 func (s *SQLStore) GetComment(commentID string) (*Comment, error) {
 	c := &Comment{}
-	err := s.db.QueryRow(s.replacePlaceholders("SELECT id, todo_id, user_id, message, created_at FROM todo_comments WHERE id = ?"), commentID).
+	err := s.q.QueryRow(s.replacePlaceholders("SELECT id, todo_id, user_id, message, created_at FROM todo_comments WHERE id = ?"), commentID).
 		Scan(&c.ID, &c.TodoID, &c.UserID, &c.Message, &c.CreatedAt)
 	if err != nil {
 		return nil, err
@@ -412,10 +953,88 @@ func (s *SQLStore) GetComment(commentID string) (*Comment, error) {
 }
 
 func (s *SQLStore) DeleteComment(commentID string) error {
-	_, err := s.db.Exec(s.replacePlaceholders("DELETE FROM todo_comments WHERE id = ?"), commentID)
+	_, err := s.q.Exec(s.replacePlaceholders("DELETE FROM todo_comments WHERE id = ?"), commentID)
 	return err
 }
 
+// checklistItemColumns are the todo_checklist_items columns, in the order they are scanned into a ChecklistItem
+var checklistItemColumns = []string{"id", "todo_id", "message", "checked", "sort_order", "created_at", "checked_at"}
+
+func scanChecklistItem(row rowScanner) (*ChecklistItem, error) {
+	item := &ChecklistItem{}
+	if err := row.Scan(&item.ID, &item.TodoID, &item.Message, &item.Checked, &item.SortOrder, &item.CreatedAt, &item.CheckedAt); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *SQLStore) SaveChecklistItem(item *ChecklistItem) error {
+	if item.ID == "" {
+		item.ID = model.NewId()
+	}
+	if item.CreatedAt == 0 {
+		item.CreatedAt = model.GetMillis()
+	}
+	query := s.dialect.Upsert("todo_checklist_items", []string{"id"}, checklistItemColumns, []string{"message", "checked", "sort_order", "checked_at"})
+	_, err := s.q.Exec(s.replacePlaceholders(query),
+		item.ID, item.TodoID, item.Message, item.Checked, item.SortOrder, item.CreatedAt, item.CheckedAt)
+	return err
+}
+
+func (s *SQLStore) GetChecklistItem(itemID string) (*ChecklistItem, error) {
+	return scanChecklistItem(s.q.QueryRow(s.replacePlaceholders("SELECT "+strings.Join(checklistItemColumns, ", ")+" FROM todo_checklist_items WHERE id = ?"), itemID))
+}
+
+func (s *SQLStore) GetChecklist(todoID string) ([]*ChecklistItem, error) {
+	rows, err := s.q.Query(s.replacePlaceholders("SELECT "+strings.Join(checklistItemColumns, ", ")+" FROM todo_checklist_items WHERE todo_id = ? ORDER BY sort_order ASC, created_at ASC"), todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*ChecklistItem
+	for rows.Next() {
+		item, err := scanChecklistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (s *SQLStore) DeleteChecklistItem(itemID string) error {
+	_, err := s.q.Exec(s.replacePlaceholders("DELETE FROM todo_checklist_items WHERE id = ?"), itemID)
+	return err
+}
+
+func (s *SQLStore) GetChecklistProgress(todoIDs []string) (map[string]*ChecklistProgress, error) {
+	progress := map[string]*ChecklistProgress{}
+	if len(todoIDs) == 0 {
+		return progress, nil
+	}
+
+	args := make([]interface{}, 0, len(todoIDs))
+	for _, todoID := range todoIDs {
+		args = append(args, todoID)
+	}
+	rows, err := s.q.Query(s.replacePlaceholders("SELECT todo_id, SUM(CASE WHEN checked THEN 1 ELSE 0 END), COUNT(*) FROM todo_checklist_items WHERE todo_id IN ("+placeholders(len(todoIDs))+") GROUP BY todo_id"), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID string
+		p := &ChecklistProgress{}
+		if err := rows.Scan(&todoID, &p.Checked, &p.Total); err != nil {
+			return nil, err
+		}
+		progress[todoID] = p
+	}
+	return progress, rows.Err()
+}
+
 func (s *SQLStore) AddAuditLog(log *AuditLog) error {
 	if log.ID == "" {
 		log.ID = model.NewId()
@@ -423,13 +1042,24 @@ func (s *SQLStore) AddAuditLog(log *AuditLog) error {
 	if log.CreatedAt == 0 {
 		log.CreatedAt = model.GetMillis()
 	}
-	_, err := s.db.Exec(s.replacePlaceholders("INSERT INTO todo_audit_log (id, todo_id, user_id, action, metadata, created_at) VALUES (?, ?, ?, ?, ?, ?)"),
-		log.ID, log.TodoID, log.UserID, log.Action, log.Metadata, log.CreatedAt)
+	_, err := s.q.Exec(s.replacePlaceholders("INSERT INTO todo_audit_log (id, todo_id, user_id, action, metadata, snapshot, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)"),
+		log.ID, log.TodoID, log.UserID, log.Action, log.Metadata, log.Snapshot, log.CreatedAt)
 	return err
 }
 
+// auditLogColumns are the todo_audit_log columns, in the order they are scanned into an AuditLog
+const auditLogColumns = "id, todo_id, user_id, action, metadata, COALESCE(snapshot, ''), created_at"
+
+func scanAuditLog(row rowScanner) (*AuditLog, error) {
+	l := &AuditLog{}
+	if err := row.Scan(&l.ID, &l.TodoID, &l.UserID, &l.Action, &l.Metadata, &l.Snapshot, &l.CreatedAt); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
 func (s *SQLStore) GetAuditLogs(todoID string) ([]*AuditLog, error) {
-	rows, err := s.db.Query(s.replacePlaceholders("SELECT id, todo_id, user_id, action, metadata, created_at FROM todo_audit_log WHERE todo_id = ? ORDER BY created_at DESC"), todoID)
+	rows, err := s.q.Query(s.replacePlaceholders("SELECT "+auditLogColumns+" FROM todo_audit_log WHERE todo_id = ? ORDER BY created_at DESC"), todoID)
 	if err != nil {
 		return nil, err
 	}
@@ -437,11 +1067,50 @@ func (s *SQLStore) GetAuditLogs(todoID string) ([]*AuditLog, error) {
 
 	var logs []*AuditLog
 	for rows.Next() {
-		l := &AuditLog{}
-		if err := rows.Scan(&l.ID, &l.TodoID, &l.UserID, &l.Action, &l.Metadata, &l.CreatedAt); err != nil {
+		l, err := scanAuditLog(rows)
+		if err != nil {
 			return nil, err
 		}
 		logs = append(logs, l)
 	}
 	return logs, nil
 }
+
+func (s *SQLStore) GetLastAuditLog(userID string, since int64) (*AuditLog, error) {
+	query := fmt.Sprintf("SELECT %s FROM todo_audit_log WHERE user_id = ? AND created_at >= ? AND action NOT IN (%s) "+
+		"ORDER BY created_at DESC, CASE WHEN COALESCE(snapshot, '') <> '' THEN 0 ELSE 1 END %s",
+		auditLogColumns, placeholders(len(passiveAuditLogActions)), s.dialect.Limit(1, 0))
+	args := []interface{}{userID, since}
+	for _, action := range passiveAuditLogActions {
+		args = append(args, action)
+	}
+	log, err := scanAuditLog(s.q.QueryRow(s.replacePlaceholders(query), args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return log, nil
+}
+
+func (s *SQLStore) GetJobState(name string) (*JobState, error) {
+	state := &JobState{}
+	var lastError sql.NullString
+	err := s.q.QueryRow(s.replacePlaceholders("SELECT name, last_started_at, last_finished_at, last_error FROM todo_jobs WHERE name = ?"), name).
+		Scan(&state.Name, &state.LastStartedAt, &state.LastFinishedAt, &lastError)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state.LastError = lastError.String
+	return state, nil
+}
+
+func (s *SQLStore) SaveJobState(state *JobState) error {
+	query := s.dialect.Upsert("todo_jobs", []string{"name"}, []string{"name", "last_started_at", "last_finished_at", "last_error"}, []string{"last_started_at", "last_finished_at", "last_error"})
+	_, err := s.q.Exec(s.replacePlaceholders(query), state.Name, state.LastStartedAt, state.LastFinishedAt, state.LastError)
+	return err
+}
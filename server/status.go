@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidTransition is wrapped by the errors of status changes not allowed by the workflow
+var ErrInvalidTransition = errors.New("invalid status change")
+
+// activeStatuses are the statuses of the todos in the My list and the custom lists
+var activeStatuses = []string{StatusOpen, StatusInProgress, StatusBlocked}
+
+// defaultStatusTransitions is the default workflow of the todos: the statuses each status
+// can change to. Received todos are accepted into the open status or declined, and the other
+// ones are started, blocked and completed from any active status. Done and archived todos
+// only come back through undo and the trash, and declined ones when their sender sends them
+// again.
+var defaultStatusTransitions = map[string][]string{
+	StatusPending:    {StatusOpen, StatusDone, StatusDeclined},
+	StatusOpen:       {StatusInProgress, StatusBlocked, StatusDone},
+	StatusInProgress: {StatusOpen, StatusBlocked, StatusDone},
+	StatusBlocked:    {StatusOpen, StatusInProgress, StatusDone},
+}
+
+var (
+	statusTransitionsLock sync.RWMutex
+	// statusTransitions is the workflow in use, set from the configuration
+	statusTransitions = defaultStatusTransitions
+)
+
+// parseStatusWorkflow parses the workflow of the configuration, a JSON object mapping active
+// statuses to the active statuses or the done status they can change to, and returns the
+// transitions of the workflow. The statuses missing from workflow keep their default
+// transitions, and an empty workflow is the default one. The transitions of received todos
+// cannot be configured, as accepting and declining them depend on them.
+func parseStatusWorkflow(workflow string) (map[string][]string, error) {
+	if strings.TrimSpace(workflow) == "" {
+		return defaultStatusTransitions, nil
+	}
+
+	var configured map[string][]string
+	if err := json.Unmarshal([]byte(workflow), &configured); err != nil {
+		return nil, errors.Wrap(err, "the status workflow is not a JSON object of status lists")
+	}
+
+	transitions := maps.Clone(defaultStatusTransitions)
+	for from, to := range configured {
+		if !isActiveStatus(from) {
+			return nil, fmt.Errorf("the workflow cannot change the transitions of the %s status", from)
+		}
+		for _, status := range to {
+			if status != StatusDone && (!isActiveStatus(status) || status == from) {
+				return nil, fmt.Errorf("a %s todo cannot change to the %s status", statusLabel(from), status)
+			}
+		}
+		transitions[from] = to
+	}
+	return transitions, nil
+}
+
+// setStatusTransitions replaces the workflow in use
+func setStatusTransitions(transitions map[string][]string) {
+	statusTransitionsLock.Lock()
+	defer statusTransitionsLock.Unlock()
+	statusTransitions = transitions
+}
+
+// isActiveStatus returns true if status is the status of a todo being worked on
+func isActiveStatus(status string) bool {
+	return slices.Contains(activeStatuses, status)
+}
+
+// checkTransition returns an error wrapping ErrInvalidTransition if the workflow does not
+// allow a todo to change from the status from to the status to
+func checkTransition(from, to string) error {
+	statusTransitionsLock.RLock()
+	allowed := statusTransitions[from]
+	statusTransitionsLock.RUnlock()
+
+	if !slices.Contains(allowed, to) {
+		return fmt.Errorf("%w: a %s todo cannot be %s", ErrInvalidTransition, statusLabel(from), statusLabel(to))
+	}
+	return nil
+}
+
+// statusLabel returns the name of status shown to users
+func statusLabel(status string) string {
+	switch status {
+	case StatusInProgress:
+		return "in progress"
+	default:
+		return status
+	}
+}
+
+// parseStatus returns the status named name, accepting the labels shown to users
+func parseStatus(name string) (string, bool) {
+	switch name {
+	case "open", "todo":
+		return StatusOpen, true
+	case "in_progress", "in-progress", "progress", "started":
+		return StatusInProgress, true
+	case "blocked":
+		return StatusBlocked, true
+	case "done", "complete", "completed":
+		return StatusDone, true
+	}
+	return "", false
+}
+
+// SetIssueStatus changes the status of the active todo issueID of userID, following the
+// workflow. Completing the todo through its status is the same as CompleteIssue. It returns
+// the issue, the other party of a sent todo if any and the list the todo was in.
+func (l *listManager) SetIssueStatus(userID, issueID, status string, version int64) (issue *Issue, foreignID string, listToUpdate string, err error) {
+	err = l.transaction(func(tx *listManager) error {
+		issue, err = tx.store.GetIssue(issueID)
+		if err != nil {
+			return err
+		}
+		if err = checkVersion(issue, version); err != nil {
+			return err
+		}
+
+		list, ok := issue.ListFor(userID)
+		if !ok || !isOwnedList(list) {
+			return fmt.Errorf("%w: only the todos of your lists can change their status", ErrInvalidTransition)
+		}
+		listToUpdate = list
+		if err = checkTransition(issue.Status, status); err != nil {
+			return err
+		}
+
+		if status == StatusDone {
+			issue, foreignID, _, err = tx.completeIssue(userID, issueID)
+			return err
+		}
+
+		oldStatus := issue.Status
+		issue.Status = status
+		issue.UpdateAt = model.GetMillis()
+		if err = tx.store.UpdateIssue(issue); err != nil {
+			return err
+		}
+
+		// The sender of a shared todo follows the status of each assignee through
+		// GetAssignees instead.
+		foreignID = issue.ForeignUserID
+		if issue.ForeignIssueID != "" && !issue.IsShared() {
+			if err := tx.setForeignIssueStatus(issue.ForeignIssueID, status); err != nil {
+				return err
+			}
+		}
+		return tx.recordAuditLog(issueID, userID, "set_status", oldStatus+" -> "+status)
+	})
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return issue, foreignID, listToUpdate, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetIssueStatus(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	receiverIssueID, err := l.SendIssue(testUserID, testOtherID, "review the PR", "", "", "", 0, 0)
+	require.NoError(t, err)
+	_, _, _, err = l.SetIssueStatus(testOtherID, receiverIssueID, StatusInProgress, 0)
+	assert.ErrorIs(t, err, ErrInvalidTransition, "received todos are accepted first")
+	_, _, err = l.AcceptIssue(testOtherID, receiverIssueID)
+	require.NoError(t, err)
+
+	issue, foreignID, list, err := l.SetIssueStatus(testOtherID, receiverIssueID, StatusInProgress, 0)
+	require.NoError(t, err)
+	assert.Equal(t, StatusInProgress, issue.Status)
+	assert.Equal(t, testUserID, foreignID)
+	assert.Equal(t, MyListKey, list)
+
+	lists, err := l.GetAllList(testOtherID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{receiverIssueID}, issueIDs(lists.My), "todos in progress stay in their list")
+	lists, err = l.GetAllList(testUserID)
+	require.NoError(t, err)
+	require.Len(t, lists.Out, 1)
+	assert.Equal(t, StatusInProgress, lists.Out[0].Status, "the sender sees the progress of the todo")
+
+	_, _, _, err = l.SetIssueStatus(testOtherID, receiverIssueID, StatusBlocked, issue.Version-1)
+	assert.ErrorIs(t, err, ErrConflict)
+	_, _, _, err = l.SetIssueStatus(testUserID, receiverIssueID, StatusBlocked, 0)
+	assert.ErrorIs(t, err, ErrInvalidTransition, "only the owner changes the status")
+
+	issue, _, _, err = l.SetIssueStatus(testOtherID, receiverIssueID, StatusDone, 0)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDone, issue.Status)
+	assert.NotZero(t, issue.CompletedAt)
+	_, _, _, err = l.SetIssueStatus(testOtherID, receiverIssueID, StatusOpen, 0)
+	assert.ErrorIs(t, err, ErrInvalidTransition, "done todos are not reopened through their status")
+	_, _, _, err = l.CompleteIssue(testOtherID, receiverIssueID)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+func TestParseStatusWorkflow(t *testing.T) {
+	transitions, err := parseStatusWorkflow("")
+	require.NoError(t, err)
+	assert.Equal(t, defaultStatusTransitions, transitions, "the default workflow is used when none is configured")
+
+	transitions, err = parseStatusWorkflow(`{"open": ["in_progress"], "in_progress": ["blocked", "done"]}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{StatusInProgress}, transitions[StatusOpen])
+	assert.Equal(t, []string{StatusBlocked, StatusDone}, transitions[StatusInProgress])
+	assert.Equal(t, defaultStatusTransitions[StatusBlocked], transitions[StatusBlocked], "the statuses left out keep their transitions")
+	assert.Equal(t, defaultStatusTransitions[StatusPending], transitions[StatusPending])
+	assert.Equal(t, []string{StatusInProgress, StatusBlocked, StatusDone}, defaultStatusTransitions[StatusOpen], "the default workflow is not changed")
+
+	for _, invalid := range []string{
+		`["open"]`,
+		`{"pending": ["in_progress"]}`,
+		`{"done": ["open"]}`,
+		`{"open": ["declined"]}`,
+		`{"open": ["open"]}`,
+		`{"open": ["started"]}`,
+	} {
+		_, err = parseStatusWorkflow(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSetIssueStatusConfiguredWorkflow(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	transitions, err := parseStatusWorkflow(`{"open": ["in_progress"], "in_progress": ["done"]}`)
+	require.NoError(t, err)
+	setStatusTransitions(transitions)
+	t.Cleanup(func() { setStatusTransitions(defaultStatusTransitions) })
+
+	issue, err := l.AddIssue(testUserID, "write the tests", "", "", "", 0, 0)
+	require.NoError(t, err)
+	_, _, _, err = l.SetIssueStatus(testUserID, issue.ID, StatusDone, 0)
+	assert.ErrorIs(t, err, ErrInvalidTransition, "open todos are started before being done")
+	_, _, _, err = l.CompleteIssue(testUserID, issue.ID)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	_, _, _, err = l.SetIssueStatus(testUserID, issue.ID, StatusInProgress, 0)
+	require.NoError(t, err)
+	issue, _, _, err = l.SetIssueStatus(testUserID, issue.ID, StatusDone, 0)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDone, issue.Status)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// tagNamePattern matches the normalized tag names. Tags start with a letter, so that
+// references like #123 are not taken as tags.
+var tagNamePattern = regexp.MustCompile(`^\p{L}[\p{L}\p{N}_-]{0,63}$`)
+
+// Tag is a label of a user, attached to any number of the user's todos
+type Tag struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	Name      string `json:"name"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// normalizeTagName returns name without its leading # and in lower case
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+}
+
+// isValidTagName returns true if the normalized name can be used as a tag
+func isValidTagName(name string) bool {
+	return tagNamePattern.MatchString(name)
+}
+
+// parseTags removes the #tags from message and returns the remaining message and the
+// normalized names of the tags, without duplicates
+func parseTags(message string) (string, []string) {
+	var words, tags []string
+	seen := map[string]bool{}
+	for _, word := range strings.Fields(message) {
+		name := normalizeTagName(word)
+		if !strings.HasPrefix(word, "#") || !isValidTagName(name) {
+			words = append(words, word)
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			tags = append(tags, name)
+		}
+	}
+	return strings.Join(words, " "), tags
+}
+
+// TagIssue adds the tags of userID named names to issueID, creating the tags that do not
+// exist yet, and returns all the tags of the issue
+func (l *listManager) TagIssue(userID, issueID string, names []string) ([]string, error) {
+	for i, name := range names {
+		names[i] = normalizeTagName(name)
+		if !isValidTagName(names[i]) {
+			return nil, errors.Errorf("invalid tag %q", name)
+		}
+	}
+
+	var tags []string
+	err := l.transaction(func(tx *listManager) error {
+		if _, err := tx.store.GetIssue(issueID); err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			tag, err := tx.getOrCreateTag(userID, name)
+			if err != nil {
+				return err
+			}
+			if err := tx.store.AddIssueTag(issueID, tag.ID); err != nil {
+				return err
+			}
+			if err := tx.recordAuditLog(issueID, userID, "add_tag", name); err != nil {
+				return err
+			}
+		}
+
+		issueTags, err := tx.store.GetIssueTags([]string{issueID})
+		if err != nil {
+			return err
+		}
+		tags = issueTags[issueID]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// UntagIssue removes the tag of userID named name from issueID
+func (l *listManager) UntagIssue(userID, issueID, name string) error {
+	name = normalizeTagName(name)
+	return l.transaction(func(tx *listManager) error {
+		tag, err := tx.store.GetTag(userID, name)
+		if err != nil || tag == nil {
+			return err
+		}
+
+		if err := tx.store.RemoveIssueTag(issueID, tag.ID); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(issueID, userID, "remove_tag", name)
+	})
+}
+
+// GetTags returns the tags of userID, sorted by name
+func (l *listManager) GetTags(userID string) ([]*Tag, error) {
+	return l.store.GetTags(userID)
+}
+
+func (l *listManager) getOrCreateTag(userID, name string) (*Tag, error) {
+	tag, err := l.store.GetTag(userID, name)
+	if err != nil || tag != nil {
+		return tag, err
+	}
+
+	tag = &Tag{UserID: userID, Name: name}
+	if err := l.store.SaveTag(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// addTags sets the tag names of issues
+func (l *listManager) addTags(issues []*ExtendedIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	todoIDs := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		todoIDs = append(todoIDs, issue.ID)
+	}
+
+	tags, err := l.store.GetIssueTags(todoIDs)
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		issue.Tags = tags[issue.ID]
+	}
+	return nil
+}
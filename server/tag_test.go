@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTags(t *testing.T) {
+	message, tags := parseTags("#Work fix issue #123 for #client-a  #work")
+	assert.Equal(t, "fix issue #123 for", message)
+	assert.Equal(t, []string{"work", "client-a"}, tags)
+
+	message, tags = parseTags("no tags here")
+	assert.Equal(t, "no tags here", message)
+	assert.Empty(t, tags)
+}
+
+func TestTagIssue(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	report, err := l.AddIssue(testUserID, "report", "", "", "", 0, 0)
+	require.NoError(t, err)
+	review, err := l.AddIssue(testUserID, "review", "", "", "", 0, 0)
+	require.NoError(t, err)
+
+	tags, err := l.TagIssue(testUserID, report.ID, []string{"#Work", "client"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"client", "work"}, tags)
+	_, err = l.TagIssue(testUserID, review.ID, []string{"work"})
+	require.NoError(t, err)
+	_, err = l.TagIssue(testUserID, review.ID, []string{"#123"})
+	assert.Error(t, err)
+
+	userTags, err := l.GetTags(testUserID)
+	require.NoError(t, err)
+	require.Len(t, userTags, 2, "tags are shared by the todos of the user")
+
+	page, err := l.QueryIssues(testUserID, &IssueQuery{Tag: "client", SortBy: SortByUpdated, PerPage: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Issues, 1)
+	assert.Equal(t, report.ID, page.Issues[0].ID)
+
+	require.NoError(t, l.UntagIssue(testUserID, report.ID, "#client"))
+	myList, err := l.GetIssueList(testUserID, MyListKey)
+	require.NoError(t, err)
+	for _, issue := range myList {
+		assert.Equal(t, []string{"work"}, issue.Tags)
+	}
+}
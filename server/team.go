@@ -0,0 +1,111 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+// teamBoardLimit is the maximum number of todos returned in a team board
+const teamBoardLimit = 200
+
+// teamMembersPerPage is the number of team members read at once to build a team board
+const teamMembersPerPage = 200
+
+// ErrNotTeamAdmin is returned when a user who cannot manage a team asks for its board
+var ErrNotTeamAdmin = errors.New("only the team admins can see the board of the team")
+
+// AssigneeCounts counts the outstanding todos of a user
+type AssigneeCounts struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	// Open counts the todos in the My list and the custom lists of the user
+	Open int `json:"open"`
+	// Overdue counts the open todos past their due date
+	Overdue int `json:"overdue"`
+	// Pending counts the received todos not accepted yet
+	Pending int `json:"pending"`
+}
+
+// TeamBoard is the outstanding todos of the members of a team
+type TeamBoard struct {
+	TeamID string `json:"team_id"`
+	// Issues are the open and pending todos of the members, the ones due first first, up to
+	// teamBoardLimit
+	Issues []*Issue `json:"issues"`
+	// Assignees are the counts of the members with outstanding todos, most overdue first
+	Assignees []*AssigneeCounts `json:"assignees"`
+	// Total sums the counts of all the members
+	Total *AssigneeCounts `json:"total"`
+}
+
+// GetTeamBoard returns the outstanding todos of the members of teamID. Only the users allowed
+// to manage the team can see its board.
+func (l *listManager) GetTeamBoard(userID, teamID string) (*TeamBoard, error) {
+	if !l.api.HasPermissionToTeam(userID, teamID, model.PermissionManageTeam) {
+		return nil, ErrNotTeamAdmin
+	}
+
+	memberIDs, err := l.getTeamMemberIDs(teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := l.store.CountAssignedIssues(memberIDs, model.GetMillis())
+	if err != nil {
+		return nil, err
+	}
+	issues, err := l.store.GetAssignedIssues(memberIDs, teamBoardLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	board := &TeamBoard{
+		TeamID:    teamID,
+		Issues:    issues,
+		Assignees: make([]*AssigneeCounts, 0, len(counts)),
+		Total:     &AssigneeCounts{},
+	}
+	if board.Issues == nil {
+		board.Issues = []*Issue{}
+	}
+	for _, c := range counts {
+		c.Username = l.GetUserName(c.UserID)
+		board.Assignees = append(board.Assignees, c)
+		board.Total.Open += c.Open
+		board.Total.Overdue += c.Overdue
+		board.Total.Pending += c.Pending
+	}
+	sort.Slice(board.Assignees, func(i, j int) bool {
+		a, b := board.Assignees[i], board.Assignees[j]
+		if a.Overdue != b.Overdue {
+			return a.Overdue > b.Overdue
+		}
+		if a.Open != b.Open {
+			return a.Open > b.Open
+		}
+		return a.Username < b.Username
+	})
+
+	return board, nil
+}
+
+// getTeamMemberIDs returns the IDs of the active members of teamID
+func (l *listManager) getTeamMemberIDs(teamID string) ([]string, error) {
+	var userIDs []string
+	for page := 0; ; page++ {
+		members, appErr := l.api.GetTeamMembers(teamID, page, teamMembersPerPage)
+		if appErr != nil {
+			return nil, errors.Wrap(appErr, "failed to get the team members")
+		}
+		for _, member := range members {
+			if member.DeleteAt == 0 {
+				userIDs = append(userIDs, member.UserId)
+			}
+		}
+		if len(members) < teamMembersPerPage {
+			return userIDs, nil
+		}
+	}
+}
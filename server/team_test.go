@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTeamBoard(t *testing.T) {
+	l, _ := setupTestListManager(t)
+
+	const teamID = "testteamid0000000000000000"
+	api := l.api.(*plugintest.API)
+	api.On("HasPermissionToTeam", testUserID, teamID, model.PermissionManageTeam).Return(true)
+	api.On("HasPermissionToTeam", testOtherID, teamID, model.PermissionManageTeam).Return(false)
+	api.On("GetTeamMembers", teamID, 0, teamMembersPerPage).Return([]*model.TeamMember{
+		{TeamId: teamID, UserId: testUserID},
+		{TeamId: teamID, UserId: testOtherID},
+		{TeamId: teamID, UserId: testThirdID, DeleteAt: 1},
+	}, nil)
+
+	_, err := l.AddIssue(testUserID, "plan the sprint", "", "", "", 0, 0)
+	require.NoError(t, err)
+	_, err = l.AddIssue(testOtherID, "fix the build", "", "", "", 1, 0)
+	require.NoError(t, err)
+	_, err = l.SendIssue(testUserID, testOtherID, "review the PR", "", "", "", 0, 0)
+	require.NoError(t, err)
+	_, err = l.AddIssue(testThirdID, "left the team", "", "", "", 0, 0)
+	require.NoError(t, err)
+
+	_, err = l.GetTeamBoard(testOtherID, teamID)
+	assert.ErrorIs(t, err, ErrNotTeamAdmin)
+
+	board, err := l.GetTeamBoard(testUserID, teamID)
+	require.NoError(t, err)
+	assert.Len(t, board.Issues, 3, "the sent copies and the todos of former members are not on the board")
+	require.Len(t, board.Assignees, 2)
+	assert.Equal(t, &AssigneeCounts{UserID: testOtherID, Username: "user_" + testOtherID[:4], Open: 1, Overdue: 1, Pending: 1}, board.Assignees[0])
+	assert.Equal(t, &AssigneeCounts{UserID: testUserID, Username: "user_" + testUserID[:4], Open: 1}, board.Assignees[1])
+	assert.Equal(t, &AssigneeCounts{Open: 2, Overdue: 1, Pending: 1}, board.Total)
+}
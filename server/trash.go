@@ -0,0 +1,41 @@
+package main
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// trashPurgeJobName is the name of the background job emptying the trash
+	trashPurgeJobName = "trash_purge"
+	// trashPurgeInterval is how often the job deletes the todos past the trash retention
+	trashPurgeInterval = time.Hour
+)
+
+// purgeTrash permanently deletes the todos that have been in the trash for longer than the
+// configured retention, with their comments and, if configured, their audit log.
+func (p *Plugin) purgeTrash() error {
+	config := p.getConfiguration()
+	if config.TrashRetentionDays <= 0 {
+		return nil
+	}
+
+	retention := time.Duration(config.TrashRetentionDays) * 24 * time.Hour
+	deletedBefore := model.GetMillis() - retention.Milliseconds()
+
+	var purged int
+	err := p.store.Transaction(func(store ListStore) error {
+		var err error
+		purged, err = store.PurgeTrash(deletedBefore, config.TrashPurgeAuditLog)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if purged > 0 {
+		p.API.LogInfo("Purged todos from the trash", "count", purged)
+	}
+	return nil
+}
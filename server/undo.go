@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+// undoWindow is how long after an action it can be undone
+const undoWindow = 10 * time.Minute
+
+// ErrNothingToUndo is returned when the user has no action left to undo within the undo window
+var ErrNothingToUndo = errors.New("there is nothing to undo")
+
+// issueSnapshot is the state of a todo before an action, recorded in the audit log so the
+// action can be undone
+type issueSnapshot struct {
+	Issue *Issue `json:"issue"`
+	// Version is the version of the todo after the action. The action is only undone while
+	// the todo is still at that version, so later changes are never overwritten.
+	Version int64 `json:"version"`
+	// Created is true if the todo was created by the action, in which case undoing the
+	// action moves it to the trash
+	Created bool `json:"created,omitempty"`
+}
+
+// snapshotIssues returns a copy of the issues, to be passed to recordUndoableAuditLog once
+// they are changed. Empty and unknown IDs are skipped.
+func (l *listManager) snapshotIssues(issueIDs ...string) []*Issue {
+	var issues []*Issue
+	for _, issueID := range issueIDs {
+		if issueID == "" {
+			continue
+		}
+		if issue, err := l.store.GetIssue(issueID); err == nil {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// recordUndoableAuditLog records the action like recordAuditLog, along with the state of the
+// issues before it and the issues it created, so that UndoLastAction can revert it. It must
+// be called after the issues are changed.
+func (l *listManager) recordUndoableAuditLog(todoID, userID, action, metadata string, before []*Issue, created ...*Issue) error {
+	snapshots := make([]*issueSnapshot, 0, len(before)+len(created))
+	for _, issue := range before {
+		current, err := l.store.GetIssue(issue.ID)
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, &issueSnapshot{Issue: issue, Version: current.Version})
+	}
+	for _, issue := range created {
+		current, err := l.store.GetIssue(issue.ID)
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, &issueSnapshot{Issue: current, Version: current.Version, Created: true})
+	}
+
+	snapshot, err := json.Marshal(snapshots)
+	if err != nil {
+		return err
+	}
+
+	return l.addAuditLog(&AuditLog{
+		TodoID:   todoID,
+		UserID:   userID,
+		Action:   action,
+		Metadata: metadata,
+		Snapshot: string(snapshot),
+	})
+}
+
+// getUndoableAuditLog returns the audit log of the last action of userID if it was recorded
+// with recordUndoableAuditLog in the undo window, or nil. An action is no longer undoable
+// once the user did anything else, including undoing it.
+func (l *listManager) getUndoableAuditLog(userID string) (*AuditLog, error) {
+	log, err := l.store.GetLastAuditLog(userID, model.GetMillis()-undoWindow.Milliseconds())
+	if err != nil || log == nil || log.Snapshot == "" {
+		return nil, err
+	}
+	return log, nil
+}
+
+// GetUndoableAction returns the ID of the audit log of the last action of userID if it can
+// still be undone, or an empty string.
+func (l *listManager) GetUndoableAction(userID string) (string, error) {
+	log, err := l.getUndoableAuditLog(userID)
+	if err != nil || log == nil {
+		return "", err
+	}
+	return log.ID, nil
+}
+
+// UndoLastAction reverts the last action of userID if it was recorded with
+// recordUndoableAuditLog in the undo window, and returns its audit log and the reverted
+// issues. If logID is not empty, the action is only reverted if its audit log is logID. It
+// returns ErrConflict if one of the issues changed since the action.
+func (l *listManager) UndoLastAction(userID, logID string) (log *AuditLog, issues []*Issue, err error) {
+	err = l.transaction(func(tx *listManager) error {
+		log, err = tx.getUndoableAuditLog(userID)
+		if err != nil {
+			return err
+		}
+		if log == nil || (logID != "" && log.ID != logID) {
+			return ErrNothingToUndo
+		}
+
+		var snapshots []*issueSnapshot
+		if err = json.Unmarshal([]byte(log.Snapshot), &snapshots); err != nil {
+			return errors.Wrap(err, "invalid audit log snapshot")
+		}
+
+		for _, snapshot := range snapshots {
+			current, err := tx.store.GetIssue(snapshot.Issue.ID)
+			if err != nil || current.Version != snapshot.Version {
+				return ErrConflict
+			}
+
+			if snapshot.Created {
+				trashed, err := tx.trashIssue(current.ID)
+				if err != nil {
+					return err
+				}
+				issues = append(issues, trashed)
+				continue
+			}
+
+			issue := snapshot.Issue
+			issue.Version = current.Version
+			if err := tx.store.UpdateIssue(issue); err != nil {
+				return err
+			}
+			issues = append(issues, issue)
+		}
+
+		return tx.recordAuditLog(log.TodoID, userID, "undo", log.ID)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return log, issues, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// watchersLimit is the maximum number of watchers of a todo
+const watchersLimit = 50
+
+// ErrInvalidWatcher is wrapped by the errors of watchers that cannot be added
+var ErrInvalidWatcher = errors.New("invalid watcher")
+
+// Watcher is a user notified about the changes of a todo they are not working on
+type Watcher struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// watchedIssueID returns the ID of the todo the watchers of issue are recorded on. The copies
+// of a sent todo share the watchers of the sender's copy.
+func watchedIssueID(issue *Issue) string {
+	if issue.ForeignIssueID != "" && !issue.IsSenderCopy() {
+		return issue.ForeignIssueID
+	}
+	return issue.ID
+}
+
+// Watch makes watcherID a watcher of issueID, on behalf of userID. The watcher must be an
+// active user who can see the todo already, such as a member of the channel of a channel
+// todo. The creator and the assignee of the todo are notified already, and cannot watch it.
+func (l *listManager) Watch(userID, issueID, watcherID string) error {
+	return l.transaction(func(tx *listManager) error {
+		issue, err := tx.store.GetIssue(issueID)
+		if err != nil {
+			return err
+		}
+		if issue.CreatorID == watcherID || issue.AssigneeID == watcherID {
+			return fmt.Errorf("%w: the creator and the assignee of a todo are notified already", ErrInvalidWatcher)
+		}
+		if err = tx.checkWatcher(issue, watcherID); err != nil {
+			return err
+		}
+
+		todoID := watchedIssueID(issue)
+		watchers, err := tx.store.GetWatchers(todoID)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(watchers, watcherID) {
+			return nil
+		}
+		if len(watchers) >= watchersLimit {
+			return fmt.Errorf("%w: a todo cannot have more than %d watchers", ErrInvalidWatcher, watchersLimit)
+		}
+
+		if err := tx.store.AddWatcher(todoID, watcherID); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(issueID, userID, "watch", watcherID)
+	})
+}
+
+// checkWatcher returns an error wrapping ErrInvalidWatcher if watcherID cannot watch issue
+func (l *listManager) checkWatcher(issue *Issue, watcherID string) error {
+	watcher, appErr := l.api.GetUser(watcherID)
+	if appErr != nil {
+		if appErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: the user does not exist", ErrInvalidWatcher)
+		}
+		return errors.Wrap(appErr, "failed to get the watcher")
+	}
+	if watcher.DeleteAt != 0 || watcher.IsBot {
+		return fmt.Errorf("%w: only active users can watch a todo", ErrInvalidWatcher)
+	}
+	// Watching a todo lets the watcher see it, so it must not grant access to users who could
+	// not see the todo already.
+	if issue.ChannelID != "" {
+		if !l.isChannelMember(issue.ChannelID, watcherID) {
+			return fmt.Errorf("%w: only the members of the channel can watch a channel todo", ErrInvalidWatcher)
+		}
+		return nil
+	}
+	if !watcher.IsSystemAdmin() {
+		return fmt.Errorf("%w: only the users who can see a todo can watch it", ErrInvalidWatcher)
+	}
+	return nil
+}
+
+// Unwatch stops watcherID from watching issueID, on behalf of userID
+func (l *listManager) Unwatch(userID, issueID, watcherID string) error {
+	return l.transaction(func(tx *listManager) error {
+		issue, err := tx.store.GetIssue(issueID)
+		if err != nil {
+			return err
+		}
+		if err := tx.store.RemoveWatcher(watchedIssueID(issue), watcherID); err != nil {
+			return err
+		}
+		return tx.recordAuditLog(issueID, userID, "unwatch", watcherID)
+	})
+}
+
+// GetWatchers returns the watchers of issueID, which is any copy of the todo
+func (l *listManager) GetWatchers(issueID string) ([]*Watcher, error) {
+	issue, err := l.store.GetIssue(issueID)
+	if err != nil {
+		return nil, err
+	}
+	userIDs, err := l.store.GetWatchers(watchedIssueID(issue))
+	if err != nil {
+		return nil, err
+	}
+
+	watchers := make([]*Watcher, 0, len(userIDs))
+	for _, userID := range userIDs {
+		watchers = append(watchers, &Watcher{UserID: userID, Username: l.GetUserName(userID)})
+	}
+	return watchers, nil
+}
+
+// isWatcher returns true if userID watches issue
+func (l *listManager) isWatcher(issue *Issue, userID string) (bool, error) {
+	userIDs, err := l.store.GetWatchers(watchedIssueID(issue))
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(userIDs, userID), nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchers(t *testing.T) {
+	const adminID = "testadminid000000000000000"
+	api := &plugintest.API{}
+	api.On("GetUser", mock.AnythingOfType("string")).Return(func(userID string) *model.User {
+		user := &model.User{Id: userID, Username: "user_" + userID[:4], Roles: model.SystemUserRoleId}
+		if userID == adminID {
+			user.Roles = model.SystemAdminRoleId + " " + model.SystemUserRoleId
+		}
+		return user
+	}, nil)
+	l := &listManager{store: NewMemoryStore(), api: api}
+
+	receiverIssueID, err := l.SendIssue(testUserID, testOtherID, "review the PR", "", "", "", 0, 0)
+	require.NoError(t, err)
+	receiverIssue, err := l.store.GetIssue(receiverIssueID)
+	require.NoError(t, err)
+	senderIssueID := receiverIssue.ForeignIssueID
+
+	err = l.Watch(testUserID, senderIssueID, testOtherID)
+	assert.ErrorIs(t, err, ErrInvalidWatcher, "the assignee is notified already")
+
+	err = l.Watch(testUserID, senderIssueID, testThirdID)
+	assert.ErrorIs(t, err, ErrInvalidWatcher, "watching a todo does not grant access to it")
+	authorized, err := l.IsAuthorizedToRead(receiverIssueID, testThirdID)
+	require.NoError(t, err)
+	assert.False(t, authorized)
+
+	require.NoError(t, l.Watch(testUserID, senderIssueID, adminID))
+	require.NoError(t, l.Watch(testOtherID, receiverIssueID, adminID))
+
+	watchers, err := l.GetWatchers(receiverIssueID)
+	require.NoError(t, err)
+	require.Len(t, watchers, 1, "the copies of a sent todo share their watchers")
+	assert.Equal(t, adminID, watchers[0].UserID)
+
+	require.NoError(t, l.Unwatch(adminID, receiverIssueID, adminID))
+	watchers, err = l.GetWatchers(senderIssueID)
+	require.NoError(t, err)
+	assert.Empty(t, watchers)
+}
+
+func TestWatchInvalidUser(t *testing.T) {
+	const (
+		channelID = "testchannelid0000000000000"
+		unknownID = "testunknownid0000000000000"
+		botID     = "testbotid00000000000000000"
+		memberID  = "testmemberid00000000000000"
+	)
+	api := &plugintest.API{}
+	api.On("GetUser", testOtherID).Return(&model.User{Id: testOtherID, Username: "other", DeleteAt: 1}, nil)
+	api.On("GetUser", testThirdID).Return(&model.User{Id: testThirdID, Username: "third"}, nil)
+	api.On("GetUser", botID).Return(&model.User{Id: botID, Username: "bot", IsBot: true}, nil)
+	api.On("GetUser", memberID).Return(&model.User{Id: memberID, Username: "member"}, nil)
+	api.On("GetUser", unknownID).Return(nil, model.NewAppError("GetUser", "not_found", nil, "", http.StatusNotFound))
+	api.On("GetUser", testUserID).Return(&model.User{Id: testUserID, Username: "user"}, nil).Maybe()
+	api.On("GetChannelMember", channelID, testUserID).Return(&model.ChannelMember{}, nil)
+	api.On("GetChannelMember", channelID, testThirdID).Return(nil, model.NewAppError("GetChannelMember", "not_found", nil, "", http.StatusNotFound))
+	api.On("GetChannelMember", channelID, memberID).Return(&model.ChannelMember{}, nil)
+	api.On("LogError", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	l := &listManager{store: NewMemoryStore(), api: api}
+
+	issue, err := l.AddIssue(testUserID, "write the docs", "", "", "", 0, 0)
+	require.NoError(t, err)
+	assert.ErrorIs(t, l.Watch(testUserID, issue.ID, unknownID), ErrInvalidWatcher)
+	assert.ErrorIs(t, l.Watch(testUserID, issue.ID, testOtherID), ErrInvalidWatcher, "deactivated users cannot watch")
+	assert.ErrorIs(t, l.Watch(testUserID, issue.ID, botID), ErrInvalidWatcher)
+	assert.ErrorIs(t, l.Watch(testUserID, issue.ID, testThirdID), ErrInvalidWatcher,
+		"only the users working on a personal todo can see it")
+
+	channelIssue, err := l.AddChannelIssue(testUserID, channelID, "tag the release", "")
+	require.NoError(t, err)
+	assert.ErrorIs(t, l.Watch(testUserID, channelIssue.ID, testThirdID), ErrInvalidWatcher,
+		"the watchers of a channel todo must be able to see it")
+	require.NoError(t, l.Watch(testUserID, channelIssue.ID, memberID))
+}
+
+func TestDueDateChangedNotifiesSanitizedMessage(t *testing.T) {
+	p, api := setupTestPlugin(t)
+	api.On("GetChannelMember", "channel", mock.AnythingOfType("string")).Return(&model.ChannelMember{}, nil)
+
+	issue, err := p.listManager.AddChannelIssue(testUserID, "channel", "ship it", "")
+	require.NoError(t, err)
+	require.NoError(t, p.listManager.Watch(testUserID, issue.ID, testThirdID))
+	issue, err = p.store.GetIssue(issue.ID)
+	require.NoError(t, err)
+
+	w := doTestRequest(t, p, http.MethodPut, "/edit", testUserID, EditAPIRequest{
+		ID:      issue.ID,
+		Message: "<b>ship it</b>",
+		DueAt:   time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC).UnixMilli(),
+		Version: issue.Version,
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var messages []string
+	for _, call := range api.Calls {
+		if call.Method == "CreatePost" {
+			messages = append(messages, call.Arguments.Get(0).(*model.Post).Message)
+		}
+	}
+	require.Len(t, messages, 1)
+	assert.Contains(t, messages[0], "March 10, 2024: ship it")
+	assert.NotContains(t, messages[0], "<b>")
+}
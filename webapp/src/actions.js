@@ -61,10 +61,11 @@ export const removeAssignee = () => (dispatch) => {
     });
 };
 
-export const setEditingTodo = (issueID) => (dispatch) => {
+export const setEditingTodo = (issueID, version) => (dispatch) => {
     dispatch({
         type: SET_EDITING_TODO,
         issueID,
+        version,
     });
 };
 
@@ -125,18 +126,28 @@ export const add = (message, postPermalink, description, sendTo, postID, dueAt,
     }));
 };
 
-export const editIssue = (id, message, description, dueAt, priority) => async (dispatch, getState) => {
-    await fetch(getPluginServerRoute(getState()) + '/edit', Client4.getOptions({
+export const editIssue = (id, message, description, dueAt, priority, version) => async (dispatch, getState) => {
+    const resp = await fetch(getPluginServerRoute(getState()) + '/edit', Client4.getOptions({
         method: 'put',
-        body: JSON.stringify({ id, message, description, due_at: dueAt, priority }),
+        body: JSON.stringify({ id, message, description, due_at: dueAt, priority, version }),
     }));
+
+    // The todo was changed by someone else in the meantime: show its current state.
+    if (resp.status === 409) {
+        dispatch(fetchAllIssueLists());
+    }
 };
 
-export const changeAssignee = (id, assignee) => async (dispatch, getState) => {
-    await fetch(getPluginServerRoute(getState()) + '/change_assignment', Client4.getOptions({
+export const changeAssignee = (id, assignee, version) => async (dispatch, getState) => {
+    const resp = await fetch(getPluginServerRoute(getState()) + '/change_assignment', Client4.getOptions({
         method: 'post',
-        body: JSON.stringify({ id, send_to: assignee }),
+        body: JSON.stringify({ id, send_to: assignee, version }),
     }));
+
+    // The todo was changed by someone else in the meantime: show its current state.
+    if (resp.status === 409) {
+        dispatch(fetchAllIssueLists());
+    }
 };
 
 export const fetchAllIssueLists = (reminder = false) => async (dispatch, getState) => {
@@ -179,6 +190,13 @@ export const accept = (id) => async (dispatch, getState) => {
     }));
 };
 
+export const decline = (id, reason) => async (dispatch, getState) => {
+    await fetch(getPluginServerRoute(getState()) + '/decline', Client4.getOptions({
+        method: 'post',
+        body: JSON.stringify({ id, reason }),
+    }));
+};
+
 export const bump = (id) => async (dispatch, getState) => {
     await fetch(getPluginServerRoute(getState()) + '/bump', Client4.getOptions({
         method: 'post',
@@ -25,7 +25,7 @@ const AssigneeForm = (
     useEscapeKey(close);
     const submit = useCallback(() => {
         if (editingTodo && assignee) {
-            changeAssignee(editingTodo, assignee.username);
+            changeAssignee(editingTodo.id, assignee.username, editingTodo.version);
             removeEditingTodo();
         } else if (assignee) {
             getAssignee(assignee);
@@ -96,7 +96,10 @@ AssigneeForm.propTypes = {
     theme: PropTypes.object.isRequired,
     autocompleteUsers: PropTypes.func.isRequired,
     getAssignee: PropTypes.func.isRequired,
-    editingTodo: PropTypes.string.isRequired,
+    editingTodo: PropTypes.shape({
+        id: PropTypes.string.isRequired,
+        version: PropTypes.number.isRequired,
+    }),
     removeAssignee: PropTypes.func.isRequired,
     removeEditingTodo: PropTypes.func.isRequired,
     changeAssignee: PropTypes.func.isRequired,
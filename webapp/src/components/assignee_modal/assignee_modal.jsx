@@ -40,7 +40,10 @@ AssigneeModal.propTypes = {
     theme: PropTypes.object.isRequired,
     autocompleteUsers: PropTypes.func.isRequired,
     getAssignee: PropTypes.func.isRequired,
-    editingTodo: PropTypes.string.isRequired,
+    editingTodo: PropTypes.shape({
+        id: PropTypes.string.isRequired,
+        version: PropTypes.number.isRequired,
+    }),
     removeAssignee: PropTypes.func.isRequired,
     removeEditingTodo: PropTypes.func.isRequired,
     changeAssignee: PropTypes.func.isRequired,
@@ -0,0 +1,52 @@
+import React, {useState} from 'react';
+import PropTypes from 'prop-types';
+
+import Button from 'src/widget/buttons/button';
+
+const DeclineButton = (props) => {
+    const [askReason, setAskReason] = useState(false);
+    const [reason, setReason] = useState('');
+
+    if (!askReason) {
+        return (
+            <Button
+                emphasis='tertiary'
+                onClick={() => setAskReason(true)}
+            >
+                {'Decline'}
+            </Button>
+        );
+    }
+
+    return (
+        <div className='d-flex align-items-center'>
+            <input
+                className='form-control'
+                placeholder='Reason (optional)'
+                value={reason}
+                autoFocus={true}
+                onChange={(e) => setReason(e.target.value)}
+                onKeyDown={(e) => {
+                    if (e.key === 'Enter') {
+                        props.decline(props.issueId, reason);
+                    } else if (e.key === 'Escape') {
+                        setAskReason(false);
+                    }
+                }}
+            />
+            <Button
+                emphasis='tertiary'
+                onClick={() => props.decline(props.issueId, reason)}
+            >
+                {'Decline'}
+            </Button>
+        </div>
+    );
+};
+
+DeclineButton.propTypes = {
+    issueId: PropTypes.string.isRequired,
+    decline: PropTypes.func.isRequired,
+};
+
+export default DeclineButton;
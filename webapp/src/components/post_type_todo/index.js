@@ -4,16 +4,21 @@
 import {connect} from 'react-redux';
 import {bindActionCreators} from 'redux';
 
-import {remove, complete, accept, telemetry} from '../../actions';
+import {remove, complete, accept, decline, telemetry} from '../../actions';
 import {getInIssues, getSiteURL} from '../../selectors';
 
 import PostTypeTodo from './post_type_todo';
 
 function mapStateToProps(state, ownProps) {
+    const issue = getInIssues(state).find((inIssue) => inIssue.id === ownProps.post.props.issueId);
+
     return {
         ...ownProps,
         siteURL: getSiteURL(state),
-        pendingAnswer: getInIssues(state).some((issue) => issue.id === ownProps.post.props.issueId),
+        pendingAnswer: Boolean(issue),
+
+        // Only the receiver of a todo can decline it, until they answer.
+        canDecline: Boolean(issue) && issue.status === 'pending' && issue.assignee_id === state.entities.users.currentUserId,
     };
 }
 
@@ -23,6 +28,7 @@ function mapDispatchToProps(dispatch) {
             remove,
             complete,
             accept,
+            decline,
             telemetry,
         }, dispatch),
     };
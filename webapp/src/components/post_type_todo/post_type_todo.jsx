@@ -6,6 +6,7 @@ import {makeStyleFromTheme} from 'mattermost-redux/utils/theme_utils';
 import RemoveButton from '../buttons/remove';
 import CompleteButton from '../buttons/complete';
 import AcceptButton from '../buttons/accept';
+import DeclineButton from '../buttons/decline';
 
 import PostPermalink from '../todo_item/post_permalink';
 
@@ -15,12 +16,14 @@ export default class PostTypeTodo extends React.PureComponent {
     static propTypes = {
         post: PropTypes.object.isRequired,
         pendingAnswer: PropTypes.bool.isRequired,
+        canDecline: PropTypes.bool.isRequired,
         theme: PropTypes.object.isRequired,
         siteURL: PropTypes.string.isRequired,
         actions: PropTypes.shape({
             complete: PropTypes.func.isRequired,
             remove: PropTypes.func.isRequired,
             accept: PropTypes.func.isRequired,
+            decline: PropTypes.func.isRequired,
             telemetry: PropTypes.func.isRequired,
         }).isRequired,
     };
@@ -49,6 +52,15 @@ export default class PostTypeTodo extends React.PureComponent {
                 className={`todo-post d-flex flex-row-reverse align-items-center justify-content-end ${this.state.done ? 'todo-item--done' : ''}`}
                 style={style.body}
             >
+                {this.props.canDecline &&
+                    <DeclineButton
+                        issueId={this.props.post.props.issueId}
+                        decline={(issueID, reason) => {
+                            this.props.actions.telemetry('custom_post_decline');
+                            this.props.actions.decline(issueID, reason);
+                        }}
+                    />
+                }
                 <RemoveButton
                     issueId={this.props.post.props.issueId}
                     remove={(issueID) => {
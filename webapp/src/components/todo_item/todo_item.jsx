@@ -103,7 +103,10 @@ function TodoItem(props) {
     let listPositionMessage = '';
     let createdMessage = 'Created ';
     if (issue.user) {
-        if (issue.list === '') {
+        if (issue.status === 'declined') {
+            createdMessage = 'Sent to ' + issue.user;
+            listPositionMessage = 'Declined.';
+        } else if (issue.list === '') {
             createdMessage = 'Sent to ' + issue.user;
             listPositionMessage =
                 'Accepted. On position ' + (issue.position + 1) + '.';
@@ -194,12 +197,12 @@ function TodoItem(props) {
     const saveEditedTodo = () => {
         setEditTodo(false);
         const dueAtTimestamp = dueAt ? new Date(dueAt).getTime() : 0;
-        editIssue(issue.id, message, description, dueAtTimestamp, priority);
+        editIssue(issue.id, message, description, dueAtTimestamp, priority, issue.version);
     };
 
     const editAssignee = () => {
         openAssigneeModal('');
-        setEditingTodo(issue.id);
+        setEditingTodo(issue.id, issue.version);
     };
 
     return (
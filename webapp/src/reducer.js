@@ -54,7 +54,7 @@ const todoToast = (state = null, action) => {
 const editingTodo = (state = null, action) => {
     switch (action.type) {
     case SET_EDITING_TODO:
-        return action.issueID;
+        return {id: action.issueID, version: action.version};
     case REMOVE_EDITING_TODO:
         return null;
     default: